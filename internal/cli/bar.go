@@ -0,0 +1,392 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// barIcon is the default bar text (nf-md-vpn).
+const barIcon = "󰖂"
+
+// Bar colors per state, for formats that color the text themselves.
+var barColors = map[string]string{
+	"connected":    "#8bc34a",
+	"warning":      "#ffc107",
+	"disconnected": "#888888",
+	"error":        "#f44336",
+}
+
+// barSample is the counter reading a rate is computed from.
+type barSample struct {
+	at     time.Time
+	rx, tx uint64
+}
+
+// barSampleDoc is the saved form of a barSample.
+type barSampleDoc struct {
+	At time.Time `json:"at"`
+	Rx uint64    `json:"rx"`
+	Tx uint64    `json:"tx"`
+}
+
+// barSampleMaxAge is how old a saved reading may be to compute rates
+// from; over longer spans the average says little about the current rate.
+const barSampleMaxAge = time.Minute
+
+// barMonitor turns successive status reads into bar states. It keeps the
+// previous counters of each interface, so rates are known from the second
+// reading on.
+type barMonitor struct {
+	stale     time.Duration // handshakes older than this are a warning
+	prev      map[string]barSample
+	measuring bool // another reading will follow, so missing rates are coming
+}
+
+func newBarMonitor(stale time.Duration) *barMonitor {
+	return &barMonitor{stale: stale, prev: make(map[string]barSample), measuring: true}
+}
+
+// barStateFile is where single bar runs keep their last reading, so the
+// next run can compute rates: $XDG_RUNTIME_DIR/wireguard-tui-bar.json, or
+// "" when XDG_RUNTIME_DIR is unset and nothing is kept.
+func barStateFile() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "wireguard-tui-bar.json")
+}
+
+// load restores the readings saved at path that are recent enough at now.
+// A missing or unreadable file just leaves nothing to compare against.
+func (m *barMonitor) load(path string, now time.Time) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var saved map[string]barSampleDoc
+	if json.Unmarshal(data, &saved) != nil {
+		return
+	}
+	for name, d := range saved {
+		if age := now.Sub(d.At); age > 0 && age <= barSampleMaxAge {
+			m.prev[name] = barSample{at: d.At, rx: d.Rx, tx: d.Tx}
+		}
+	}
+}
+
+// save writes the current readings to path, replacing it atomically so
+// bars running side by side never read half a file.
+func (m *barMonitor) save(path string) error {
+	saved := make(map[string]barSampleDoc, len(m.prev))
+	for name, p := range m.prev {
+		saved[name] = barSampleDoc{At: p.at, Rx: p.rx, Tx: p.tx}
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// barIface is what the bar shows about one active interface.
+type barIface struct {
+	name           string
+	measured       bool    // rates are known
+	rxRate, txRate float64 // bits per second
+	handshake      time.Time
+	hasPeers       bool
+}
+
+// barState is one bar update.
+type barState struct {
+	ifaces    []barIface
+	err       error
+	now       time.Time
+	stale     time.Duration
+	measuring bool // unmeasured rates will be known on the next update
+}
+
+// class is the state name used for CSS classes and colors.
+func (s barState) class() string {
+	switch {
+	case s.err != nil:
+		return "error"
+	case len(s.ifaces) == 0:
+		return "disconnected"
+	}
+	for _, i := range s.ifaces {
+		if s.isStale(i) {
+			return "warning"
+		}
+	}
+	return "connected"
+}
+
+// isStale reports whether an interface's newest handshake is missing or
+// older than the threshold. Interfaces without peers never handshake and
+// are not flagged.
+func (s barState) isStale(i barIface) bool {
+	return i.hasPeers && (i.handshake.IsZero() || s.now.Sub(i.handshake) > s.stale)
+}
+
+// update records a status reading taken at now. A counter that went
+// backwards (the interface was recreated) restarts the measurement.
+func (m *barMonitor) update(now time.Time, all []*wg.InterfaceStatus, err error) barState {
+	state := barState{err: err, now: now, stale: m.stale, measuring: m.measuring}
+	if err != nil {
+		return state
+	}
+
+	seen := make(map[string]bool, len(all))
+	for _, s := range all {
+		bi := barIface{name: s.Name, hasPeers: len(s.Peers) > 0}
+		var cur barSample
+		cur.at = now
+		for _, p := range s.Peers {
+			cur.rx += p.TransferRx
+			cur.tx += p.TransferTx
+			if p.LatestHandshake.After(bi.handshake) {
+				bi.handshake = p.LatestHandshake
+			}
+		}
+
+		if prev, ok := m.prev[s.Name]; ok && cur.rx >= prev.rx && cur.tx >= prev.tx {
+			if secs := now.Sub(prev.at).Seconds(); secs > 0 {
+				bi.measured = true
+				bi.rxRate = float64(cur.rx-prev.rx) * 8 / secs
+				bi.txRate = float64(cur.tx-prev.tx) * 8 / secs
+			}
+		}
+		m.prev[s.Name] = cur
+		seen[s.Name] = true
+		state.ifaces = append(state.ifaces, bi)
+	}
+	for name := range m.prev {
+		if !seen[name] {
+			delete(m.prev, name)
+		}
+	}
+	return state
+}
+
+// formatRate renders bits per second like "1.2 Mbps".
+func formatRate(bps float64) string {
+	switch {
+	case bps >= 1e6:
+		return fmt.Sprintf("%.1f Mbps", bps/1e6)
+	case bps >= 1e3:
+		return fmt.Sprintf("%.0f Kbps", bps/1e3)
+	}
+	return fmt.Sprintf("%.0f bps", bps)
+}
+
+// formatAge renders a duration like "3m 12s ago".
+func formatAge(d time.Duration) string {
+	secs := int(d.Seconds())
+	switch {
+	case secs >= 3600:
+		return fmt.Sprintf("%dh %dm ago", secs/3600, secs%3600/60)
+	case secs >= 60:
+		return fmt.Sprintf("%dm %ds ago", secs/60, secs%60)
+	}
+	return fmt.Sprintf("%ds ago", max(secs, 0))
+}
+
+// text is the short bar label: the icon, followed by the interface count
+// when more than one is up, or the names when verbose.
+func (s barState) text(icon string, verbose bool) string {
+	switch {
+	case s.err != nil:
+		return icon + " !"
+	case !verbose && len(s.ifaces) > 1:
+		return fmt.Sprintf("%s %d", icon, len(s.ifaces))
+	case !verbose || len(s.ifaces) == 0:
+		return icon
+	}
+
+	parts := []string{icon}
+	for _, i := range s.ifaces {
+		part := i.name
+		if i.measured {
+			part += fmt.Sprintf(" ↓%s ↑%s", formatRate(i.rxRate), formatRate(i.txRate))
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// tooltip is the multi-line detail: rates and handshake age per interface.
+func (s barState) tooltip() string {
+	if s.err != nil {
+		return "WireGuard: " + s.err.Error()
+	}
+	if len(s.ifaces) == 0 {
+		return "WireGuard: No active tunnels"
+	}
+
+	names := make([]string, len(s.ifaces))
+	for i, bi := range s.ifaces {
+		names[i] = bi.name
+	}
+	lines := []string{"WireGuard: " + strings.Join(names, ", ")}
+	for _, i := range s.ifaces {
+		switch {
+		case i.measured:
+			lines = append(lines, fmt.Sprintf("%s  ↓ %s  ↑ %s", i.name, formatRate(i.rxRate), formatRate(i.txRate)))
+		case s.measuring:
+			lines = append(lines, i.name+"  measuring...")
+		default:
+			lines = append(lines, i.name)
+		}
+		switch {
+		case !i.hasPeers:
+		case i.handshake.IsZero():
+			lines = append(lines, "  ⚠ no handshake yet")
+		case s.isStale(i):
+			lines = append(lines, "  ⚠ last handshake: "+formatAge(s.now.Sub(i.handshake)))
+		default:
+			lines = append(lines, "  ✓ last handshake: "+formatAge(s.now.Sub(i.handshake)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// barFormat renders bar states for one kind of status bar.
+type barFormat struct {
+	render func(s barState, icon string) (string, error)
+
+	// header is written once before the first update in --watch mode, and
+	// frame, if set, wraps each update line there.
+	header string
+	frame  func(line string, first bool) string
+}
+
+var barFormats = map[string]barFormat{
+	// Waybar custom module with "return-type": "json".
+	"waybar": {render: func(s barState, icon string) (string, error) {
+		out, err := json.Marshal(map[string]string{
+			"text":    s.text(icon, false),
+			"tooltip": s.tooltip(),
+			"class":   s.class(),
+			"alt":     s.class(),
+		})
+		return string(out), err
+	}},
+	// i3bar protocol: a header, then an endless JSON array with one array
+	// of blocks per update. Without --watch a single block is printed,
+	// for i3blocks-style wrappers.
+	"i3bar": {
+		render: func(s barState, icon string) (string, error) {
+			out, err := json.Marshal(map[string]string{
+				"name":      "wireguard",
+				"full_text": s.text(icon, true),
+				"color":     barColors[s.class()],
+			})
+			return string(out), err
+		},
+		header: `{"version":1}` + "\n[",
+		frame: func(line string, first bool) string {
+			if first {
+				return "[" + line + "]"
+			}
+			return ",[" + line + "]"
+		},
+	},
+	// Polybar custom/script module; %{F} sets the foreground color.
+	"polybar": {render: func(s barState, icon string) (string, error) {
+		return fmt.Sprintf("%%{F%s}%s%%{F-}", barColors[s.class()], s.text(icon, true)), nil
+	}},
+	// tmux status-right via #(...); #[fg=] sets the color.
+	"tmux": {render: func(s barState, icon string) (string, error) {
+		return fmt.Sprintf("#[fg=%s]%s#[default]", barColors[s.class()], s.text(icon, true)), nil
+	}},
+}
+
+func runBar(e *env, args []string) error {
+	fs := newFlagSet(e, "bar")
+	formatName := fs.String("format", "waybar", "output format: waybar, i3bar, polybar or tmux")
+	watch := fs.Bool("watch", false, "keep running and print an update every interval")
+	interval := fs.Duration("interval", 5*time.Second, "update interval with --watch")
+	stale := fs.Duration("stale", 180*time.Second, "handshake age after which a tunnel is flagged")
+	icon := fs.String("icon", barIcon, "text shown in the bar")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 0, 0); err != nil {
+		return err
+	}
+	format, ok := barFormats[*formatName]
+	if !ok {
+		return usagef("unknown format %q", *formatName)
+	}
+	if *interval <= 0 {
+		return usagef("--interval must be positive")
+	}
+
+	mon := newBarMonitor(*stale)
+	if !*watch {
+		// Rates come from the reading the previous run saved, if any.
+		path, now := barStateFile(), e.now()
+		if path != "" {
+			mon.load(path, now)
+		} else {
+			mon.measuring = false
+		}
+		all, err := e.mgr.GetAllStatus()
+		state := mon.update(now, all, err)
+		if path != "" && state.err == nil {
+			// Losing the reading only costs the next run its rates.
+			_ = mon.save(path)
+		}
+		line, err := format.render(state, *icon)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(e.stdout, line); err != nil {
+			return err
+		}
+		return state.err
+	}
+
+	if format.header != "" {
+		if _, err := fmt.Fprintln(e.stdout, format.header); err != nil {
+			return err
+		}
+	}
+	for first := true; ; first = false {
+		all, err := e.mgr.GetAllStatus()
+		line, err := format.render(mon.update(e.now(), all, err), *icon)
+		if err != nil {
+			return err
+		}
+		if format.frame != nil {
+			line = format.frame(line, first)
+		}
+		if _, err := fmt.Fprintln(e.stdout, line); err != nil {
+			return err
+		}
+		if !e.wait(*interval) {
+			return nil
+		}
+	}
+}
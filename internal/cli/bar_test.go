@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+func TestBarMonitorRates(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	status := func(rx, tx uint64) []*wg.InterfaceStatus {
+		return []*wg.InterfaceStatus{{Name: "wg0", Peers: []wg.PeerStatus{{TransferRx: rx, TransferTx: tx}}}}
+	}
+
+	tests := []struct {
+		name           string
+		at             time.Duration
+		rx, tx         uint64
+		measured       bool
+		wantRx, wantTx float64 // bits per second
+	}{
+		{"first reading", 0, 1000, 2000, false, 0, 0},
+		{"second reading", 10 * time.Second, 2000, 2500, true, 800, 400},
+		{"counter reset", 20 * time.Second, 100, 100, false, 0, 0},
+		{"after reset", 30 * time.Second, 1350, 100, true, 1000, 0},
+	}
+
+	m := newBarMonitor(time.Minute)
+	for _, tc := range tests {
+		state := m.update(t0.Add(tc.at), status(tc.rx, tc.tx), nil)
+		got := state.ifaces[0]
+		if got.measured != tc.measured || got.rxRate != tc.wantRx || got.txRate != tc.wantTx {
+			t.Errorf("%s: measured %v rx %v tx %v, want %v %v %v", tc.name, got.measured, got.rxRate, got.txRate, tc.measured, tc.wantRx, tc.wantTx)
+		}
+	}
+
+	// An interface that goes away starts over when it returns.
+	m.update(t0.Add(40*time.Second), nil, nil)
+	if state := m.update(t0.Add(50*time.Second), status(5000, 5000), nil); state.ifaces[0].measured {
+		t.Error("rate measured across the interface going down")
+	}
+}
+
+func TestBarStateClass(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		ifaces []barIface
+		err    error
+		want   string
+	}{
+		{"none", nil, nil, "disconnected"},
+		{"fresh", []barIface{{name: "wg0", hasPeers: true, handshake: now.Add(-time.Minute)}}, nil, "connected"},
+		{"stale", []barIface{{name: "wg0", hasPeers: true, handshake: now.Add(-5 * time.Minute)}}, nil, "warning"},
+		{"never", []barIface{{name: "wg0", hasPeers: true}}, nil, "warning"},
+		{"no peers", []barIface{{name: "wg0"}}, nil, "connected"},
+		{"error", nil, wg.ErrPasswordRequired, "error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := barState{ifaces: tc.ifaces, err: tc.err, now: now, stale: 3 * time.Minute}
+			if got := s.class(); got != tc.want {
+				t.Errorf("class() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBarFormats(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	state := barState{
+		ifaces: []barIface{{name: "wg0", measured: true, rxRate: 1.5e6, txRate: 2000, hasPeers: true, handshake: now.Add(-90 * time.Second)}},
+		now:    now,
+		stale:  3 * time.Minute,
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"waybar", `{"alt":"connected","class":"connected","text":"VPN","tooltip":"WireGuard: wg0\nwg0  ↓ 1.5 Mbps  ↑ 2 Kbps\n  ✓ last handshake: 1m 30s ago"}`},
+		{"i3bar", `{"color":"#8bc34a","full_text":"VPN wg0 ↓1.5 Mbps ↑2 Kbps","name":"wireguard"}`},
+		{"polybar", `%{F#8bc34a}VPN wg0 ↓1.5 Mbps ↑2 Kbps%{F-}`},
+		{"tmux", `#[fg=#8bc34a]VPN wg0 ↓1.5 Mbps ↑2 Kbps#[default]`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			got, err := barFormats[tc.format].render(state, "VPN")
+			if err != nil || got != tc.want {
+				t.Errorf("render = %s, %v\nwant %s", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestBarWatch(t *testing.T) {
+	e, sys, _ := newTestEnv(t)
+	sys.up["wg0"] = true
+	updates := 0
+	e.wait = func(time.Duration) bool {
+		updates++
+		return updates < 3
+	}
+
+	if code := run(e, []string{"bar", "--format", "i3bar", "--watch", "--icon", "VPN"}); code != ExitOK {
+		t.Fatalf("bar exit %d: %s", code, stderr(e))
+	}
+	lines := strings.Split(strings.TrimSpace(stdout(e)), "\n")
+	if len(lines) != 5 || lines[0] != `{"version":1}` || lines[1] != "[" ||
+		!strings.HasPrefix(lines[2], `[{"color"`) || !strings.HasPrefix(lines[3], `,[{`) {
+		t.Errorf("i3bar --watch output:\n%s", stdout(e))
+	}
+}
+
+func TestBarOnce(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	e, _, _ := newTestEnv(t)
+	if code := run(e, []string{"bar"}); code != ExitOK {
+		t.Fatalf("bar exit %d: %s", code, stderr(e))
+	}
+	if !strings.Contains(stdout(e), `"class":"disconnected"`) {
+		t.Errorf("bar with nothing up = %s", stdout(e))
+	}
+
+	e.stdout = &bytes.Buffer{}
+	if code := run(e, []string{"bar", "--format", "lemonbar"}); code != ExitUsage {
+		t.Errorf("unknown format exit %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestBarOnceRates(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	e, sys, _ := newTestEnv(t)
+	sys.up["wg0"] = true
+	now := testNow
+	e.now = func() time.Time { return now }
+	tooltip := func() string {
+		t.Helper()
+		e.stdout = &bytes.Buffer{}
+		if code := run(e, []string{"bar"}); code != ExitOK {
+			t.Fatalf("bar exit %d: %s", code, stderr(e))
+		}
+		var out map[string]string
+		if err := json.Unmarshal([]byte(stdout(e)), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out["tooltip"]
+	}
+
+	tests := []struct {
+		name    string
+		after   time.Duration
+		want    string
+		runtime bool // XDG_RUNTIME_DIR is set
+	}{
+		{"first run", 0, "wg0  measuring...", true},
+		{"next run", 5 * time.Second, "wg0  ↓ 0 bps  ↑ 0 bps", true},
+		{"saved reading too old", barSampleMaxAge + time.Second, "wg0  measuring...", true},
+		{"no runtime dir", 5 * time.Second, "wg0\n", false},
+	}
+	for _, tc := range tests {
+		if !tc.runtime {
+			t.Setenv("XDG_RUNTIME_DIR", "")
+		}
+		now = now.Add(tc.after)
+		if got := tooltip(); !strings.Contains(got, tc.want) {
+			t.Errorf("%s: tooltip = %q, want %q in it", tc.name, got, tc.want)
+		}
+	}
+}
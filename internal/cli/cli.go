@@ -0,0 +1,279 @@
+// Package cli implements the non-interactive subcommands of wireguard-tui,
+// for scripts and cron jobs. Every command is built on the same
+// internal/wg Manager as the TUI.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK         = 0 // success
+	ExitFailure    = 1 // the command failed
+	ExitUsage      = 2 // bad arguments or unknown command
+	ExitNotFound   = 3 // the named profile or interface does not exist
+	ExitPermission = 4 // privileges are needed but the helper wants a password
+)
+
+// errNotFound marks failures that exit with ExitNotFound.
+var errNotFound = errors.New("not found")
+
+// usageError marks failures that exit with ExitUsage.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+// env is what a command runs against.
+type env struct {
+	mgr    *wg.Manager
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	now  func() time.Time
+	wait func(time.Duration) bool // sleeps between --watch updates; false stops
+}
+
+type command struct {
+	name    string
+	args    string // argument synopsis for usage
+	summary string
+	run     func(e *env, args []string) error
+}
+
+// commands lists the subcommands in the order usage shows them.
+var commands = []command{
+	{"list", "[--json|--yaml]", "list profiles and whether they are up", runList},
+	{"show", "<name> [--json|--yaml] [--secrets]", "print a profile with keys hidden", runShow},
+	{"up", "<name>", "bring a profile up", runUp},
+	{"down", "<name>", "bring a profile down", runDown},
+	{"toggle", "<name>", "bring a profile up if down, down if up", runToggle},
+	{"status", "[name] [--json|--yaml]", "show live status of active interfaces", runStatus},
+	{"export", "<name> [--qr|--json|--yaml|--bundle out]", "print a profile's config, QR code or client bundle", runExport},
+	{"import", "<file|-> [--name name] [--force]", "save a .conf, JSON or YAML file as a profile", runImport},
+	{"delete", "<name>", "bring a profile down and delete its config", runDelete},
+	{"prune-expired", "[name] [--dry-run] [--every dur]", "remove peers past their expiry date", runPruneExpired},
+	{"genkey", "", "print a new private key", runGenkey},
+	{"bar", "[--format waybar|i3bar|polybar|tmux] [--watch]", "print tunnel state for a status bar", runBar},
+	{"serve-metrics", "[--listen addr] [--peer-names file]", "serve Prometheus metrics over HTTP", runServeMetrics},
+}
+
+func lookup(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// Run executes the subcommand in args[0] with the remaining arguments and
+// returns the process exit code. Output goes to stdout, errors to stderr.
+func Run(mgr *wg.Manager, args []string, stdout, stderr io.Writer) int {
+	e := &env{
+		mgr:    mgr,
+		stdin:  os.Stdin,
+		stdout: stdout,
+		stderr: stderr,
+		now:    time.Now,
+		wait: func(d time.Duration) bool {
+			time.Sleep(d)
+			return true
+		},
+	}
+	return run(e, args)
+}
+
+func run(e *env, args []string) int {
+	if len(args) == 0 {
+		Usage(e.stderr)
+		return ExitUsage
+	}
+	c, ok := lookup(args[0])
+	if !ok {
+		fmt.Fprintf(e.stderr, "unknown command %q\n\n", args[0])
+		Usage(e.stderr)
+		return ExitUsage
+	}
+
+	err := c.run(e, args[1:])
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	fmt.Fprintf(e.stderr, "%s: %v\n", c.name, err)
+
+	var usage usageError
+	switch {
+	case errors.As(err, &usage):
+		fmt.Fprintf(e.stderr, "usage: wireguard-tui %s %s\n", c.name, c.args)
+		return ExitUsage
+	case errors.Is(err, errNotFound):
+		return ExitNotFound
+	case errors.Is(err, wg.ErrPasswordRequired):
+		fmt.Fprintln(e.stderr, "hint: authenticate first (e.g. sudo -v), or run as root")
+		return ExitPermission
+	}
+	return ExitFailure
+}
+
+// Usage writes the list of subcommands to w.
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: wireguard-tui [flags] [command [args]]")
+	fmt.Fprintln(w, "\nWithout a command the interactive UI starts. Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-13s %-44s %s\n", c.name, c.args, c.summary)
+	}
+}
+
+// parseArgs parses flags in args with fs, allowing them before, after or
+// between positional arguments, and returns the positional ones. Everything
+// after "--" is positional.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{err.Error()}
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		args = rest
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// newFlagSet returns a flag set for command name that reports errors
+// through Run rather than exiting.
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// formatFlags are the --json and --yaml flags of commands with
+// machine-readable output.
+type formatFlags struct {
+	json, yaml *bool
+}
+
+func addFormatFlags(fs *flag.FlagSet) formatFlags {
+	return formatFlags{
+		json: fs.Bool("json", false, "print JSON"),
+		yaml: fs.Bool("yaml", false, "print YAML"),
+	}
+}
+
+// format returns "json", "yaml", or "" for the command's text output.
+func (f formatFlags) format() (string, error) {
+	switch {
+	case *f.json && *f.yaml:
+		return "", usagef("--json and --yaml are mutually exclusive")
+	case *f.json:
+		return "json", nil
+	case *f.yaml:
+		return "yaml", nil
+	}
+	return "", nil
+}
+
+// writeDoc encodes v to w as indented JSON or as YAML.
+func writeDoc(w io.Writer, format string, v any) error {
+	if format == "yaml" {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// wantArgs checks the number of positional arguments.
+func wantArgs(args []string, min, max int) error {
+	switch {
+	case len(args) < min:
+		return usagef("missing argument")
+	case len(args) > max:
+		return usagef("unexpected argument %q", args[max])
+	}
+	return nil
+}
+
+// findProfile returns the profile called name from the first root that
+// has one.
+func findProfile(e *env, name string) (*wg.Interface, error) {
+	profiles, err := e.mgr.LoadProfiles()
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("profile %q: %w", name, errNotFound)
+}
+
+// activeSet returns the names of the active interfaces as a set.
+func activeSet(e *env) (map[string]bool, error) {
+	names, err := e.mgr.ListInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string]bool, len(names))
+	for _, n := range names {
+		active[n] = true
+	}
+	return active, nil
+}
+
+// sortProfiles orders profiles by name, keeping root order for equal names.
+func sortProfiles(profiles []*wg.Interface) {
+	sort.SliceStable(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+}
+
+func runGenkey(e *env, args []string) error {
+	rest, err := parseArgs(newFlagSet(e, "genkey"), args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 0, 0); err != nil {
+		return err
+	}
+	key, err := wg.GeneratePrivateKey()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.stdout, key)
+	return nil
+}
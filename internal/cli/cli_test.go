@@ -0,0 +1,462 @@
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+const (
+	testPrivKey = "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk="
+	testPubKey  = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+)
+
+// testNow is the clock of test envs, a minute after the fake handshake.
+var testNow = time.Date(2026, 1, 2, 3, 5, 5, 0, time.UTC)
+
+const testConfig = `[Interface]
+PrivateKey = ` + testPrivKey + `
+Address = 10.0.0.1/24
+
+[Peer]
+PublicKey = ` + testPubKey + `
+AllowedIPs = 10.0.0.2/32
+`
+
+// fakeSystem stands in for the wg tools and the kernel: it keeps which
+// interfaces are up and answers `wg show`, `wg-quick` and status reads
+// from that.
+type fakeSystem struct {
+	mu   sync.Mutex
+	up   map[string]bool
+	ran  []string
+	fail string // stderr of a failing escalation, if set
+}
+
+func (f *fakeSystem) Run(_ context.Context, cmd wg.Command) ([]byte, []byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ran = append(f.ran, cmd.String())
+	if f.fail != "" {
+		return nil, []byte(f.fail), &wg.ExitError{Code: 1}
+	}
+
+	switch {
+	case cmd.Name == "wg" && len(cmd.Args) == 2 && cmd.Args[0] == "show":
+		if !f.up[cmd.Args[1]] {
+			return nil, []byte("Unable to access interface: No such device"), &wg.ExitError{Code: 1}
+		}
+	case cmd.Name == "wg-quick" && len(cmd.Args) == 2:
+		name := strings.TrimSuffix(filepath.Base(cmd.Args[1]), ".conf")
+		f.up[name] = cmd.Args[0] == "up"
+	}
+	return nil, nil, nil
+}
+
+func (f *fakeSystem) Interfaces() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := []string{}
+	for n, up := range f.up {
+		if up {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *fakeSystem) Status(name string) (*wg.InterfaceStatus, error) {
+	return &wg.InterfaceStatus{
+		Name:       name,
+		PublicKey:  testPubKey,
+		ListenPort: 51820,
+		Peers: []wg.PeerStatus{{
+			PublicKey:       testPubKey,
+			Endpoint:        "203.0.113.1:51820",
+			LatestHandshake: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			TransferRx:      1234,
+			TransferTx:      5678,
+		}},
+	}, nil
+}
+
+func (f *fakeSystem) AllStatus() ([]*wg.InterfaceStatus, error) {
+	names, _ := f.Interfaces()
+	var all []*wg.InterfaceStatus
+	for _, n := range names {
+		s, _ := f.Status(n)
+		all = append(all, s)
+	}
+	return all, nil
+}
+
+// newTestEnv returns an env over a Manager whose profile roots are fresh
+// temporary directories holding the given files (root index → name →
+// content).
+func newTestEnv(t *testing.T, roots ...map[string]string) (*env, *fakeSystem, []string) {
+	t.Helper()
+	sys := &fakeSystem{up: map[string]bool{}}
+	mgr := wg.NewManager(sys)
+	mgr.SetBackend(sys)
+
+	var dirs []string
+	for _, files := range roots {
+		dir := t.TempDir()
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+	mgr.SetConfigDirs(dirs)
+
+	e := &env{
+		mgr:    mgr,
+		stdin:  strings.NewReader(""),
+		stdout: &bytes.Buffer{},
+		stderr: &bytes.Buffer{},
+		now:    func() time.Time { return testNow },
+		wait:   func(time.Duration) bool { return false },
+	}
+	return e, sys, dirs
+}
+
+func stdout(e *env) string { return e.stdout.(*bytes.Buffer).String() }
+func stderr(e *env) string { return e.stderr.(*bytes.Buffer).String() }
+
+func TestParseArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		want  []string
+		wantV bool
+	}{
+		{[]string{"a", "-v", "b"}, []string{"a", "b"}, true},
+		{[]string{"-v", "--", "-a", "b"}, []string{"-a", "b"}, true},
+		{[]string{"a", "--", "b", "-x"}, []string{"a", "b", "-x"}, false},
+		{[]string{"--", "a", "--", "-v"}, []string{"a", "--", "-v"}, false},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		v := fs.Bool("v", false, "")
+		got, err := parseArgs(fs, tc.args)
+		if err != nil || !slices.Equal(got, tc.want) {
+			t.Errorf("parseArgs(%q) = %q, %v; want %q", tc.args, got, err, tc.want)
+		}
+		if *v != tc.wantV {
+			t.Errorf("parseArgs(%q): -v = %v, want %v", tc.args, *v, tc.wantV)
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		fail string
+		want int
+	}{
+		{"no command", nil, "", ExitUsage},
+		{"unknown command", []string{"frobnicate"}, "", ExitUsage},
+		{"missing argument", []string{"show"}, "", ExitUsage},
+		{"extra argument", []string{"show", "wg0", "wg1"}, "", ExitUsage},
+		{"unknown flag", []string{"list", "--xml"}, "", ExitUsage},
+		{"conflicting formats", []string{"list", "--json", "--yaml"}, "", ExitUsage},
+		{"unknown profile", []string{"up", "nope"}, "", ExitNotFound},
+		{"status of down interface", []string{"status", "wg0"}, "", ExitNotFound},
+		{"password required", []string{"up", "wg0"}, "sudo: a password is required", ExitPermission},
+		{"command failure", []string{"up", "wg0"}, "wg-quick: something broke", ExitFailure},
+		{"help", []string{"list", "-h"}, "", ExitOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e, sys, _ := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+			sys.fail = tc.fail
+			if got := run(e, tc.args); got != tc.want {
+				t.Errorf("run(%q) = %d, want %d; stderr:\n%s", tc.args, got, tc.want, stderr(e))
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	e, sys, dirs := newTestEnv(t,
+		map[string]string{"wg1.conf": testConfig, "notes.txt": "x"},
+		map[string]string{"wg0.conf": testConfig},
+	)
+	sys.up["wg1"] = true
+
+	if code := run(e, []string{"list", "--json"}); code != ExitOK {
+		t.Fatalf("list --json exit %d: %s", code, stderr(e))
+	}
+	var got []profileSummary
+	if err := json.Unmarshal([]byte(stdout(e)), &got); err != nil {
+		t.Fatalf("list --json output is not JSON: %v\n%s", err, stdout(e))
+	}
+	want := []profileSummary{
+		{Name: "wg0", Path: filepath.Join(dirs[1], "wg0.conf"), Up: false, Peers: 1},
+		{Name: "wg1", Path: filepath.Join(dirs[0], "wg1.conf"), Up: true, Peers: 1},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("list --json = %+v, want %+v", got, want)
+	}
+
+	e.stdout = &bytes.Buffer{}
+	run(e, []string{"list"})
+	lines := strings.Split(strings.TrimSpace(stdout(e)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[2], "wg1") || !strings.Contains(lines[2], " up ") {
+		t.Errorf("list =\n%s", stdout(e))
+	}
+}
+
+func TestShowHidesKeys(t *testing.T) {
+	e, _, _ := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+	if code := run(e, []string{"show", "wg0"}); code != ExitOK {
+		t.Fatalf("show exit %d: %s", code, stderr(e))
+	}
+	if out := stdout(e); strings.Contains(out, testPrivKey) || !strings.Contains(out, testPubKey) {
+		t.Errorf("show =\n%s\nwant private key hidden, public key shown", out)
+	}
+}
+
+func TestExport(t *testing.T) {
+	e, _, _ := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+	if code := run(e, []string{"export", "wg0"}); code != ExitOK || stdout(e) != testConfig {
+		t.Errorf("export = %d %q, want the config unchanged", code, stdout(e))
+	}
+
+	e.stdout = &bytes.Buffer{}
+	if code := run(e, []string{"export", "wg0", "--qr"}); code != ExitOK || !strings.Contains(stdout(e), "█") {
+		t.Errorf("export --qr = %d, output:\n%s", code, stdout(e))
+	}
+}
+
+func TestExportBundle(t *testing.T) {
+	e, _, dirs := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+	if code := run(e, []string{"export", "wg0", "--bundle", "-"}); code != ExitFailure || !strings.Contains(stderr(e), "no peer has a stored client") {
+		t.Errorf("export --bundle without clients: exit %d, stderr %q", code, stderr(e))
+	}
+	if code := run(e, []string{"export", "wg0", "--bundle", "-", "--qr"}); code != ExitUsage {
+		t.Errorf("export --bundle --qr: exit %d, want usage error", code)
+	}
+
+	profiles, err := e.mgr.LoadProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, client, err := wg.AddClient(profiles[0], wg.ClientOptions{Name: "laptop", Endpoint: "vpn.example.com:51820"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.mgr.SaveConfig(dirs[0], server); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.mgr.SaveClient(server, client); err != nil {
+		t.Fatal(err)
+	}
+
+	e.stdout = &bytes.Buffer{}
+	if code := run(e, []string{"export", "wg0", "--bundle", "-"}); code != ExitOK {
+		t.Fatalf("export --bundle exit %d: %s", code, stderr(e))
+	}
+	data := e.stdout.(*bytes.Buffer).Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("bundle is not a zip: %v", err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, " "); got != "laptop.conf laptop.png manifest.txt" {
+		t.Errorf("bundle files = %s", got)
+	}
+
+	out := filepath.Join(t.TempDir(), "clients")
+	if code := run(e, []string{"export", "wg0", "--bundle", out}); code != ExitOK {
+		t.Fatalf("export --bundle dir exit %d: %s", code, stderr(e))
+	}
+	if _, err := os.Stat(filepath.Join(out, "laptop.png")); err != nil {
+		t.Errorf("bundle directory: %v", err)
+	}
+}
+
+func TestShowFormats(t *testing.T) {
+	e, _, _ := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+
+	if code := run(e, []string{"show", "wg0", "--yaml"}); code != ExitOK {
+		t.Fatalf("show --yaml exit %d: %s", code, stderr(e))
+	}
+	if out := stdout(e); !strings.Contains(out, "private_key: (hidden)") || !strings.Contains(out, "- 10.0.0.1/24") {
+		t.Errorf("show --yaml =\n%s", out)
+	}
+
+	e.stdout = &bytes.Buffer{}
+	run(e, []string{"show", "--secrets", "wg0"})
+	if !strings.Contains(stdout(e), testPrivKey) {
+		t.Errorf("show --secrets hides the private key:\n%s", stdout(e))
+	}
+}
+
+func TestExportJSONImports(t *testing.T) {
+	e, _, dirs := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+
+	// Without --secrets the document can't be imported back.
+	run(e, []string{"export", "wg0", "--json"})
+	e.stdin = strings.NewReader(stdout(e))
+	if code := run(e, []string{"import", "-", "--name", "copy"}); code != ExitFailure || !strings.Contains(stderr(e), "redacted") {
+		t.Errorf("import of redacted export: exit %d, stderr %q", code, stderr(e))
+	}
+
+	e.stdout = &bytes.Buffer{}
+	run(e, []string{"export", "wg0", "--json", "--secrets"})
+	e.stdin = strings.NewReader(stdout(e))
+	// The document names wg0, which exists; --name picks another.
+	if code := run(e, []string{"import", "-", "--name", "copy"}); code != ExitOK {
+		t.Fatalf("import exit %d: %s", code, stderr(e))
+	}
+	data, _ := os.ReadFile(filepath.Join(dirs[0], "copy.conf"))
+	if string(data) != testConfig {
+		t.Errorf("imported config =\n%s\nwant\n%s", data, testConfig)
+	}
+}
+
+func TestImport(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "office.conf")
+	if err := os.WriteFile(src, []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(t.TempDir(), "bad.conf")
+	if err := os.WriteFile(bad, []byte("[Interface]\nListenPort = 99999\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		existing map[string]string
+		args     []string
+		stdin    string
+		want     int
+		wantFile string // profile expected afterwards in the first root
+	}{
+		{"name from file", nil, []string{src}, "", ExitOK, "office.conf"},
+		{"name flag", nil, []string{src, "--name", "work"}, "", ExitOK, "work.conf"},
+		{"stdin", nil, []string{"--name", "work", "-"}, testConfig, ExitOK, "work.conf"},
+		{"stdin needs name", nil, []string{"-"}, testConfig, ExitUsage, ""},
+		{"invalid name", nil, []string{src, "--name", "a/b"}, "", ExitUsage, ""},
+		{"invalid config", nil, []string{bad}, "", ExitFailure, ""},
+		{"exists", map[string]string{"office.conf": "[Interface]\n"}, []string{src}, "", ExitFailure, ""},
+		{"force replaces", map[string]string{"office.conf": "[Interface]\n"}, []string{src, "--force"}, "", ExitOK, "office.conf"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e, _, dirs := newTestEnv(t, tc.existing)
+			e.stdin = strings.NewReader(tc.stdin)
+			if got := run(e, append([]string{"import"}, tc.args...)); got != tc.want {
+				t.Fatalf("import exit %d, want %d; stderr:\n%s", got, tc.want, stderr(e))
+			}
+			if tc.wantFile == "" {
+				return
+			}
+			data, err := os.ReadFile(filepath.Join(dirs[0], tc.wantFile))
+			if err != nil || string(data) != testConfig {
+				t.Errorf("%s = %q, %v; want the imported config", tc.wantFile, data, err)
+			}
+		})
+	}
+}
+
+func TestUpDownAreIdempotent(t *testing.T) {
+	e, sys, dirs := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+	target := filepath.Join(dirs[0], "wg0.conf")
+
+	for _, step := range []struct {
+		cmd    string
+		wantUp bool
+		output string
+	}{
+		{"up", true, "wg0 is now up"},
+		{"up", true, "wg0 is already up"},
+		{"down", false, "wg0 is now down"},
+		{"down", false, "wg0 is already down"},
+		{"toggle", true, "wg0 is now up"},
+	} {
+		e.stdout = &bytes.Buffer{}
+		if code := run(e, []string{step.cmd, "wg0"}); code != ExitOK {
+			t.Fatalf("%s exit %d: %s", step.cmd, code, stderr(e))
+		}
+		if sys.up["wg0"] != step.wantUp || strings.TrimSpace(stdout(e)) != step.output {
+			t.Errorf("%s: up = %v, output %q; want %v, %q", step.cmd, sys.up["wg0"], stdout(e), step.wantUp, step.output)
+		}
+	}
+
+	var quick []string
+	for _, c := range sys.ran {
+		if strings.HasPrefix(c, "wg-quick") {
+			quick = append(quick, c)
+		}
+	}
+	want := []string{"wg-quick up " + target, "wg-quick down " + target, "wg-quick up " + target}
+	if strings.Join(quick, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wg-quick runs = %q, want %q", quick, want)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	e, sys, _ := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+	sys.up["wg0"] = true
+
+	if code := run(e, []string{"status", "--json"}); code != ExitOK {
+		t.Fatalf("status --json exit %d: %s", code, stderr(e))
+	}
+	var got []map[string]any
+	if err := json.Unmarshal([]byte(stdout(e)), &got); err != nil {
+		t.Fatalf("status --json output is not JSON: %v\n%s", err, stdout(e))
+	}
+	peer := got[0]["peers"].([]any)[0].(map[string]any)
+	if got[0]["name"] != "wg0" || peer["rx_bytes"] != float64(1234) || peer["latest_handshake"] != "2026-01-02T03:04:05Z" {
+		t.Errorf("status --json = %s", stdout(e))
+	}
+
+	e.stdout = &bytes.Buffer{}
+	run(e, []string{"status", "wg0"})
+	for _, want := range []string{"interface: wg0", "latest handshake: 2026-01-02T03:04:05Z", "transfer: 1234 B received, 5678 B sent"} {
+		if !strings.Contains(stdout(e), want) {
+			t.Errorf("status output missing %q:\n%s", want, stdout(e))
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	e, _, dirs := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+	if code := run(e, []string{"delete", "wg0"}); code != ExitOK {
+		t.Fatalf("delete exit %d: %s", code, stderr(e))
+	}
+	if _, err := os.Stat(filepath.Join(dirs[0], "wg0.conf")); !os.IsNotExist(err) {
+		t.Errorf("config still exists after delete: %v", err)
+	}
+}
+
+func TestGenkey(t *testing.T) {
+	e, _, _ := newTestEnv(t)
+	if code := run(e, []string{"genkey"}); code != ExitOK {
+		t.Fatalf("genkey exit %d", code)
+	}
+	if _, err := wg.ParseKey(strings.TrimSpace(stdout(e))); err != nil {
+		t.Errorf("genkey printed %q: %v", stdout(e), err)
+	}
+}
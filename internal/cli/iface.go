@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+func runUp(e *env, args []string) error {
+	return setState(e, "up", args, true)
+}
+
+func runDown(e *env, args []string) error {
+	return setState(e, "down", args, false)
+}
+
+// setState brings the named profile up or down. It succeeds without doing
+// anything when the interface is already in that state, so it is safe to
+// repeat from cron.
+func setState(e *env, cmd string, args []string, up bool) error {
+	rest, err := parseArgs(newFlagSet(e, cmd), args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 1, 1); err != nil {
+		return err
+	}
+	p, err := findProfile(e, rest[0])
+	if err != nil {
+		return err
+	}
+
+	isUp, err := e.mgr.IsUp(p.Target())
+	if err != nil {
+		return err
+	}
+	if isUp == up {
+		fmt.Fprintf(e.stdout, "%s is already %s\n", p.Name, cmd)
+		return nil
+	}
+	change := e.mgr.Down
+	if up {
+		change = e.mgr.Up
+	}
+	if err := change(p.Target()); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "%s is now %s\n", p.Name, cmd)
+	return nil
+}
+
+func runToggle(e *env, args []string) error {
+	rest, err := parseArgs(newFlagSet(e, "toggle"), args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 1, 1); err != nil {
+		return err
+	}
+	p, err := findProfile(e, rest[0])
+	if err != nil {
+		return err
+	}
+	nowUp, err := e.mgr.Toggle(p.Target())
+	if err != nil {
+		return err
+	}
+	state := "down"
+	if nowUp {
+		state = "up"
+	}
+	fmt.Fprintf(e.stdout, "%s is now %s\n", p.Name, state)
+	return nil
+}
+
+func runStatus(e *env, args []string) error {
+	fs := newFlagSet(e, "status")
+	ff := addFormatFlags(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 0, 1); err != nil {
+		return err
+	}
+	format, err := ff.format()
+	if err != nil {
+		return err
+	}
+
+	var all []*wg.InterfaceStatus
+	if len(rest) == 1 {
+		active, err := activeSet(e)
+		if err != nil {
+			return err
+		}
+		if !active[rest[0]] {
+			return fmt.Errorf("interface %q is not up: %w", rest[0], errNotFound)
+		}
+		s, err := e.mgr.GetStatus(rest[0])
+		if err != nil {
+			return err
+		}
+		all = []*wg.InterfaceStatus{s}
+	} else if all, err = e.mgr.GetAllStatus(); err != nil {
+		return err
+	}
+
+	if format != "" {
+		docs := make([]wg.InterfaceStatusDoc, 0, len(all))
+		for _, s := range all {
+			docs = append(docs, wg.NewStatusDoc(s))
+		}
+		return writeDoc(e.stdout, format, docs)
+	}
+	for i, s := range all {
+		if i > 0 {
+			fmt.Fprintln(e.stdout)
+		}
+		writeStatus(e.stdout, s)
+	}
+	return nil
+}
+
+// writeStatus prints s in the layout of `wg show`, with exact byte counts
+// and UTC handshake times so scripts can parse it too.
+func writeStatus(w io.Writer, s *wg.InterfaceStatus) {
+	fmt.Fprintf(w, "interface: %s\n", s.Name)
+	fmt.Fprintf(w, "  public key: %s\n", s.PublicKey)
+	if s.ListenPort != 0 {
+		fmt.Fprintf(w, "  listening port: %d\n", s.ListenPort)
+	}
+	if s.FwMark != 0 {
+		fmt.Fprintf(w, "  fwmark: 0x%x\n", s.FwMark)
+	}
+	for _, p := range s.Peers {
+		fmt.Fprintf(w, "\npeer: %s\n", p.PublicKey)
+		if p.Endpoint != "" {
+			fmt.Fprintf(w, "  endpoint: %s\n", p.Endpoint)
+		}
+		fmt.Fprintf(w, "  allowed ips: %s\n", wg.FormatPrefixes(p.AllowedIPs))
+		handshake := "never"
+		if !p.LatestHandshake.IsZero() {
+			handshake = p.LatestHandshake.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "  latest handshake: %s\n", handshake)
+		fmt.Fprintf(w, "  transfer: %d B received, %d B sent\n", p.TransferRx, p.TransferTx)
+		if p.PersistentKeepalive != 0 {
+			fmt.Fprintf(w, "  persistent keepalive: every %d seconds\n", p.PersistentKeepalive)
+		}
+	}
+}
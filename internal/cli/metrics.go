@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// Content types of the two exposition formats served. Prometheus asks for
+// OpenMetrics in its Accept header; anything else gets the classic text
+// format.
+const (
+	promContentType        = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// metricFamily is one metric name with its samples.
+type metricFamily struct {
+	name    string // with the _total suffix for counters
+	help    string
+	counter bool
+	samples []metricSample
+}
+
+type metricSample struct {
+	labels [][2]string // name, value pairs in output order
+	value  float64
+}
+
+func (f *metricFamily) add(value float64, labels ...[2]string) {
+	f.samples = append(f.samples, metricSample{labels: labels, value: value})
+}
+
+// label builds a label pair; a short helper keeps the collectors readable.
+func label(name, value string) [2]string { return [2]string{name, value} }
+
+// profileRefresh is how long serve-metrics keeps the profiles it read.
+// Parsing every config on each scrape would cost more than reading the
+// status it reports.
+const profileRefresh = time.Minute
+
+// wgMetrics collects the metrics served by serve-metrics.
+type wgMetrics struct {
+	mgr   *wg.Manager
+	names map[string]string // peer public key → friendly name
+	now   func() time.Time  // nil means time.Now
+
+	mu       sync.Mutex
+	loadedAt time.Time       // when profiles was read; zero before the first scrape
+	profiles []*wg.Interface // as of loadedAt
+}
+
+// loadProfiles returns the profiles, read again once profileRefresh has
+// passed or when an interface is up that they don't include.
+func (m *wgMetrics) loadProfiles(active map[string]bool) []*wg.Interface {
+	now := time.Now
+	if m.now != nil {
+		now = m.now
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stale := m.loadedAt.IsZero() || now().Sub(m.loadedAt) >= profileRefresh
+	if !stale {
+		known := make(map[string]bool, len(m.profiles))
+		for _, p := range m.profiles {
+			known[p.Name] = true
+		}
+		for name := range active {
+			stale = stale || !known[name]
+		}
+	}
+	if stale {
+		m.profiles, _ = m.mgr.LoadProfiles()
+		m.loadedAt = now()
+	}
+	return m.profiles
+}
+
+// collect reads the live status of every interface and turns it into
+// metric families. Profiles that are not up are reported with
+// wireguard_interface_up 0, so alerts can fire on a tunnel going down.
+// Failing to read profiles only loses those zeros; failing to read status
+// fails the scrape.
+func (m *wgMetrics) collect() ([]*metricFamily, error) {
+	all, err := m.mgr.GetAllStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	up := &metricFamily{name: "wireguard_interface_up", help: "Whether the interface is up (1) or only configured (0)."}
+	peers := &metricFamily{name: "wireguard_peers", help: "Number of peers on the running interface."}
+	ifRx := &metricFamily{name: "wireguard_interface_receive_bytes_total", help: "Bytes received from all peers of the interface.", counter: true}
+	ifTx := &metricFamily{name: "wireguard_interface_transmit_bytes_total", help: "Bytes sent to all peers of the interface.", counter: true}
+	rx := &metricFamily{name: "wireguard_peer_receive_bytes_total", help: "Bytes received from the peer.", counter: true}
+	tx := &metricFamily{name: "wireguard_peer_transmit_bytes_total", help: "Bytes sent to the peer.", counter: true}
+	hs := &metricFamily{name: "wireguard_peer_last_handshake_seconds", help: "Unix time of the peer's latest handshake, 0 if none."}
+
+	active := make(map[string]bool, len(all))
+	var ifaces []string
+	for _, s := range all {
+		active[s.Name] = true
+		ifaces = append(ifaces, s.Name)
+	}
+	profiles := m.loadProfiles(active)
+	seen := make(map[string]bool)
+	metaNames := make(map[[2]string]string) // interface, public key → Name metadata
+	for _, p := range profiles {
+		if seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+		if !active[p.Name] {
+			ifaces = append(ifaces, p.Name)
+		}
+		for _, peer := range p.Peers {
+			metaNames[[2]string{p.Name, peer.PublicKey}] = peer.Name
+		}
+	}
+	sort.Strings(ifaces)
+	for _, name := range ifaces {
+		up.add(boolValue(active[name]), label("interface", name))
+	}
+
+	for _, s := range all {
+		iface := label("interface", s.Name)
+		peers.add(float64(len(s.Peers)), iface)
+		var sumRx, sumTx uint64
+		for _, p := range s.Peers {
+			sumRx += p.TransferRx
+			sumTx += p.TransferTx
+			name, ok := m.names[p.PublicKey]
+			if !ok {
+				name = metaNames[[2]string{s.Name, p.PublicKey}]
+			}
+			labels := [][2]string{iface, label("public_key", p.PublicKey), label("name", name)}
+			rx.add(float64(p.TransferRx), labels...)
+			tx.add(float64(p.TransferTx), labels...)
+			var at float64
+			if !p.LatestHandshake.IsZero() {
+				at = float64(p.LatestHandshake.Unix())
+			}
+			hs.add(at, labels...)
+		}
+		ifRx.add(float64(sumRx), iface)
+		ifTx.add(float64(sumTx), iface)
+	}
+	return []*metricFamily{up, peers, ifRx, ifTx, rx, tx, hs}, nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// writeMetrics writes families in the Prometheus text format, or in
+// OpenMetrics when openMetrics is set. The two differ in counter family
+// names (no _total in OpenMetrics TYPE lines) and the closing # EOF.
+func writeMetrics(w io.Writer, families []*metricFamily, openMetrics bool) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		family, typ := f.name, "gauge"
+		if f.counter {
+			typ = "counter"
+			if openMetrics {
+				family = strings.TrimSuffix(family, "_total")
+			}
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", family, f.help, family, typ)
+		for _, s := range f.samples {
+			bw.WriteString(f.name)
+			if len(s.labels) > 0 {
+				bw.WriteByte('{')
+				for i, l := range s.labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, "%s=\"%s\"", l[0], escapeLabel(l[1]))
+				}
+				bw.WriteByte('}')
+			}
+			fmt.Fprintf(bw, " %s\n", strconv.FormatFloat(s.value, 'f', -1, 64))
+		}
+	}
+	if openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+// ServeHTTP serves the metrics page. Every scrape reads fresh status; only
+// the profiles are kept between scrapes (see loadProfiles).
+func (m *wgMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	families, err := m.collect()
+	if err != nil {
+		http.Error(w, "reading WireGuard status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, families, openMetrics); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if openMetrics {
+		w.Header().Set("Content-Type", openMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", promContentType)
+	}
+	w.Write(buf.Bytes())
+}
+
+// metricsHandler routes /metrics to the exporter and answers / with a
+// pointer to it, as Prometheus exporters conventionally do.
+func metricsHandler(m *wgMetrics) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<html><body><a href="/metrics">WireGuard metrics</a></body></html>`)
+	})
+	return mux
+}
+
+// readPeerNames parses a peer names file: one "<public key> <name>" per
+// line, with blank lines and # comments ignored. The name is the rest of
+// the line and may contain spaces.
+func readPeerNames(r io.Reader) (map[string]string, error) {
+	names := make(map[string]string)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key := strings.Fields(line)[0]
+		name := strings.TrimSpace(line[len(key):])
+		if name == "" {
+			return nil, fmt.Errorf("line %d: want \"<public key> <name>\"", n)
+		}
+		if _, err := wg.ParseKey(key); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		names[key] = name
+	}
+	return names, sc.Err()
+}
+
+func runServeMetrics(e *env, args []string) error {
+	fs := newFlagSet(e, "serve-metrics")
+	listen := fs.String("listen", ":9586", "address to serve /metrics on")
+	namesFile := fs.String("peer-names", "", `file of "<public key> <name>" lines labelling peers, instead of their Name metadata`)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 0, 0); err != nil {
+		return err
+	}
+
+	m := &wgMetrics{mgr: e.mgr, now: e.now}
+	if *namesFile != "" {
+		f, err := os.Open(*namesFile)
+		if err != nil {
+			return err
+		}
+		m.names, err = readPeerNames(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", *namesFile, err)
+		}
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "serving metrics on http://%s/metrics\n", ln.Addr())
+	srv := &http.Server{
+		Handler:           metricsHandler(m),
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	return srv.Serve(ln)
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, url, accept string) (string, string) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: %s\n%s", url, resp.Status, body)
+	}
+	return string(body), resp.Header.Get("Content-Type")
+}
+
+func TestServeMetrics(t *testing.T) {
+	e, sys, _ := newTestEnv(t, map[string]string{"wg0.conf": testConfig, "wg1.conf": testConfig})
+	sys.up["wg0"] = true
+	names, err := readPeerNames(strings.NewReader("# office peers\n" + testPubKey + "  Alice's \"laptop\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(metricsHandler(&wgMetrics{mgr: e.mgr, names: names}))
+	defer srv.Close()
+
+	body, ctype := scrape(t, srv.URL+"/metrics", "")
+	if !strings.HasPrefix(ctype, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ctype)
+	}
+	peer := `{interface="wg0",public_key="` + testPubKey + `",name="Alice's \"laptop\""}`
+	for _, want := range []string{
+		"# TYPE wireguard_interface_up gauge\n",
+		`wireguard_interface_up{interface="wg0"} 1` + "\n",
+		`wireguard_interface_up{interface="wg1"} 0` + "\n",
+		`wireguard_peers{interface="wg0"} 1` + "\n",
+		"# TYPE wireguard_interface_receive_bytes_total counter\n",
+		`wireguard_interface_receive_bytes_total{interface="wg0"} 1234` + "\n",
+		`wireguard_interface_transmit_bytes_total{interface="wg0"} 5678` + "\n",
+		"wireguard_peer_receive_bytes_total" + peer + " 1234\n",
+		"wireguard_peer_transmit_bytes_total" + peer + " 5678\n",
+		"wireguard_peer_last_handshake_seconds" + peer + " 1767323045\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `peers{interface="wg1"}`) || strings.Contains(body, "# EOF") {
+		t.Errorf("unexpected output:\n%s", body)
+	}
+
+	body, ctype = scrape(t, srv.URL+"/metrics", "application/openmetrics-text;version=1.0.0,text/plain;q=0.5")
+	if !strings.HasPrefix(ctype, "application/openmetrics-text") {
+		t.Errorf("Content-Type = %q", ctype)
+	}
+	if !strings.HasSuffix(body, "# EOF\n") || !strings.Contains(body, "# TYPE wireguard_peer_receive_bytes counter\n") {
+		t.Errorf("OpenMetrics output:\n%s", body)
+	}
+
+	// Without a names file entry, peers are labelled with their Name
+	// metadata.
+	e, sys, _ = newTestEnv(t, map[string]string{"wg0.conf": strings.Replace(testConfig, "[Peer]\n", "[Peer]\n# Name = bob\n", 1)})
+	sys.up["wg0"] = true
+	fams, err := (&wgMetrics{mgr: e.mgr}).collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := writeMetrics(&out, fams, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `name="bob"}`) {
+		t.Errorf("metrics without names file:\n%s", out.String())
+	}
+}
+
+func TestMetricsProfileRefresh(t *testing.T) {
+	e, sys, dirs := newTestEnv(t, map[string]string{"wg0.conf": testConfig})
+	sys.up["wg0"] = true
+	now := testNow
+	m := &wgMetrics{mgr: e.mgr, now: func() time.Time { return now }}
+	collect := func() string {
+		t.Helper()
+		fams, err := m.collect()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out strings.Builder
+		if err := writeMetrics(&out, fams, false); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dirs[0], name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	collect()
+
+	// Profiles are kept between scrapes...
+	write("wg1.conf", testConfig)
+	if out := collect(); strings.Contains(out, `interface="wg1"`) {
+		t.Errorf("new profile reported before the refresh:\n%s", out)
+	}
+	// ...until they are due for a refresh,
+	now = now.Add(profileRefresh)
+	if out := collect(); !strings.Contains(out, `wireguard_interface_up{interface="wg1"} 0`) {
+		t.Errorf("new profile missing after the refresh:\n%s", out)
+	}
+	// or an interface comes up that they don't know.
+	write("wg2.conf", strings.Replace(testConfig, "[Peer]\n", "[Peer]\n# Name = carol\n", 1))
+	sys.up["wg2"] = true
+	if out := collect(); !strings.Contains(out, `{interface="wg2",public_key="`+testPubKey+`",name="carol"}`) {
+		t.Errorf("new interface not labelled from its profile:\n%s", out)
+	}
+}
+
+func TestReadPeerNamesErrors(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"no name", testPubKey + "\n", "line 1"},
+		{"bad key", "# c\nnot-a-key Bob\n", "line 2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readPeerNames(strings.NewReader(tc.input))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("readPeerNames() error = %v, want containing %q", err, tc.want)
+			}
+		})
+	}
+}
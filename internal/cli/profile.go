@@ -0,0 +1,273 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// profileSummary is one entry of `list --json`.
+type profileSummary struct {
+	Name  string `json:"name" yaml:"name"`
+	Path  string `json:"path" yaml:"path"`
+	Up    bool   `json:"up" yaml:"up"`
+	Peers int    `json:"peers" yaml:"peers"`
+}
+
+func runList(e *env, args []string) error {
+	fs := newFlagSet(e, "list")
+	ff := addFormatFlags(fs)
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 0, 0); err != nil {
+		return err
+	}
+	format, err := ff.format()
+	if err != nil {
+		return err
+	}
+
+	profiles, loadErr := e.mgr.LoadProfiles()
+	if loadErr != nil && len(profiles) == 0 {
+		return loadErr
+	}
+	active, err := activeSet(e)
+	if err != nil {
+		return err
+	}
+	sortProfiles(profiles)
+
+	summaries := make([]profileSummary, 0, len(profiles))
+	for _, p := range profiles {
+		summaries = append(summaries, profileSummary{
+			Name:  p.Name,
+			Path:  p.Path(),
+			Up:    active[p.Name],
+			Peers: len(p.Peers),
+		})
+	}
+
+	if format != "" {
+		if err := writeDoc(e.stdout, format, summaries); err != nil {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSTATE\tPEERS\tPATH")
+		for _, s := range summaries {
+			state := "down"
+			if s.Up {
+				state = "up"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", s.Name, state, s.Peers, s.Path)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	// Profiles from readable roots were printed; still report the rest.
+	return loadErr
+}
+
+func runShow(e *env, args []string) error {
+	fs := newFlagSet(e, "show")
+	ff := addFormatFlags(fs)
+	secrets := fs.Bool("secrets", false, "include private and preshared keys")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 1, 1); err != nil {
+		return err
+	}
+	format, err := ff.format()
+	if err != nil {
+		return err
+	}
+	p, err := findProfile(e, rest[0])
+	if err != nil {
+		return err
+	}
+
+	if format != "" {
+		return writeDoc(e.stdout, format, wg.NewInterfaceDoc(p, *secrets))
+	}
+	conf := wg.MarshalConfig(p)
+	if !*secrets {
+		conf = wg.RedactKeys(conf)
+	}
+	fmt.Fprintf(e.stdout, "# %s\n", p.Path())
+	_, err = io.WriteString(e.stdout, conf)
+	return err
+}
+
+func runExport(e *env, args []string) error {
+	fs := newFlagSet(e, "export")
+	qr := fs.Bool("qr", false, "print the config as a terminal QR code")
+	ff := addFormatFlags(fs)
+	secrets := fs.Bool("secrets", false, "include private and preshared keys in JSON/YAML")
+	bundle := fs.String("bundle", "", "write the profile's stored client configs and QR codes to a .zip, a directory, or - for a zip on stdout")
+	endpoint := fs.String("endpoint", "", "server endpoint for bundled clients stored without one")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 1, 1); err != nil {
+		return err
+	}
+	format, err := ff.format()
+	if err != nil {
+		return err
+	}
+	if *qr && format != "" {
+		return usagef("--qr can't be combined with --%s", format)
+	}
+	if *bundle != "" && (*qr || format != "") {
+		return usagef("--bundle can't be combined with --qr, --json or --yaml")
+	}
+	p, err := findProfile(e, rest[0])
+	if err != nil {
+		return err
+	}
+
+	if *bundle != "" {
+		return exportBundle(e, p, *bundle, *endpoint)
+	}
+
+	if format != "" {
+		return writeDoc(e.stdout, format, wg.NewInterfaceDoc(p, *secrets))
+	}
+	out := wg.MarshalConfig(p)
+	if *qr {
+		if out, err = wg.GenerateQRString(p); err != nil {
+			return fmt.Errorf("generating QR code: %w", err)
+		}
+	}
+	_, err = io.WriteString(e.stdout, out)
+	return err
+}
+
+// exportBundle writes the client bundle of p to path, or as a zip to
+// stdout if path is "-".
+func exportBundle(e *env, p *wg.Interface, path, endpoint string) error {
+	clients, err := e.mgr.Clients(p)
+	if err != nil {
+		return err
+	}
+	files, err := wg.ClientBundle(p, clients, endpoint)
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		return wg.WriteBundleZip(e.stdout, files)
+	}
+	if err := wg.WriteBundle(path, files); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "wrote %d clients to %s\n", (len(files)-1)/2, path)
+	return nil
+}
+
+func runImport(e *env, args []string) error {
+	fs := newFlagSet(e, "import")
+	name := fs.String("name", "", "profile name (default: the name in a JSON/YAML document, else the file name)")
+	force := fs.Bool("force", false, "replace an existing profile")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 1, 1); err != nil {
+		return err
+	}
+	src := rest[0]
+
+	var data []byte
+	if src == "-" {
+		data, err = io.ReadAll(e.stdin)
+	} else {
+		data, err = os.ReadFile(src)
+	}
+	if err != nil {
+		return err
+	}
+	iface, err := parseImport(src, data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", src, err)
+	}
+
+	if *name != "" {
+		iface.Name = *name
+	}
+	if iface.Name == "" {
+		if src == "-" {
+			return usagef("--name is required when reading a nameless profile from stdin")
+		}
+		base := filepath.Base(src)
+		iface.Name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	if !wg.ValidInterfaceName(iface.Name) {
+		return usagef("invalid profile name %q: use up to 15 letters, digits, '-' or '_'", iface.Name)
+	}
+
+	diags := wg.Validate(iface)
+	for _, d := range diags {
+		fmt.Fprintf(e.stderr, "%s: %s: %s\n", src, d.Severity, d)
+	}
+	if wg.HasErrors(diags) {
+		return fmt.Errorf("%s has errors, not imported", src)
+	}
+
+	iface.Dir = e.mgr.ConfigDirs()[0]
+	_, exists, err := e.mgr.DiffConfig(iface.Dir, iface)
+	if err != nil {
+		return err
+	}
+	if exists && !*force {
+		return fmt.Errorf("profile %q already exists in %s (use --force to replace it)", iface.Name, iface.Dir)
+	}
+	if err := e.mgr.SaveConfig(iface.Dir, iface); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "imported %s\n", iface.Path())
+	return nil
+}
+
+func runDelete(e *env, args []string) error {
+	rest, err := parseArgs(newFlagSet(e, "delete"), args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 1, 1); err != nil {
+		return err
+	}
+	p, err := findProfile(e, rest[0])
+	if err != nil {
+		return err
+	}
+	if err := e.mgr.DeleteProfile(p); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "deleted %s\n", p.Path())
+	return nil
+}
+
+// parseImport parses an import source as an InterfaceDoc in JSON (content
+// starting with "{") or YAML (a .yaml/.yml file), or else as a .conf file.
+func parseImport(src string, data []byte) (*wg.Interface, error) {
+	switch ext := strings.ToLower(filepath.Ext(src)); {
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+		return wg.ParseInterfaceJSON(data)
+	case ext == ".yaml" || ext == ".yml":
+		return wg.ParseInterfaceYAML(data)
+	}
+	return wg.ParseConfig(bytes.NewReader(data))
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// runPruneExpired removes peers whose Expires date has passed from the
+// named profile, or from every profile. With --every it keeps running and
+// sweeps again after each interval, reporting failures without stopping.
+func runPruneExpired(e *env, args []string) error {
+	fs := newFlagSet(e, "prune-expired")
+	dryRun := fs.Bool("dry-run", false, "list expired peers without removing them")
+	every := fs.Duration("every", 0, "keep running and sweep again at this interval")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest, 0, 1); err != nil {
+		return err
+	}
+	if *every < 0 {
+		return usagef("--every must not be negative")
+	}
+
+	for {
+		err := pruneSweep(e, rest, *dryRun)
+		if *every == 0 {
+			return err
+		}
+		if err != nil {
+			fmt.Fprintf(e.stderr, "prune-expired: %v\n", err)
+		}
+		if !e.wait(*every) {
+			return nil
+		}
+	}
+}
+
+// pruneSweep prunes the profile named in args, or all profiles. A failing
+// profile doesn't stop the others; the errors are returned together.
+func pruneSweep(e *env, args []string, dryRun bool) error {
+	var profiles []*wg.Interface
+	var errs []error
+	if len(args) == 1 {
+		p, err := findProfile(e, args[0])
+		if err != nil {
+			return err
+		}
+		profiles = []*wg.Interface{p}
+	} else {
+		var err error
+		profiles, err = e.mgr.LoadProfiles()
+		if err != nil {
+			if len(profiles) == 0 {
+				return err
+			}
+			// Sweep the profiles that loaded and report the rest.
+			errs = append(errs, err)
+		}
+		sortProfiles(profiles)
+	}
+
+	now := e.now()
+	for _, p := range profiles {
+		if dryRun {
+			for _, peer := range wg.ExpiredPeers(p, now) {
+				fmt.Fprintf(e.stdout, "%s: would remove %s, expired %s\n", p.Name, peerName(peer), wg.FormatMetaTime(peer.Expires))
+			}
+			continue
+		}
+		removed, live, err := e.mgr.PruneExpired(p, now)
+		for _, peer := range removed {
+			where := "from the config"
+			if live {
+				where = "from the config and the running interface"
+			}
+			fmt.Fprintf(e.stdout, "%s: removed %s %s, expired %s\n", p.Name, peerName(peer), where, wg.FormatMetaTime(peer.Expires))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// peerName names a peer in messages: its Name metadata with its public
+// key, or just the key.
+func peerName(p wg.Peer) string {
+	if p.Name == "" {
+		return p.PublicKey
+	}
+	return fmt.Sprintf("%s (%s)", p.Name, p.PublicKey)
+}
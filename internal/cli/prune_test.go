@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// expiringConfig has one peer that expired a day before testNow and one
+// that is still valid.
+const expiringConfig = `[Interface]
+PrivateKey = ` + testPrivKey + `
+Address = 10.0.0.1/24
+
+[Peer]
+# Name = contractor
+# Expires = 2026-01-01
+PublicKey = ` + testPubKey + `
+AllowedIPs = 10.0.0.2/32
+
+[Peer]
+# Expires = 2027-01-01
+PublicKey = ` + testPrivKey + `
+AllowedIPs = 10.0.0.3/32
+`
+
+func TestPruneExpired(t *testing.T) {
+	e, sys, dirs := newTestEnv(t, map[string]string{"wg0.conf": expiringConfig, "wg1.conf": testConfig})
+	sys.up["wg0"] = true
+	path := filepath.Join(dirs[0], "wg0.conf")
+
+	if code := run(e, []string{"prune-expired", "--dry-run"}); code != ExitOK {
+		t.Fatalf("prune-expired --dry-run exit %d: %s", code, stderr(e))
+	}
+	if want := "wg0: would remove contractor (" + testPubKey + "), expired 2026-01-01\n"; stdout(e) != want {
+		t.Errorf("dry run output = %q, want %q", stdout(e), want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != expiringConfig {
+		t.Error("dry run changed the config")
+	}
+
+	e.stdout = &bytes.Buffer{}
+	if code := run(e, []string{"prune-expired"}); code != ExitOK {
+		t.Fatalf("prune-expired exit %d: %s", code, stderr(e))
+	}
+	if !strings.Contains(stdout(e), "wg0: removed contractor ("+testPubKey+") from the config and the running interface") {
+		t.Errorf("output = %q", stdout(e))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || strings.Contains(string(data), "contractor") || !strings.Contains(string(data), "2027-01-01") {
+		t.Errorf("pruned config = %s, %v", data, err)
+	}
+	var removes, quick int
+	for _, c := range sys.ran {
+		switch {
+		case c == "wg set wg0 peer "+testPubKey+" remove":
+			removes++
+		case strings.HasPrefix(c, "wg-quick"):
+			quick++
+		}
+	}
+	if removes != 1 || quick != 0 {
+		t.Errorf("ran %q; want one live removal and no restart", sys.ran)
+	}
+
+	// Nothing is left to prune.
+	e.stdout = &bytes.Buffer{}
+	if code := run(e, []string{"prune-expired", "wg0"}); code != ExitOK || stdout(e) != "" {
+		t.Errorf("second prune exit %d, output %q", code, stdout(e))
+	}
+	if code := run(e, []string{"prune-expired", "nope"}); code != ExitNotFound {
+		t.Errorf("prune-expired nope exit %d, want %d", code, ExitNotFound)
+	}
+}
+
+func TestPruneExpiredEvery(t *testing.T) {
+	e, _, _ := newTestEnv(t, map[string]string{"wg0.conf": expiringConfig})
+	sweeps := 0
+	e.wait = func(d time.Duration) bool {
+		if d != time.Hour {
+			t.Errorf("waited %v, want 1h", d)
+		}
+		sweeps++
+		return sweeps < 2
+	}
+
+	if code := run(e, []string{"prune-expired", "--every", "1h"}); code != ExitOK {
+		t.Fatalf("prune-expired --every exit %d: %s", code, stderr(e))
+	}
+	if sweeps != 2 || strings.Count(stdout(e), "removed contractor") != 1 {
+		t.Errorf("%d sweeps, output %q; want 2 sweeps removing once", sweeps, stdout(e))
+	}
+}
+
+func TestPruneExpiredLoadError(t *testing.T) {
+	// A profile that doesn't parse fails its whole root; the other root
+	// is still swept.
+	e, _, _ := newTestEnv(t, map[string]string{"wg0.conf": expiringConfig}, map[string]string{"bad.conf": "not a config\n"})
+
+	if code := run(e, []string{"prune-expired"}); code != ExitFailure {
+		t.Errorf("prune-expired exit %d, want %d", code, ExitFailure)
+	}
+	if !strings.Contains(stdout(e), "wg0: removed contractor") {
+		t.Errorf("output = %q; want the loaded profile pruned", stdout(e))
+	}
+	if !strings.Contains(stderr(e), "bad") {
+		t.Errorf("stderr = %q; want the load error", stderr(e))
+	}
+}
@@ -0,0 +1,157 @@
+// Package notify sends desktop notifications about WireGuard tunnels and
+// decides when a tunnel's state change deserves one.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// Urgency is the freedesktop notification urgency level.
+type Urgency byte
+
+const (
+	Low      Urgency = 0
+	Normal   Urgency = 1
+	Critical Urgency = 2
+)
+
+func (u Urgency) String() string {
+	switch u {
+	case Low:
+		return "low"
+	case Critical:
+		return "critical"
+	}
+	return "normal"
+}
+
+// appName and icon identify the notifications to the notification daemon.
+const (
+	appName = "wireguard-tui"
+	icon    = "network-vpn"
+)
+
+// sendTimeout bounds how long a notification command may take; a hung
+// session bus must not stall the caller.
+const sendTimeout = 5 * time.Second
+
+// Notification is one desktop notification.
+type Notification struct {
+	Summary string
+	Body    string
+	Urgency Urgency
+}
+
+// Notifier shows notifications.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// Command sends notifications by running an external command through a
+// wg.Runner: gdbus calling org.freedesktop.Notifications.Notify on the
+// session bus, or notify-send.
+type Command struct {
+	Runner wg.Runner
+	Tool   string // "gdbus" or "notify-send"
+}
+
+// Notify implements Notifier.
+func (c Command) Notify(n Notification) error {
+	cmd := wg.Command{Name: c.Tool}
+	switch c.Tool {
+	case "gdbus":
+		cmd.Args = gdbusArgs(n)
+	case "notify-send":
+		cmd.Args = notifySendArgs(n)
+	default:
+		return fmt.Errorf("unknown notification tool %q", c.Tool)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	stdout, stderr, err := c.Runner.Run(ctx, cmd)
+	if err != nil {
+		out := strings.TrimSpace(string(stderr) + string(stdout))
+		return fmt.Errorf("%s: %w: %s", c.Tool, err, out)
+	}
+	return nil
+}
+
+// gdbusArgs calls the Notify method directly. gdbus parses each argument
+// as GVariant text, so strings are quoted and numbers typed (which also
+// keeps -1 from looking like an option).
+func gdbusArgs(n Notification) []string {
+	return []string{
+		"call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		gvariantString(appName),
+		"uint32 0", // replaces_id: a new notification
+		gvariantString(icon),
+		gvariantString(n.Summary),
+		gvariantString(n.Body),
+		"@as []", // actions
+		"{'urgency': <byte " + strconv.Itoa(int(n.Urgency)) + ">}",
+		"int32 -1", // expire_timeout: the server's default
+	}
+}
+
+func notifySendArgs(n Notification) []string {
+	return []string{"--app-name=" + appName, "--icon=" + icon, "--urgency=" + n.Urgency.String(), "--", n.Summary, n.Body}
+}
+
+var gvariantEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`)
+
+// gvariantString quotes s as a GVariant text-format string.
+func gvariantString(s string) string {
+	return "'" + gvariantEscaper.Replace(s) + "'"
+}
+
+// Fallback tries each Notifier in order until one succeeds.
+type Fallback []Notifier
+
+// Notify implements Notifier, returning the errors of all if none works.
+func (f Fallback) Notify(n Notification) error {
+	var errs []error
+	for _, nf := range f {
+		err := nf.Notify(n)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return errors.New("no notification method available")
+	}
+	return errors.Join(errs...)
+}
+
+// New returns a Notifier for the desktop session: gdbus when a session
+// bus is known, then notify-send, whichever are installed. It returns nil
+// when neither is, e.g. on a headless host or under sudo, which drops
+// DBUS_SESSION_BUS_ADDRESS.
+func New(r wg.Runner) Notifier {
+	var f Fallback
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		if _, err := exec.LookPath("gdbus"); err == nil {
+			f = append(f, Command{Runner: r, Tool: "gdbus"})
+		}
+	}
+	if _, err := exec.LookPath("notify-send"); err == nil {
+		f = append(f, Command{Runner: r, Tool: "notify-send"})
+	}
+	if len(f) == 0 {
+		return nil
+	}
+	return f
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// fakeRunner records commands and fails those whose name is in fail.
+type fakeRunner struct {
+	ran  []wg.Command
+	fail map[string]bool
+}
+
+func (f *fakeRunner) Run(_ context.Context, cmd wg.Command) ([]byte, []byte, error) {
+	f.ran = append(f.ran, cmd)
+	if f.fail[cmd.Name] {
+		return nil, []byte("Cannot autolaunch D-Bus without X11 $DISPLAY"), &wg.ExitError{Code: 1}
+	}
+	return nil, nil, nil
+}
+
+func TestCommandGdbus(t *testing.T) {
+	r := &fakeRunner{}
+	n := Notification{Summary: "wg0 went down", Body: "it's gone\nreally", Urgency: Critical}
+	if err := (Command{Runner: r, Tool: "gdbus"}).Notify(n); err != nil {
+		t.Fatal(err)
+	}
+	got := r.ran[0].String()
+	want := "gdbus call --session --dest org.freedesktop.Notifications --object-path /org/freedesktop/Notifications" +
+		" --method org.freedesktop.Notifications.Notify 'wireguard-tui' uint32 0 'network-vpn' 'wg0 went down'" +
+		` 'it\'s gone\nreally' @as [] {'urgency': <byte 2>} int32 -1`
+	if got != want {
+		t.Errorf("ran\n%s\nwant\n%s", got, want)
+	}
+	if r.ran[0].Privileged {
+		t.Error("notification command runs privileged")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	r := &fakeRunner{fail: map[string]bool{"gdbus": true}}
+	f := Fallback{Command{Runner: r, Tool: "gdbus"}, Command{Runner: r, Tool: "notify-send"}}
+	if err := f.Notify(Notification{Summary: "s", Body: "b", Urgency: Normal}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.ran) != 2 || r.ran[1].String() != "notify-send --app-name=wireguard-tui --icon=network-vpn --urgency=normal -- s b" {
+		t.Errorf("ran %v", r.ran)
+	}
+
+	r.fail["notify-send"] = true
+	err := f.Notify(Notification{Summary: "s"})
+	if err == nil || !strings.Contains(err.Error(), "autolaunch") {
+		t.Errorf("all failing: err = %v", err)
+	}
+	var exit *wg.ExitError
+	if !errors.As(err, &exit) {
+		t.Errorf("error %v does not wrap the exit status", err)
+	}
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// Watcher compares successive health checks of the active interfaces and
+// returns the notifications they call for: an interface that went down
+// without being asked to, and a handshake that went stale or dead.
+type Watcher struct {
+	enabled  func(iface string) bool
+	prev     map[string]wg.Health // nil until the first check
+	expected map[string]bool      // interfaces brought down on purpose
+}
+
+// NewWatcher returns a Watcher that only notifies about interfaces for
+// which enabled returns true. A nil enabled notifies about all.
+func NewWatcher(enabled func(iface string) bool) *Watcher {
+	if enabled == nil {
+		enabled = func(string) bool { return true }
+	}
+	return &Watcher{enabled: enabled, expected: make(map[string]bool)}
+}
+
+// Expect records that iface is being brought down on purpose, so its
+// disappearance at the next check is not reported.
+func (w *Watcher) Expect(iface string) {
+	w.expected[iface] = true
+}
+
+// Update takes the health of every active interface and returns the
+// notifications for what changed since the previous call. The first call
+// only records the state: tunnels that were already stale when watching
+// started are not reported.
+func (w *Watcher) Update(health map[string]wg.Health) []Notification {
+	prev := w.prev
+	w.prev = health
+	expected := w.expected
+	w.expected = make(map[string]bool)
+	if prev == nil {
+		return nil
+	}
+
+	var out []Notification
+	for _, name := range sortedNames(prev) {
+		if _, up := health[name]; up || expected[name] || !w.enabled(name) {
+			continue
+		}
+		out = append(out, Notification{
+			Summary: fmt.Sprintf("WireGuard: %s went down", name),
+			Body:    "The interface is no longer active.",
+			Urgency: Critical,
+		})
+	}
+	for _, name := range sortedNames(health) {
+		h := health[name]
+		before, seen := prev[name]
+		if h < wg.HealthStale || (seen && before >= h) || !w.enabled(name) {
+			continue
+		}
+		n := Notification{
+			Summary: fmt.Sprintf("WireGuard: %s is %s", name, h),
+			Body:    "No recent handshake with a peer; the tunnel may not be passing traffic.",
+			Urgency: Normal,
+		}
+		if h == wg.HealthDead {
+			n.Urgency = Critical
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// SendAll shows each of ns with n, returning the first error. A nil n
+// drops them.
+func SendAll(n Notifier, ns []Notification) error {
+	if n == nil {
+		return nil
+	}
+	var first error
+	for _, x := range ns {
+		if err := n.Notify(x); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func sortedNames(m map[string]wg.Health) []string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
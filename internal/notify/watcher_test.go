@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// recorder is a fake Notifier that keeps what it was sent.
+type recorder struct{ got []Notification }
+
+func (r *recorder) Notify(n Notification) error {
+	r.got = append(r.got, n)
+	return nil
+}
+
+func TestWatcher(t *testing.T) {
+	type health = map[string]wg.Health
+	const (
+		ok    = wg.HealthHealthy
+		stale = wg.HealthStale
+		dead  = wg.HealthDead
+	)
+
+	w := NewWatcher(func(iface string) bool { return iface != "lab" })
+	rec := &recorder{}
+	steps := []struct {
+		name   string
+		expect string // Expect()ed before the check
+		health health
+		want   []string
+	}{
+		{"first check records only", "", health{"wg0": stale, "wg1": ok, "lab": ok}, nil},
+		{"no change", "", health{"wg0": stale, "wg1": ok, "lab": ok}, nil},
+		{"goes stale", "", health{"wg0": stale, "wg1": stale, "lab": stale}, []string{"WireGuard: wg1 is stale"}},
+		{"goes dead", "", health{"wg0": dead, "wg1": stale, "lab": dead}, []string{"WireGuard: wg0 is dead"}},
+		{"recovers", "", health{"wg0": ok, "wg1": ok, "lab": ok}, nil},
+		{"dropped", "", health{"wg1": ok}, []string{"WireGuard: wg0 went down"}},
+		{"brought down on purpose", "wg1", health{}, nil},
+		{"comes back stale", "", health{"wg0": stale}, []string{"WireGuard: wg0 is stale"}},
+	}
+	for _, step := range steps {
+		if step.expect != "" {
+			w.Expect(step.expect)
+		}
+		rec.got = nil
+		if err := SendAll(rec, w.Update(step.health)); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, n := range rec.got {
+			got = append(got, n.Summary)
+		}
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: notified %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestWatcherUrgency(t *testing.T) {
+	w := NewWatcher(nil)
+	w.Update(map[string]wg.Health{"wg0": wg.HealthHealthy, "wg1": wg.HealthHealthy})
+	got := w.Update(map[string]wg.Health{"wg0": wg.HealthStale})
+	if len(got) != 2 || got[0].Urgency != Critical || got[1].Urgency != Normal {
+		t.Errorf("Update() = %+v, want wg1 down (critical) then wg0 stale (normal)", got)
+	}
+}
@@ -0,0 +1,168 @@
+// Package settings reads the wireguard-tui settings file and resolves
+// options that can also come from flags and environment variables.
+package settings
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables that override the settings file.
+const (
+	EnvConfigDir = "WIREGUARD_TUI_CONFIG_DIR" // list separated like PATH
+	EnvPrivilege = "WIREGUARD_TUI_PRIVILEGE"
+)
+
+// Settings holds the options read from the settings file. The file uses
+// the same "Key = value" lines as WireGuard configs, with # comments:
+//
+//	config_dir = /etc/wireguard
+//	config_dir = ~/.config/wireguard
+//	privilege = sudo
+//	notify = all, !lab
+//
+// config_dir may repeat; each adds a profile root, the first being where
+// new profiles are saved. notify lists the profiles that raise desktop
+// notifications; see NotifyFor.
+type Settings struct {
+	ConfigDirs []string
+	Privilege  string
+	Notify     []string
+}
+
+// DefaultPath returns the settings file location,
+// $XDG_CONFIG_HOME/wireguard-tui/config (usually ~/.config/wireguard-tui/config).
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "wireguard-tui", "config")
+}
+
+// Load reads the settings file at path. A missing file is not an error and
+// yields empty Settings.
+func Load(path string) (*Settings, error) {
+	s := &Settings{}
+	if path == "" {
+		return s, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading settings: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s line %d: expected key = value, got %q", path, lineNum, line)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "config_dir":
+			s.ConfigDirs = append(s.ConfigDirs, ExpandHome(value))
+		case "privilege":
+			s.Privilege = value
+		case "notify":
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					s.Notify = append(s.Notify, item)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s line %d: unknown setting %q", path, lineNum, key)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading settings: %w", err)
+	}
+	return s, nil
+}
+
+// ResolveConfigDirs picks the profile roots: directories given as flags
+// win over the environment variable, which wins over the settings file.
+// Empty entries are dropped; with nothing set the result is nil, meaning
+// the default /etc/wireguard.
+func ResolveConfigDirs(flagDirs []string, env string, s *Settings) []string {
+	var dirs []string
+	switch {
+	case len(flagDirs) > 0:
+		dirs = flagDirs
+	case env != "":
+		dirs = filepath.SplitList(env)
+	default:
+		dirs = s.ConfigDirs
+	}
+
+	var out []string
+	for _, d := range dirs {
+		if d = strings.TrimSpace(d); d != "" {
+			out = append(out, filepath.Clean(ExpandHome(d)))
+		}
+	}
+	return out
+}
+
+// ResolvePrivilege picks the privilege method: flag, then environment,
+// then settings file. An empty result means auto-detect.
+func ResolvePrivilege(flagValue, env string, s *Settings) string {
+	switch {
+	case flagValue != "":
+		return flagValue
+	case env != "":
+		return env
+	}
+	return s.Privilege
+}
+
+// NotifyFor reports whether profile raises desktop notifications. The
+// notify entries are "off", "all", "!name" to exclude a profile, or
+// profile names; naming profiles limits notifications to those. Without
+// any entry every profile notifies.
+func (s *Settings) NotifyFor(profile string) bool {
+	listed, limited := false, false
+	for _, item := range s.Notify {
+		switch {
+		case item == "off", item == "!"+profile:
+			return false
+		case item == profile:
+			listed = true
+		case item != "all" && !strings.HasPrefix(item, "!"):
+			limited = true
+		}
+	}
+	return listed || !limited
+}
+
+// ExpandHome replaces a leading ~ with the user's home directory.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
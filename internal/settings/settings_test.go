@@ -0,0 +1,138 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeSettings(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	path := writeSettings(t, `# profile roots
+config_dir = /etc/wireguard
+Config_Dir = ~/.config/wireguard   # user profiles
+
+privilege = doas
+`)
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	want := []string{"/etc/wireguard", "/home/me/.config/wireguard"}
+	if !reflect.DeepEqual(s.ConfigDirs, want) {
+		t.Errorf("ConfigDirs = %q, want %q", s.ConfigDirs, want)
+	}
+	if s.Privilege != "doas" {
+		t.Errorf("Privilege = %q, want doas", s.Privilege)
+	}
+}
+
+func TestNotifyFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		notify  map[string]bool
+	}{
+		{"default all", "", map[string]bool{"wg0": true, "lab": true}},
+		{"listed only", "notify = wg0, office\n", map[string]bool{"wg0": true, "office": true, "lab": false}},
+		{"all but one", "notify = all\nnotify = !lab\n", map[string]bool{"wg0": true, "lab": false}},
+		{"exclusion only", "notify = !lab\n", map[string]bool{"wg0": true, "lab": false}},
+		{"off", "notify = wg0\nnotify = off\n", map[string]bool{"wg0": false}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Load(writeSettings(t, tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for profile, want := range tc.notify {
+				if got := s.NotifyFor(profile); got != want {
+					t.Errorf("NotifyFor(%q) = %v, want %v", profile, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "nope"))
+	if err != nil {
+		t.Fatalf("Load returned error for missing file: %v", err)
+	}
+	if len(s.ConfigDirs) != 0 || s.Privilege != "" {
+		t.Errorf("Load() = %+v, want empty settings", s)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no equals", "config_dir /tmp\n", "line 1: expected key = value"},
+		{"unknown key", "\nconfdir = /tmp\n", `line 2: unknown setting "confdir"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writeSettings(t, tc.content))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Load() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveConfigDirs(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	file := &Settings{ConfigDirs: []string{"/from/file"}}
+
+	tests := []struct {
+		name  string
+		flags []string
+		env   string
+		file  *Settings
+		want  []string
+	}{
+		{"flags win", []string{"/a", "~/b/"}, "/env", file, []string{"/a", "/home/me/b"}},
+		{"env over file", nil, "/env1:/env2:", file, []string{"/env1", "/env2"}},
+		{"file", nil, "", file, []string{"/from/file"}},
+		{"default", nil, "", &Settings{}, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ResolveConfigDirs(tc.flags, tc.env, tc.file)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ResolveConfigDirs() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolvePrivilege(t *testing.T) {
+	file := &Settings{Privilege: "doas"}
+	if got := ResolvePrivilege("root", "sudo", file); got != "root" {
+		t.Errorf("flag: got %q, want root", got)
+	}
+	if got := ResolvePrivilege("", "sudo", file); got != "sudo" {
+		t.Errorf("env: got %q, want sudo", got)
+	}
+	if got := ResolvePrivilege("", "", file); got != "doas" {
+		t.Errorf("file: got %q, want doas", got)
+	}
+}
@@ -0,0 +1,232 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// Fields of the add-client form.
+const (
+	clientFieldName = iota
+	clientFieldEndpoint
+	clientFieldAllowedIPs
+	clientFieldDNS
+	clientFieldKeepalive
+	clientFieldCount
+)
+
+var clientFieldLabels = [clientFieldCount]string{
+	"Client name:",
+	"Server endpoint:",
+	"Client AllowedIPs:",
+	"Client DNS:",
+	"Keepalive:",
+}
+
+// addClientModel provisions a client of a server profile: the next free
+// address, fresh keys and a PSK, a [Peer] on the server, and the client's
+// config to export.
+type addClientModel struct {
+	server *wg.Interface
+	inputs []textinput.Model
+	focus  int
+
+	// client is the config being added, kept while its server change is
+	// being confirmed.
+	client *wg.Interface
+	err    error
+}
+
+// clientAddedMsg is sent after the server profile was saved with the new
+// client.
+type clientAddedMsg struct {
+	server   *wg.Interface
+	client   *wg.Interface
+	applied  wg.ApplyReport
+	applyErr error
+	isUp     bool
+}
+
+func newAddClientModel(server *wg.Interface) addClientModel {
+	inputs := make([]textinput.Model, clientFieldCount)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].CharLimit = 200
+	}
+	inputs[clientFieldName].Placeholder = "laptop"
+	inputs[clientFieldName].CharLimit = 15
+	port := server.ListenPort
+	if port == 0 {
+		port = 51820
+	}
+	inputs[clientFieldEndpoint].Placeholder = fmt.Sprintf("vpn.example.com:%d", port)
+	var subnets []string
+	for _, a := range server.Address {
+		subnets = append(subnets, a.Masked().String())
+	}
+	inputs[clientFieldAllowedIPs].SetValue(strings.Join(subnets, ", "))
+	inputs[clientFieldAllowedIPs].Placeholder = "0.0.0.0/0, ::/0 for a full tunnel"
+	inputs[clientFieldDNS].Placeholder = "optional, e.g. 1.1.1.1"
+	inputs[clientFieldKeepalive].SetValue("25")
+	inputs[clientFieldKeepalive].CharLimit = 5
+	inputs[clientFieldName].Focus()
+
+	return addClientModel{server: server, inputs: inputs}
+}
+
+// addClientAction saves the server profile with its new client peer, and
+// stores the client's config for later bundle exports.
+type addClientAction struct {
+	mgr    *wg.Manager
+	old    *wg.Interface // the server before the client was added
+	server *wg.Interface
+	client *wg.Interface
+}
+
+func (c addClientAction) execute() tea.Msg {
+	clients, err := c.mgr.Clients(c.server)
+	if err != nil {
+		return errMsg{err: err}
+	}
+	for _, existing := range clients {
+		if existing.Name == c.client.Name {
+			return errMsg{err: fmt.Errorf("%s already has a client named %q", c.server.Name, c.client.Name)}
+		}
+	}
+	// The client goes first: if the server fails to save, an unused
+	// client config is left behind rather than a peer whose key is lost.
+	if err := c.mgr.SaveClient(c.server, c.client); err != nil {
+		return errMsg{err: err}
+	}
+	if err := c.mgr.SaveConfig(c.server.Dir, c.server); err != nil {
+		return errMsg{err: err}
+	}
+	applied, up, err := applySaved(c.mgr, c.old, c.server)
+	return clientAddedMsg{server: c.server, client: c.client, applied: applied, applyErr: err, isUp: up}
+}
+
+// clientAdded shows the new client's config for export, returning to the
+// server's detail view from there.
+func (a App) clientAdded(msg clientAddedMsg) (App, tea.Cmd) {
+	a.detail = newDetailModel(msg.server, msg.isUp, a.list.health, a.list.peerHealth)
+	a.exportView = newExportModel(msg.client)
+	a.currentView = viewExport
+	a.message = fmt.Sprintf("Added client %q to %s", msg.client.Name, msg.server.Name) + appliedNote(msg.applied)
+	a.err = applyError(msg.applyErr)
+	return a, clearMessages()
+}
+
+// build provisions the client from the form.
+func (m addClientModel) build() (server, client *wg.Interface, err error) {
+	name := strings.TrimSpace(m.inputs[clientFieldName].Value())
+	if !wg.ValidInterfaceName(name) {
+		return nil, nil, fmt.Errorf("invalid client name %q: use up to 15 letters, digits, '-' or '_'", name)
+	}
+	opts := wg.ClientOptions{
+		Name:     name,
+		Endpoint: strings.TrimSpace(m.inputs[clientFieldEndpoint].Value()),
+	}
+	if opts.AllowedIPs, err = wg.ParsePrefixes(m.inputs[clientFieldAllowedIPs].Value()); err != nil {
+		return nil, nil, fmt.Errorf("client AllowedIPs: %w", err)
+	}
+	if opts.DNS, _, err = wg.ParseDNS(m.inputs[clientFieldDNS].Value()); err != nil {
+		return nil, nil, fmt.Errorf("client DNS: %w", err)
+	}
+	if s := strings.TrimSpace(m.inputs[clientFieldKeepalive].Value()); s != "" {
+		if opts.PersistentKeepalive, err = strconv.Atoi(s); err != nil {
+			return nil, nil, fmt.Errorf("keepalive must be a number of seconds")
+		}
+	}
+
+	server, client, err = wg.AddClient(m.server, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, iface := range []*wg.Interface{server, client} {
+		for _, d := range wg.Validate(iface) {
+			if d.Severity == wg.SeverityError {
+				return nil, nil, fmt.Errorf("%s: %s", iface.Name, d)
+			}
+		}
+	}
+	return server, client, nil
+}
+
+func (a App) updateAddClient(msg tea.Msg) (App, tea.Cmd) {
+	m := &a.addClient
+
+	switch msg := msg.(type) {
+	case configDiffMsg:
+		a.confirm = newDiffConfirmModel(
+			fmt.Sprintf("Add client %q to %s?", m.client.Name, msg.iface.Name),
+			msg.diff,
+			addClientAction{mgr: a.mgr, old: m.server, server: msg.iface, client: m.client},
+			viewAddClient,
+		)
+		a.currentView = viewConfirm
+		return a, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			a.currentView = viewDetail
+			return a, nil
+		case "tab", "down":
+			m.setFocus((m.focus + 1) % clientFieldCount)
+			return a, nil
+		case "shift+tab", "up":
+			m.setFocus((m.focus + clientFieldCount - 1) % clientFieldCount)
+			return a, nil
+		case "enter":
+			if m.focus < clientFieldCount-1 {
+				m.setFocus(m.focus + 1)
+				return a, nil
+			}
+			server, client, err := m.build()
+			m.err = err
+			if err != nil {
+				return a, nil
+			}
+			m.client = client
+			return a, diffConfig(a.mgr, server)
+		}
+
+		var cmd tea.Cmd
+		m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+		return a, cmd
+	}
+
+	return a, nil
+}
+
+func (m *addClientModel) setFocus(i int) {
+	m.inputs[m.focus].Blur()
+	m.focus = i
+	m.inputs[m.focus].Focus()
+}
+
+func (m addClientModel) view(width, height int) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Add Client to " + m.server.Name))
+	b.WriteString("\n\n")
+
+	b.WriteString("  " + descStyle.Render("Keys, a preshared key and the next free address are generated.") + "\n\n")
+	for i, in := range m.inputs {
+		b.WriteString("  " + labelStyle.Render(clientFieldLabels[i]) + in.View() + "\n")
+	}
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString("  " + wrapError(m.err, width) + "\n\n")
+	}
+
+	b.WriteString(helpKey("tab", "next field") + "  " + helpKey("enter", "add") + "  " + helpKey("esc", "cancel"))
+	return b.String()
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// Advanced interface field indices. These inputs are shared by the editor
+// (after its basic fields) and the wizard's "Routing & Hooks" step.
+const (
+	advFieldMTU = iota
+	advFieldTable
+	advFieldFwMark
+	advFieldPreUp
+	advFieldPostUp
+	advFieldPreDown
+	advFieldPostDown
+	advFieldSaveConfig
+	advFieldCount
+)
+
+// advFieldLabels maps advanced field indices to labels.
+var advFieldLabels = [advFieldCount]string{
+	"MTU:",
+	"Table:",
+	"FwMark:",
+	"PreUp:",
+	"PostUp:",
+	"PreDown:",
+	"PostDown:",
+	"SaveConfig:",
+}
+
+// advFieldKeys maps advanced field indices to the config keys they edit,
+// for matching diagnostics to inputs.
+var advFieldKeys = [advFieldCount]string{
+	"MTU",
+	"Table",
+	"FwMark",
+	"PreUp",
+	"PostUp",
+	"PreDown",
+	"PostDown",
+	"SaveConfig",
+}
+
+// hookSeparator separates multiple hook commands entered in a single field.
+const hookSeparator = ";;"
+
+// splitHooks splits a hook field into individual commands.
+func splitHooks(s string) []string {
+	var hooks []string
+	for _, h := range strings.Split(s, hookSeparator) {
+		if h = strings.TrimSpace(h); h != "" {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
+
+// joinHooks renders hook commands for editing in a single field.
+func joinHooks(hooks []string) string {
+	return strings.Join(hooks, " "+hookSeparator+" ")
+}
+
+// makeAdvancedInputs creates the advanced field inputs populated from iface.
+func makeAdvancedInputs(iface *wg.Interface) []textinput.Model {
+	inputs := make([]textinput.Model, advFieldCount)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+	}
+
+	inputs[advFieldMTU].Placeholder = "auto"
+	inputs[advFieldMTU].CharLimit = 5
+	if iface.MTU != 0 {
+		inputs[advFieldMTU].SetValue(strconv.Itoa(iface.MTU))
+	}
+
+	inputs[advFieldTable].Placeholder = "auto (or off, 1234, name)"
+	inputs[advFieldTable].CharLimit = 32
+	inputs[advFieldTable].SetValue(iface.Table)
+
+	inputs[advFieldFwMark].Placeholder = "off (or 0xca6c)"
+	inputs[advFieldFwMark].CharLimit = 10
+	if iface.FwMark != 0 {
+		inputs[advFieldFwMark].SetValue(fmt.Sprintf("0x%x", iface.FwMark))
+	}
+
+	hooks := [][]string{iface.PreUp, iface.PostUp, iface.PreDown, iface.PostDown}
+	for i, list := range hooks {
+		in := &inputs[advFieldPreUp+i]
+		in.Placeholder = "command (separate several with " + hookSeparator + ")"
+		in.CharLimit = 1000
+		in.SetValue(joinHooks(list))
+	}
+
+	inputs[advFieldSaveConfig].Placeholder = "false"
+	inputs[advFieldSaveConfig].CharLimit = 5
+	if iface.SaveConfig {
+		inputs[advFieldSaveConfig].SetValue("true")
+	}
+
+	return inputs
+}
+
+// applyAdvancedInputs parses the advanced inputs into iface. Inputs that
+// don't parse are reported as diagnostics and leave their field unchanged.
+func applyAdvancedInputs(inputs []textinput.Model, iface *wg.Interface) []wg.Diagnostic {
+	var diags []wg.Diagnostic
+
+	if s := strings.TrimSpace(inputs[advFieldMTU].Value()); s == "" {
+		iface.MTU = 0
+	} else if mtu, err := strconv.Atoi(s); err != nil {
+		diags = append(diags, inputDiag(-1, "MTU", fmt.Errorf("must be a number")))
+	} else {
+		iface.MTU = mtu
+	}
+
+	if table, err := wg.ParseTable(inputs[advFieldTable].Value()); err != nil {
+		diags = append(diags, inputDiag(-1, "Table", err))
+	} else {
+		iface.Table = table
+	}
+
+	if mark, err := wg.ParseFwMark(inputs[advFieldFwMark].Value()); err != nil {
+		diags = append(diags, inputDiag(-1, "FwMark", err))
+	} else {
+		iface.FwMark = mark
+	}
+
+	if save, err := wg.ParseSaveConfig(inputs[advFieldSaveConfig].Value()); err != nil {
+		diags = append(diags, inputDiag(-1, "SaveConfig", err))
+	} else {
+		iface.SaveConfig = save
+	}
+
+	iface.PreUp = splitHooks(inputs[advFieldPreUp].Value())
+	iface.PostUp = splitHooks(inputs[advFieldPostUp].Value())
+	iface.PreDown = splitHooks(inputs[advFieldPreDown].Value())
+	iface.PostDown = splitHooks(inputs[advFieldPostDown].Value())
+	return diags
+}
@@ -1,10 +1,14 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/mlu/wireguard-tui/internal/notify"
+	wg "github.com/mlu/wireguard-tui/internal/wg"
 )
 
 type viewType int
@@ -19,10 +23,12 @@ const (
 	viewExport
 	viewConfirm
 	viewTeleport
+	viewPassword
+	viewHistory
+	viewDashboard
+	viewAddClient
 )
 
-const configDir = "/etc/wireguard"
-
 // Custom message types
 type errMsg struct{ err error }
 type clearErrMsg struct{}
@@ -39,6 +45,7 @@ func clearMessages() tea.Cmd {
 // App is the root Bubble Tea model. It manages navigation between views.
 type App struct {
 	currentView viewType
+	mgr         *wg.Manager
 
 	list         listModel
 	detail       detailModel
@@ -49,25 +56,50 @@ type App struct {
 	exportView   exportModel
 	confirm      confirmModel
 	teleportView teleportModel
+	password     passwordModel
+	history      historyModel
+	dashboard    dashboardModel
+	addClient    addClientModel
 
-	width   int
-	height  int
+	width    int
+	height   int
 	err      error
 	message  string
+	alert    string // stale tunnels, from the background health check
 	toggling bool
+
+	// Desktop notifications, driven by the health check; nil when off.
+	notifier notify.Notifier
+	watcher  *notify.Watcher
 }
 
-// NewApp creates a new App starting at the list view.
-func NewApp() App {
+// NewApp creates a new App starting at the list view. All interface and
+// config operations go through mgr.
+func NewApp(mgr *wg.Manager) App {
 	return App{
 		currentView: viewList,
+		mgr:         mgr,
 		list:        newListModel(),
 	}
 }
 
-// Init implements tea.Model. It loads profiles on startup.
+// WithNotifications makes the App send desktop notifications with n when
+// an active tunnel goes down without being toggled here, or its handshake
+// goes stale. Only profiles for which enabled returns true notify. A nil
+// n leaves notifications off.
+func (a App) WithNotifications(n notify.Notifier, enabled func(profile string) bool) App {
+	if n != nil {
+		a.notifier = n
+		a.watcher = notify.NewWatcher(enabled)
+	}
+	return a
+}
+
+// Init implements tea.Model. It loads profiles on startup, asking for the
+// sudo password first if one is needed, and starts the background health
+// check.
 func (a App) Init() tea.Cmd {
-	return loadProfiles()
+	return tea.Batch(checkPassword(a.mgr), checkHealth(a.mgr))
 }
 
 // Update implements tea.Model.
@@ -85,10 +117,31 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case errMsg:
 		a.toggling = false
+		if errors.Is(msg.err, wg.ErrPasswordRequired) && a.mgr.AcceptsPassword() && a.currentView != viewPassword {
+			a.password = newPasswordModel(a.currentView, nil)
+			a.currentView = viewPassword
+			return a, nil
+		}
+		if a.currentView == viewConfirm && a.confirm.busy {
+			a.confirm.busy = false
+			a.currentView = a.confirm.back
+		}
 		a.err = msg.err
 		return a, clearMessages()
 
+	case passwordNeededMsg:
+		a.password = newPasswordModel(a.currentView, msg.retry)
+		a.currentView = viewPassword
+		return a, nil
+
+	case toggledMsg:
+		a.expectDown(msg.name, msg.nowUp)
+
+	case deletedMsg:
+		a.expectDown(msg.name, false)
+
 	case teleportToggleDoneMsg:
+		a.expectDown(msg.name, msg.nowUp)
 		a.toggling = false
 		state := "DOWN"
 		if msg.nowUp {
@@ -99,6 +152,9 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.list.active[msg.name] = msg.nowUp
 		return a, clearMessages()
 
+	case healthTickMsg, healthMsg:
+		return a.updateHealth(msg)
+
 	case clearErrMsg:
 		a.err = nil
 		a.message = ""
@@ -130,6 +186,14 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a, cmd = a.updateConfirm(msg)
 	case viewTeleport:
 		a, cmd = a.updateTeleport(msg)
+	case viewPassword:
+		a, cmd = a.updatePassword(msg)
+	case viewHistory:
+		a, cmd = a.updateHistory(msg)
+	case viewDashboard:
+		a, cmd = a.updateDashboard(msg)
+	case viewAddClient:
+		a, cmd = a.updateAddClient(msg)
 	}
 
 	return a, cmd
@@ -157,8 +221,19 @@ func (a App) View() string {
 		content = a.confirm.view(a.width, a.height)
 	case viewTeleport:
 		content = a.teleportView.view(a.width, a.height)
+	case viewPassword:
+		content = a.password.view(a.width, a.height)
+	case viewHistory:
+		content = a.history.view(a.width, a.height)
+	case viewDashboard:
+		content = a.dashboard.view(a.width, a.height)
+	case viewAddClient:
+		content = a.addClient.view(a.width, a.height)
 	}
 
+	if a.alert != "" {
+		content += "\n" + warningStyle.Render(a.alert)
+	}
 	if a.err != nil {
 		content += "\n" + wrapError(a.err, a.width)
 	}
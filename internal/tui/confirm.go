@@ -20,75 +20,123 @@ type confirmAction interface {
 // deleteAction deletes a WireGuard profile. If the interface is currently up,
 // it is brought down first before the config file is removed.
 type deleteAction struct {
-	name string
+	mgr     *wg.Manager
+	profile *wg.Interface
 }
 
 func (d deleteAction) execute() tea.Msg {
-	// If up, bring down first
-	up, _ := wg.IsUp(d.name)
-	if up {
-		if err := wg.Down(d.name); err != nil {
-			return errMsg{err}
-		}
-	}
-	// Delete config file
-	if err := wg.DeleteConfig(configDir, d.name); err != nil {
+	if err := d.mgr.DeleteProfile(d.profile); err != nil {
 		return errMsg{err}
 	}
-	return deletedMsg(d)
+	return deletedMsg{name: d.profile.Name}
 }
 
 type deletedMsg struct{ name string }
 
+// configDiffMsg carries what saving a profile would change on disk, for
+// showing in the confirm view before writing.
+type configDiffMsg struct {
+	iface  *wg.Interface
+	diff   string
+	exists bool
+}
+
+func diffConfig(mgr *wg.Manager, iface *wg.Interface) tea.Cmd {
+	return func() tea.Msg {
+		diff, exists, err := mgr.DiffConfig(iface.Dir, iface)
+		if err != nil {
+			return errMsg{err}
+		}
+		return configDiffMsg{iface: iface, diff: diff, exists: exists}
+	}
+}
+
 type confirmModel struct {
 	message  string
 	action   confirmAction
-	selected int // 0 = yes, 1 = no
+	selected int      // 0 = yes, 1 = no
+	back     viewType // view to return to on "no" or failure
+	busy     bool     // action is running
+
+	// diff, if set, is a unified diff of what the action changes, shown
+	// below the message; offset scrolls it.
+	diff   string
+	offset int
 }
 
-func newConfirmModel(msg string, action confirmAction) confirmModel {
+func newConfirmModel(msg string, action confirmAction, back viewType) confirmModel {
 	return confirmModel{
 		message:  msg,
 		action:   action,
 		selected: 1, // Default to No
+		back:     back,
 	}
 }
 
+// newDiffConfirmModel is newConfirmModel with a diff of the change shown
+// for review.
+func newDiffConfirmModel(msg, diff string, action confirmAction, back viewType) confirmModel {
+	c := newConfirmModel(msg, action, back)
+	c.diff = diff
+	return c
+}
+
 func (a App) updateConfirm(msg tea.Msg) (App, tea.Cmd) {
 	switch msg := msg.(type) {
 	case deletedMsg:
+		a.confirm.busy = false
 		a.message = fmt.Sprintf("Deleted profile %q", msg.name)
 		a.currentView = viewList
-		return a, tea.Batch(loadProfiles(), clearMessages())
+		return a, tea.Batch(loadProfiles(a.mgr), clearMessages())
+
+	case restoredMsg:
+		a.confirm.busy = false
+		a.detail = newDetailModel(msg.profile, a.detail.isUp, a.list.health, a.list.peerHealth)
+		a.message = fmt.Sprintf("Restored %q to the version from %s", msg.profile.Name, formatBackupTime(msg.when)) + appliedNote(msg.applied)
+		a.err = applyError(msg.applyErr)
+		a.currentView = viewDetail
+		return a, clearMessages()
+
+	case editorSavedMsg:
+		a.confirm.busy = false
+		return a.editorSaved(msg)
+
+	case importDoneMsg:
+		a.confirm.busy = false
+		return a.importDone(msg.name)
+
+	case clientAddedMsg:
+		a.confirm.busy = false
+		return a.clientAdded(msg)
 
 	case tea.KeyMsg:
+		if a.confirm.busy {
+			return a, nil
+		}
 		switch msg.String() {
 		case "left", "h":
 			a.confirm.selected = 0
 		case "right", "l":
 			a.confirm.selected = 1
+		case "up", "k":
+			if a.confirm.offset > 0 {
+				a.confirm.offset--
+			}
+		case "down", "j":
+			a.confirm.offset++
 
 		case "y":
-			action := a.confirm.action
-			a.currentView = viewList
-			return a, func() tea.Msg {
-				return action.execute()
-			}
+			return a.runConfirmed()
 
 		case "n", "esc":
-			a.currentView = viewDetail
+			a.currentView = a.confirm.back
 			return a, nil
 
 		case "enter":
 			if a.confirm.selected == 0 {
-				action := a.confirm.action
-				a.currentView = viewList
-				return a, func() tea.Msg {
-					return action.execute()
-				}
+				return a.runConfirmed()
 			}
-			// selected == 1 (No) — go back to detail
-			a.currentView = viewDetail
+			a.currentView = a.confirm.back
 			return a, nil
 		}
 	}
@@ -96,6 +144,16 @@ func (a App) updateConfirm(msg tea.Msg) (App, tea.Cmd) {
 	return a, nil
 }
 
+// runConfirmed runs the confirmed action, staying on the confirm view
+// until its result arrives.
+func (a App) runConfirmed() (App, tea.Cmd) {
+	action := a.confirm.action
+	a.confirm.busy = true
+	return a, func() tea.Msg {
+		return action.execute()
+	}
+}
+
 func (c confirmModel) view(width, height int) string {
 	var b strings.Builder
 
@@ -105,6 +163,20 @@ func (c confirmModel) view(width, height int) string {
 	b.WriteString("  " + c.message)
 	b.WriteString("\n\n")
 
+	if c.diff != "" {
+		lines := strings.Split(renderDiff(c.diff), "\n")
+		// Leave room for the title, message, buttons and help.
+		visible := max(height-10, 5)
+		offset := min(c.offset, max(len(lines)-visible, 0))
+		b.WriteString(strings.Join(lines[offset:min(offset+visible, len(lines))], "\n"))
+		b.WriteString("\n\n")
+	}
+
+	if c.busy {
+		b.WriteString("  " + descStyle.Render("Working..."))
+		return b.String()
+	}
+
 	// Render yes/no buttons
 	yesStyle := lipgloss.NewStyle().Foreground(colorDim)
 	noStyle := lipgloss.NewStyle().Foreground(colorDim)
@@ -128,7 +200,42 @@ func (c confirmModel) view(width, height int) string {
 	b.WriteString("\n\n")
 
 	help := helpKey("y", "yes") + "  " + helpKey("n", "no")
+	if c.diff != "" {
+		help += "  " + helpKey("j/k", "scroll")
+	}
 	b.WriteString("  " + help)
 
 	return b.String()
 }
+
+// applySaved applies a saved change to the running interface with
+// mgr.Apply and reports whether the interface is up. Apply finds that out
+// first, but if it failed doing so the state is asked for again, so a
+// running tunnel isn't shown as down.
+func applySaved(mgr *wg.Manager, old, updated *wg.Interface) (applied wg.ApplyReport, up bool, err error) {
+	applied, err = mgr.Apply(old, updated)
+	up = applied.Up
+	if err != nil && !up {
+		up, _ = mgr.IsUp(updated.Target())
+	}
+	return applied, up, err
+}
+
+// appliedNote describes how a saved change reached the running interface
+// (see wg.Manager.Apply), for the status message. It is empty when the
+// interface is down or nothing changed on it.
+func appliedNote(r wg.ApplyReport) string {
+	if s := r.String(); s != "" {
+		return " (" + s + ")"
+	}
+	return ""
+}
+
+// applyError reports a change that was saved but not fully applied to
+// the running interface, or nil.
+func applyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("saved, but not applied to the running interface (restart it to apply): %w", err)
+}
@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// dashboardModel shows every active interface at once, refreshing like
+// the status view.
+type dashboardModel struct {
+	statuses []*wg.InterfaceStatus
+	rates    map[string]*wg.Throughput // per interface, shared with the status view
+	cursor   int
+	loading  bool
+	err      error
+
+	// seq tags refresh ticks, so ticks from before the dashboard was
+	// left and re-entered don't start a second refresh loop.
+	seq int
+}
+
+type dashboardTickMsg struct{ seq int }
+
+type dashboardDataMsg struct {
+	seq      int
+	statuses []*wg.InterfaceStatus
+	err      error
+}
+
+func newDashboardModel() dashboardModel {
+	return dashboardModel{rates: make(map[string]*wg.Throughput), loading: true}
+}
+
+// resume starts a new refresh loop, e.g. when returning from the status
+// view.
+func (d *dashboardModel) resume(mgr *wg.Manager) tea.Cmd {
+	d.seq++
+	return fetchDashboard(mgr, d.seq)
+}
+
+// fetchDashboard reads the status of all active interfaces in one call.
+func fetchDashboard(mgr *wg.Manager, seq int) tea.Cmd {
+	return func() tea.Msg {
+		all, err := mgr.GetAllStatus()
+		return dashboardDataMsg{seq: seq, statuses: all, err: err}
+	}
+}
+
+func dashboardTick(seq int) tea.Cmd {
+	return tea.Tick(statusInterval, func(time.Time) tea.Msg {
+		return dashboardTickMsg{seq: seq}
+	})
+}
+
+func (a App) updateDashboard(msg tea.Msg) (App, tea.Cmd) {
+	d := &a.dashboard
+
+	switch msg := msg.(type) {
+	case dashboardDataMsg:
+		if msg.seq != d.seq {
+			return a, nil
+		}
+		d.loading = false
+		d.err = msg.err
+		if msg.err == nil {
+			d.statuses = msg.statuses
+			now := time.Now()
+			seen := make(map[string]bool, len(msg.statuses))
+			for _, s := range msg.statuses {
+				seen[s.Name] = true
+				if d.rates[s.Name] == nil {
+					d.rates[s.Name] = newThroughput()
+				}
+				d.rates[s.Name].Add(now, s)
+			}
+			for name := range d.rates {
+				if !seen[name] {
+					delete(d.rates, name)
+				}
+			}
+			if d.cursor >= len(d.statuses) {
+				d.cursor = max(len(d.statuses)-1, 0)
+			}
+		}
+		return a, dashboardTick(d.seq)
+
+	case dashboardTickMsg:
+		if msg.seq != d.seq {
+			return a, nil
+		}
+		return a, fetchDashboard(a.mgr, d.seq)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if d.cursor > 0 {
+				d.cursor--
+			}
+		case "down", "j":
+			if d.cursor < len(d.statuses)-1 {
+				d.cursor++
+			}
+		case "enter":
+			if len(d.statuses) > 0 {
+				name := d.statuses[d.cursor].Name
+				a.status = newStatusModel(name, viewDashboard, a.status.seq)
+				a.status.rates = d.rates[name]
+				a.status.peers = peersByKey(a.list.profiles, name)
+				a.currentView = viewStatus
+				return a, a.status.init(a.mgr)
+			}
+		case "esc", "q":
+			d.seq++ // stop refreshing
+			a.currentView = viewList
+			return a, loadProfiles(a.mgr)
+		}
+	}
+
+	return a, nil
+}
+
+// handshakeRange returns the newest and oldest latest-handshake among the
+// peers of s. oldest is zero if any peer never completed a handshake.
+func handshakeRange(s *wg.InterfaceStatus) (newest, oldest time.Time) {
+	for i, p := range s.Peers {
+		if p.LatestHandshake.After(newest) {
+			newest = p.LatestHandshake
+		}
+		if i == 0 || (!oldest.IsZero() && p.LatestHandshake.Before(oldest)) {
+			oldest = p.LatestHandshake
+		}
+	}
+	return newest, oldest
+}
+
+func (d dashboardModel) view(width, height int) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Dashboard (live)"))
+	b.WriteString("\n\n")
+
+	switch {
+	case d.loading:
+		b.WriteString("  Loading...\n\n")
+		b.WriteString(helpKey("esc", "back"))
+		return b.String()
+	case d.err != nil:
+		b.WriteString("  " + wrapError(d.err, width) + "\n\n")
+		b.WriteString("  " + descStyle.Render("Refreshing every 2s...") + "\n\n")
+		b.WriteString(helpKey("esc", "back"))
+		return b.String()
+	case len(d.statuses) == 0:
+		b.WriteString("  " + descStyle.Render("No active interfaces") + "\n\n")
+		b.WriteString("  " + descStyle.Render("Refreshing every 2s...") + "\n\n")
+		b.WriteString(helpKey("esc", "back"))
+		return b.String()
+	}
+
+	nameStyle := lipgloss.NewStyle().Bold(true).Width(15)
+	col := lipgloss.NewStyle().Width(10)
+	rateCol := lipgloss.NewStyle().Width(28)
+	ageCol := lipgloss.NewStyle().Width(14)
+
+	b.WriteString("  " + descStyle.Render(fmt.Sprintf("%-15s %-10s %-28s %-14s %-14s %s",
+		"INTERFACE", "PEERS", "RATE", "NEWEST", "OLDEST", "HEALTH")) + "\n")
+
+	now := time.Now()
+	for i, s := range d.statuses {
+		cursor := "  "
+		if i == d.cursor {
+			cursor = "> "
+		}
+
+		rate := descStyle.Render("measuring...")
+		if total, ok := d.rates[s.Name].Total(); ok {
+			rate = fmt.Sprintf("↓ %s  ↑ %s", formatRate(total.Rx), formatRate(total.Tx))
+		}
+		newest, oldest := "-", "-"
+		if len(s.Peers) > 0 {
+			n, o := handshakeRange(s)
+			newest, oldest = formatHandshake(n), formatHandshake(o)
+		}
+		health := descStyle.Render("● idle")
+		if len(s.Peers) > 0 {
+			health = healthLabel(wg.InterfaceHealth(s, now))
+		}
+
+		b.WriteString(cursor +
+			nameStyle.Render(s.Name) + " " +
+			col.Render(fmt.Sprintf("%d", len(s.Peers))) + " " +
+			rateCol.Render(rate) + " " +
+			ageCol.Render(newest) + " " +
+			ageCol.Render(oldest) + " " +
+			health + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString("  " + descStyle.Render("Refreshing every 2s...") + "\n")
+	b.WriteString("\n")
+	b.WriteString(helpKey("enter", "status") + "  " + helpKey("esc", "back"))
+
+	return b.String()
+}
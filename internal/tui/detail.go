@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/mlu/wireguard-tui/internal/teleport"
@@ -13,6 +14,16 @@ import (
 type detailModel struct {
 	profile *wg.Interface
 	isUp    bool
+	health  map[string]wg.Health // of active interfaces, from the health check
+
+	// peerHealth is the health of each peer of the active interfaces, by
+	// interface and public key.
+	peerHealth map[string]map[string]wg.Health
+
+	// search filters the peers shown by name, owner, notes, key, endpoint
+	// or allowed IPs; searching is set while it is being typed.
+	search    textinput.Model
+	searching bool
 }
 
 type toggledMsg struct {
@@ -20,10 +31,16 @@ type toggledMsg struct {
 	nowUp bool
 }
 
-func newDetailModel(profile *wg.Interface, isUp bool) detailModel {
+func newDetailModel(profile *wg.Interface, isUp bool, health map[string]wg.Health, peerHealth map[string]map[string]wg.Health) detailModel {
+	search := textinput.New()
+	search.Placeholder = "name, owner, key, IP..."
+	search.CharLimit = 100
 	return detailModel{
-		profile: profile,
-		isUp:    isUp,
+		profile:    profile,
+		isUp:       isUp,
+		health:     health,
+		peerHealth: peerHealth,
+		search:     search,
 	}
 }
 
@@ -39,10 +56,23 @@ func (a App) updateDetail(msg tea.Msg) (App, tea.Cmd) {
 		return a, clearMessages()
 
 	case tea.KeyMsg:
+		if a.detail.searching {
+			return a.detailHandleSearch(msg)
+		}
+
 		switch msg.String() {
+		case "/":
+			a.detail.searching = true
+			a.detail.search.Focus()
+			return a, nil
+
 		case "esc":
+			if a.detail.search.Value() != "" {
+				a.detail.search.SetValue("")
+				return a, nil
+			}
 			a.currentView = viewList
-			return a, loadProfiles()
+			return a, loadProfiles(a.mgr)
 
 		case "e":
 			a.editor = newEditorModel(a.detail.profile)
@@ -50,28 +80,40 @@ func (a App) updateDetail(msg tea.Msg) (App, tea.Cmd) {
 			return a, nil
 
 		case "s":
-			a.status = newStatusModel(a.detail.profile.Name)
+			a.status = newStatusModel(a.detail.profile.Name, viewDetail, a.status.seq)
+			a.status.peers = peersByKey([]*wg.Interface{a.detail.profile}, a.detail.profile.Name)
 			a.currentView = viewStatus
-			return a, a.status.init()
+			return a, a.status.init(a.mgr)
 
 		case "t":
 			if a.toggling {
 				return a, nil
 			}
-			name := a.detail.profile.Name
+			profile := a.detail.profile
+			name := profile.Name
 			if teleport.HasToken(teleport.CredentialDir, name) {
 				a.toggling = true
 				a.message = "Regenerating Teleport config..."
-				return a, teleportToggleCmd(name)
+				return a, teleportToggleCmd(a.mgr, profile)
 			}
 			return a, func() tea.Msg {
-				nowUp, err := wg.Toggle(name)
+				nowUp, err := a.mgr.Toggle(profile.Target())
 				if err != nil {
 					return errMsg{err}
 				}
 				return toggledMsg{name: name, nowUp: nowUp}
 			}
 
+		case "h":
+			a.history = newHistoryModel(a.detail.profile)
+			a.currentView = viewHistory
+			return a, loadHistory(a.mgr, a.detail.profile)
+
+		case "c":
+			a.addClient = newAddClientModel(a.detail.profile)
+			a.currentView = viewAddClient
+			return a, nil
+
 		case "x":
 			a.exportView = newExportModel(a.detail.profile)
 			a.currentView = viewExport
@@ -81,7 +123,8 @@ func (a App) updateDetail(msg tea.Msg) (App, tea.Cmd) {
 			name := a.detail.profile.Name
 			a.confirm = newConfirmModel(
 				fmt.Sprintf("Delete profile %q?", name),
-				deleteAction{name: name},
+				deleteAction{mgr: a.mgr, profile: a.detail.profile},
+				viewDetail,
 			)
 			a.currentView = viewConfirm
 			return a, nil
@@ -91,6 +134,25 @@ func (a App) updateDetail(msg tea.Msg) (App, tea.Cmd) {
 	return a, nil
 }
 
+// detailHandleSearch handles keys while the peer search is being typed:
+// enter keeps the filter, esc drops it.
+func (a App) detailHandleSearch(msg tea.KeyMsg) (App, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		a.detail.searching = false
+		a.detail.search.Blur()
+		return a, nil
+	case "esc":
+		a.detail.searching = false
+		a.detail.search.Blur()
+		a.detail.search.SetValue("")
+		return a, nil
+	}
+	var cmd tea.Cmd
+	a.detail.search, cmd = a.detail.search.Update(msg)
+	return a, cmd
+}
+
 func truncateKey(key string, max int) string {
 	if len(key) > max {
 		return key[:max] + "..."
@@ -115,11 +177,17 @@ func (d detailModel) view(width, height int) string {
 		status = statusUp
 	}
 	b.WriteString("  " + labelStyle.Render("Status:") + status + "\n")
+	if h, ok := d.health[p.Name]; ok && d.isUp {
+		b.WriteString("  " + labelStyle.Render("Health:") + healthLabel(h) + "\n")
+	}
+	if p.Dir != "" {
+		b.WriteString("  " + labelStyle.Render("File:") + valueStyle.Render(displayDir(p.Path())) + "\n")
+	}
 	b.WriteString("\n")
 
 	// Address
-	if p.Address != "" {
-		b.WriteString("  " + labelStyle.Render("Address:") + valueStyle.Render(p.Address) + "\n")
+	if len(p.Address) > 0 {
+		b.WriteString("  " + labelStyle.Render("Address:") + valueStyle.Render(wg.FormatPrefixes(p.Address)) + "\n")
 	}
 
 	// Listen Port
@@ -128,8 +196,11 @@ func (d detailModel) view(width, height int) string {
 	}
 
 	// DNS
-	if p.DNS != "" {
-		b.WriteString("  " + labelStyle.Render("DNS:") + valueStyle.Render(p.DNS) + "\n")
+	if len(p.DNS) > 0 {
+		b.WriteString("  " + labelStyle.Render("DNS:") + valueStyle.Render(wg.FormatDNS(p.DNS, nil)) + "\n")
+	}
+	if len(p.DNSSearch) > 0 {
+		b.WriteString("  " + labelStyle.Render("Search Domains:") + valueStyle.Render(strings.Join(p.DNSSearch, ", ")) + "\n")
 	}
 
 	// MTU
@@ -137,24 +208,69 @@ func (d detailModel) view(width, height int) string {
 		b.WriteString("  " + labelStyle.Render("MTU:") + valueStyle.Render(fmt.Sprintf("%d", p.MTU)) + "\n")
 	}
 
+	// Routing
+	if p.Table != "" {
+		b.WriteString("  " + labelStyle.Render("Table:") + valueStyle.Render(p.Table) + "\n")
+	}
+	if p.FwMark != 0 {
+		b.WriteString("  " + labelStyle.Render("FwMark:") + valueStyle.Render(fmt.Sprintf("0x%x", p.FwMark)) + "\n")
+	}
+
+	// Hooks
+	hooks := []struct {
+		label string
+		cmds  []string
+	}{
+		{"PreUp:", p.PreUp},
+		{"PostUp:", p.PostUp},
+		{"PreDown:", p.PreDown},
+		{"PostDown:", p.PostDown},
+	}
+	for _, h := range hooks {
+		for i, cmd := range h.cmds {
+			label := h.label
+			if i > 0 {
+				label = ""
+			}
+			b.WriteString("  " + labelStyle.Render(label) + valueStyle.Render(cmd) + "\n")
+		}
+	}
+	if p.SaveConfig {
+		b.WriteString("  " + labelStyle.Render("SaveConfig:") + valueStyle.Render("true") + "\n")
+	}
+
 	b.WriteString("\n")
 
-	// Peers count
-	b.WriteString("  " + labelStyle.Render("Peers:") + valueStyle.Render(fmt.Sprintf("%d", len(p.Peers))) + "\n")
+	// Peers count, and the search when one is set
+	query := strings.TrimSpace(d.search.Value())
+	shown := filterPeers(p, query)
+	b.WriteString("  " + labelStyle.Render("Peers:") + valueStyle.Render(peerCount(len(shown), len(p.Peers), query)) + expiredCount(p) + "\n")
+	if d.searching {
+		b.WriteString("  " + labelStyle.Render("Search:") + d.search.View() + "\n")
+	}
 
 	// Individual peers
-	for i, peer := range p.Peers {
+	for _, i := range shown {
+		peer := p.Peers[i]
 		b.WriteString("\n")
-		fmt.Fprintf(&b, "  Peer %d:\n", i+1)
+		if peer.Name != "" {
+			fmt.Fprintf(&b, "  Peer %d: %s\n", i+1, valueStyle.Render(peer.Name))
+		} else {
+			fmt.Fprintf(&b, "  Peer %d:\n", i+1)
+		}
+		b.WriteString(peerMetaLines(peer, "    "))
 
 		if peer.PublicKey != "" {
 			b.WriteString("    " + labelStyle.Render("Public Key:") + valueStyle.Render(truncateKey(peer.PublicKey, 20)) + "\n")
 		}
+		if h, ok := d.peerHealth[p.Name][peer.PublicKey]; ok && d.isUp {
+			b.WriteString("    " + labelStyle.Render("Health:") + healthLabel(h) + "\n")
+		}
 		if peer.Endpoint != "" {
 			b.WriteString("    " + labelStyle.Render("Endpoint:") + valueStyle.Render(peer.Endpoint) + "\n")
 		}
-		if peer.AllowedIPs != "" {
-			b.WriteString("    " + labelStyle.Render("Allowed IPs:") + valueStyle.Render(peer.AllowedIPs) + "\n")
+		if len(peer.AllowedIPs) > 0 {
+			b.WriteString("    " + labelStyle.Render("Allowed IPs:") + valueStyle.Render(wg.FormatPrefixes(peer.AllowedIPs)) + "\n")
 		}
 		if peer.PersistentKeepalive != 0 {
 			b.WriteString("    " + labelStyle.Render("Keepalive:") + valueStyle.Render(fmt.Sprintf("%d", peer.PersistentKeepalive)) + "\n")
@@ -162,11 +278,18 @@ func (d detailModel) view(width, height int) string {
 	}
 
 	b.WriteString("\n")
+	if d.searching {
+		b.WriteString(helpKey("enter", "keep filter") + "  " + helpKey("esc", "clear search"))
+		return b.String()
+	}
 	help := helpKey("e", "edit") + "  " +
 		helpKey("s", "status") + "  " +
 		helpKey("t", "toggle") + "  " +
 		helpKey("x", "export") + "  " +
+		helpKey("c", "add client") + "  " +
+		helpKey("h", "history") + "  " +
 		helpKey("d", "delete") + "  " +
+		helpKey("/", "search peers") + "  " +
 		helpKey("esc", "back")
 	b.WriteString(help)
 
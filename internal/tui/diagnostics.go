@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"strings"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// inputDiag turns a form input parse error into an error diagnostic for the
+// config key the input edits.
+func inputDiag(peer int, field string, err error) wg.Diagnostic {
+	return wg.Diagnostic{Severity: wg.SeverityError, Peer: peer, Field: field, Message: err.Error()}
+}
+
+// mergeDiags combines input parse diagnostics with wg.Validate results,
+// dropping validation findings for fields whose input didn't parse (they
+// would only restate the parse error, e.g. "no address set").
+func mergeDiags(inputs, validated []wg.Diagnostic) []wg.Diagnostic {
+	merged := inputs
+	for _, d := range validated {
+		if len(fieldDiags(inputs, d.Peer, d.Field)) == 0 {
+			merged = append(merged, d)
+		}
+	}
+	return merged
+}
+
+// fieldDiags returns the diagnostics for one field of the interface
+// (peer -1) or of a peer.
+func fieldDiags(diags []wg.Diagnostic, peer int, field string) []wg.Diagnostic {
+	var out []wg.Diagnostic
+	for _, d := range diags {
+		if d.Peer == peer && d.Field == field {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// peerDiags returns every diagnostic for one peer.
+func peerDiags(diags []wg.Diagnostic, peer int) []wg.Diagnostic {
+	var out []wg.Diagnostic
+	for _, d := range diags {
+		if d.Peer == peer {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// renderDiag renders a diagnostic message colored by severity.
+func renderDiag(d wg.Diagnostic, msg string) string {
+	if d.Severity == wg.SeverityError {
+		return errorStyle.Render("✗ " + msg)
+	}
+	return warningStyle.Render("! " + msg)
+}
+
+// inlineDiags renders the diagnostics of one field for display to the right
+// of its input. It returns "" when the field has none.
+func inlineDiags(diags []wg.Diagnostic, peer int, field string) string {
+	var parts []string
+	for _, d := range fieldDiags(diags, peer, field) {
+		parts = append(parts, renderDiag(d, d.Message))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "  " + strings.Join(parts, "  ")
+}
+
+// annotateConfig appends each diagnostic to the config line holding the
+// offending key. Diagnostics without a matching line (e.g. a missing key)
+// are listed after the config.
+func annotateConfig(text string, diags []wg.Diagnostic) string {
+	placed := make([]bool, len(diags))
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	section := -2 // before [Interface]
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch trimmed {
+		case "[Interface]":
+			section = -1
+			continue
+		case "[Peer]":
+			section++
+			if section < 0 {
+				section = 0
+			}
+			continue
+		}
+		key, _, ok := strings.Cut(trimmed, "=")
+		if !ok || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		for j, d := range diags {
+			if placed[j] || d.Peer != section || !strings.EqualFold(d.Field, key) {
+				continue
+			}
+			placed[j] = true
+			lines[i] += "  " + renderDiag(d, d.Message)
+		}
+	}
+
+	out := strings.Join(lines, "\n")
+	for j, d := range diags {
+		if !placed[j] {
+			out += "\n" + renderDiag(d, d.String())
+		}
+	}
+	return out
+}
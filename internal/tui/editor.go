@@ -2,8 +2,11 @@ package tui
 
 import (
 	"fmt"
+	"net/netip"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,22 +17,29 @@ import (
 // editorModel holds the state for the profile editor form.
 type editorModel struct {
 	profile    *wg.Interface
-	inputs     []textinput.Model // 4 interface fields
+	inputs     []textinput.Model // interface fields, see editorField*
 	focusIndex int
 
 	// Peer editing
 	peers       []wg.Peer
 	peerIdx     int               // which peer is selected (-1 = none)
-	peerInputs  []textinput.Model // 5 inputs for current peer
+	peerInputs  []textinput.Model // config and metadata inputs for current peer, see editorPeerFieldCount
 	peerFocus   int
 	editingPeer bool
 
+	// diags holds the validation findings for the current form contents,
+	// refreshed after every keystroke.
+	diags []wg.Diagnostic
+
 	err error
 }
 
 // editorSavedMsg is sent after the editor successfully saves a config.
 type editorSavedMsg struct {
-	profile *wg.Interface
+	profile  *wg.Interface
+	applied  wg.ApplyReport // how the change reached the running interface
+	applyErr error
+	isUp     bool
 }
 
 // Interface field indices
@@ -37,8 +47,8 @@ const (
 	editorFieldAddress    = 0
 	editorFieldListenPort = 1
 	editorFieldDNS        = 2
-	editorFieldMTU        = 3
-	editorFieldCount      = 4
+	editorFieldMTU        = 3 // first of the advanced fields, see advanced.go
+	editorFieldCount      = editorFieldMTU + advFieldCount
 )
 
 // newEditorModel creates a new editor model pre-populated with the profile's values.
@@ -58,8 +68,8 @@ func newEditorModel(profile *wg.Interface) editorModel {
 	// Address
 	e.inputs[editorFieldAddress] = textinput.New()
 	e.inputs[editorFieldAddress].Placeholder = "10.0.0.1/24"
-	e.inputs[editorFieldAddress].SetValue(profile.Address)
-	e.inputs[editorFieldAddress].CharLimit = 43
+	e.inputs[editorFieldAddress].SetValue(wg.FormatPrefixes(profile.Address))
+	e.inputs[editorFieldAddress].CharLimit = 200
 	e.inputs[editorFieldAddress].Focus()
 
 	// Listen Port
@@ -73,24 +83,22 @@ func newEditorModel(profile *wg.Interface) editorModel {
 	// DNS
 	e.inputs[editorFieldDNS] = textinput.New()
 	e.inputs[editorFieldDNS].Placeholder = "1.1.1.1, 8.8.8.8"
-	e.inputs[editorFieldDNS].SetValue(profile.DNS)
+	e.inputs[editorFieldDNS].SetValue(wg.FormatDNS(profile.DNS, profile.DNSSearch))
 	e.inputs[editorFieldDNS].CharLimit = 100
 
-	// MTU
-	e.inputs[editorFieldMTU] = textinput.New()
-	e.inputs[editorFieldMTU].Placeholder = ""
-	if profile.MTU != 0 {
-		e.inputs[editorFieldMTU].SetValue(strconv.Itoa(profile.MTU))
-	}
-	e.inputs[editorFieldMTU].CharLimit = 5
+	// MTU, routing and hooks
+	copy(e.inputs[editorFieldMTU:], makeAdvancedInputs(profile))
 
 	// Initialize peer inputs (empty, populated when editing a peer)
 	e.peerInputs = makeEditorPeerInputs()
 
+	e.validate()
+
 	return e
 }
 
-// makeEditorPeerInputs creates a fresh set of 5 text inputs for peer editing.
+// makeEditorPeerInputs creates a fresh set of text inputs for peer editing:
+// the 5 config fields, then the metadata fields.
 func makeEditorPeerInputs() []textinput.Model {
 	inputs := make([]textinput.Model, peerSubStepCount)
 
@@ -114,13 +122,13 @@ func makeEditorPeerInputs() []textinput.Model {
 	inputs[peerStepKeepalive].Placeholder = "25"
 	inputs[peerStepKeepalive].CharLimit = 5
 
-	return inputs
+	return append(inputs, makePeerMetaInputs()...)
 }
 
 // populatePeerInputs fills the peer inputs from a peer struct.
 func populatePeerInputs(inputs []textinput.Model, peer wg.Peer) []textinput.Model {
 	inputs[peerStepPubKey].SetValue(peer.PublicKey)
-	inputs[peerStepAllowedIPs].SetValue(peer.AllowedIPs)
+	inputs[peerStepAllowedIPs].SetValue(wg.FormatPrefixes(peer.AllowedIPs))
 	inputs[peerStepEndpoint].SetValue(peer.Endpoint)
 	inputs[peerStepPSK].SetValue(peer.PresharedKey)
 	if peer.PersistentKeepalive != 0 {
@@ -128,21 +136,91 @@ func populatePeerInputs(inputs []textinput.Model, peer wg.Peer) []textinput.Mode
 	} else {
 		inputs[peerStepKeepalive].SetValue("")
 	}
+	populatePeerMetaInputs(inputs, peer)
 	return inputs
 }
 
-// buildPeerFromInputs constructs a Peer from the current peer inputs.
-func buildPeerFromInputs(inputs []textinput.Model) wg.Peer {
-	keepalive := 0
+// defaultAllowedIPs routes all traffic through a peer.
+func defaultAllowedIPs() []netip.Prefix {
+	return []netip.Prefix{netip.MustParsePrefix("0.0.0.0/0"), netip.MustParsePrefix("::/0")}
+}
+
+// parsePeerInputs applies the current peer inputs to base, the peer at index
+// idx. Starting from the existing peer keeps its comments and unknown keys
+// intact on save. Inputs that don't parse are reported as diagnostics.
+func parsePeerInputs(inputs []textinput.Model, base wg.Peer, idx int) (wg.Peer, []wg.Diagnostic) {
+	var diags []wg.Diagnostic
+
+	base.PersistentKeepalive = 0
 	if ka := strings.TrimSpace(inputs[peerStepKeepalive].Value()); ka != "" {
-		keepalive, _ = strconv.Atoi(ka)
+		keepalive, err := strconv.Atoi(ka)
+		if err != nil {
+			diags = append(diags, inputDiag(idx, "PersistentKeepalive", fmt.Errorf("must be a number of seconds")))
+		}
+		base.PersistentKeepalive = keepalive
 	}
-	return wg.Peer{
-		PublicKey:           strings.TrimSpace(inputs[peerStepPubKey].Value()),
-		AllowedIPs:          strings.TrimSpace(inputs[peerStepAllowedIPs].Value()),
-		Endpoint:            strings.TrimSpace(inputs[peerStepEndpoint].Value()),
-		PresharedKey:        strings.TrimSpace(inputs[peerStepPSK].Value()),
-		PersistentKeepalive: keepalive,
+	allowedIPs, err := wg.ParsePrefixes(inputs[peerStepAllowedIPs].Value())
+	if err != nil {
+		diags = append(diags, inputDiag(idx, "AllowedIPs", err))
+	}
+	base.PublicKey = strings.TrimSpace(inputs[peerStepPubKey].Value())
+	base.AllowedIPs = allowedIPs
+	base.Endpoint = strings.TrimSpace(inputs[peerStepEndpoint].Value())
+	base.PresharedKey = strings.TrimSpace(inputs[peerStepPSK].Value())
+	return base, diags
+}
+
+// build assembles the interface described by the form, including the peer
+// being edited, and validates it.
+func (e editorModel) build() (*wg.Interface, []wg.Diagnostic) {
+	var inputDiags []wg.Diagnostic
+
+	// Start from a copy of the loaded profile so keys the editor doesn't
+	// know about and comments survive the save.
+	updated := new(wg.Interface)
+	*updated = *e.profile
+
+	address, err := wg.ParsePrefixes(e.inputs[editorFieldAddress].Value())
+	if err != nil {
+		inputDiags = append(inputDiags, inputDiag(-1, "Address", err))
+	}
+	updated.Address = address
+
+	updated.ListenPort = 0
+	if portStr := strings.TrimSpace(e.inputs[editorFieldListenPort].Value()); portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			inputDiags = append(inputDiags, inputDiag(-1, "ListenPort", fmt.Errorf("must be a number")))
+		}
+		updated.ListenPort = port
+	}
+
+	dns, search, err := wg.ParseDNS(e.inputs[editorFieldDNS].Value())
+	if err != nil {
+		inputDiags = append(inputDiags, inputDiag(-1, "DNS", err))
+	}
+	updated.DNS = dns
+	updated.DNSSearch = search
+
+	inputDiags = append(inputDiags, applyAdvancedInputs(e.inputs[editorFieldMTU:], updated)...)
+
+	updated.Peers = slices.Clone(e.peers)
+	if e.editingPeer && e.peerIdx >= 0 && e.peerIdx < len(e.peers) {
+		peer, peerDiags := parsePeerInputs(e.peerInputs, e.peers[e.peerIdx], e.peerIdx)
+		peer, metaDiags := parsePeerMetaInputs(e.peerInputs, peer, e.peerIdx)
+		updated.Peers[e.peerIdx] = peer
+		inputDiags = append(inputDiags, peerDiags...)
+		inputDiags = append(inputDiags, metaDiags...)
+	}
+
+	return updated, mergeDiags(inputDiags, wg.Validate(updated))
+}
+
+// validate refreshes the diagnostics shown next to the form fields.
+func (e *editorModel) validate() {
+	_, e.diags = e.build()
+	if !wg.HasErrors(e.diags) {
+		e.err = nil
 	}
 }
 
@@ -150,16 +228,20 @@ func (a App) updateEditor(msg tea.Msg) (App, tea.Cmd) {
 	e := &a.editor
 
 	switch msg := msg.(type) {
-	case editorSavedMsg:
-		// Return to detail view with the updated profile
-		isUp, _ := wg.IsUp(msg.profile.Name)
-		a.detail = newDetailModel(msg.profile, isUp)
-		a.currentView = viewDetail
-		a.message = fmt.Sprintf("Saved profile %q", msg.profile.Name)
-		if isUp {
-			a.message += " (restart interface for changes to take effect)"
+	case configDiffMsg:
+		if msg.diff == "" {
+			a.currentView = viewDetail
+			a.message = fmt.Sprintf("No changes to %q", msg.iface.Name)
+			return a, clearMessages()
 		}
-		return a, clearMessages()
+		a.confirm = newDiffConfirmModel(
+			fmt.Sprintf("Save these changes to %q?", msg.iface.Name),
+			msg.diff,
+			saveAction{mgr: a.mgr, old: a.editor.profile, profile: msg.iface},
+			viewEditor,
+		)
+		a.currentView = viewConfirm
+		return a, nil
 
 	case tea.KeyMsg:
 		key := msg.String()
@@ -169,11 +251,14 @@ func (a App) updateEditor(msg tea.Msg) (App, tea.Cmd) {
 			return a.editorSave()
 		}
 
+		var cmd tea.Cmd
 		if e.editingPeer {
-			return a.editorUpdatePeerEdit(msg)
+			a, cmd = a.editorUpdatePeerEdit(msg)
+		} else {
+			a, cmd = a.editorUpdateMain(msg)
 		}
-
-		return a.editorUpdateMain(msg)
+		a.editor.validate()
+		return a, cmd
 	}
 
 	return a, nil
@@ -234,7 +319,8 @@ func (a App) editorUpdateMain(msg tea.KeyMsg) (App, tea.Cmd) {
 	case "a":
 		// Add a new peer
 		newPeer := wg.Peer{
-			AllowedIPs: "0.0.0.0/0, ::/0",
+			AllowedIPs: defaultAllowedIPs(),
+			Created:    time.Now().UTC().Truncate(time.Second),
 		}
 		e.peers = append(e.peers, newPeer)
 		e.peerIdx = len(e.peers) - 1
@@ -291,7 +377,7 @@ func (a App) editorUpdatePeerEdit(msg tea.KeyMsg) (App, tea.Cmd) {
 	case "tab", "down":
 		e.peerInputs[e.peerFocus].Blur()
 		e.peerFocus++
-		if e.peerFocus >= peerSubStepCount {
+		if e.peerFocus >= editorPeerFieldCount {
 			e.peerFocus = 0
 		}
 		e.peerInputs[e.peerFocus].Focus()
@@ -301,13 +387,13 @@ func (a App) editorUpdatePeerEdit(msg tea.KeyMsg) (App, tea.Cmd) {
 		e.peerInputs[e.peerFocus].Blur()
 		e.peerFocus--
 		if e.peerFocus < 0 {
-			e.peerFocus = peerSubStepCount - 1
+			e.peerFocus = editorPeerFieldCount - 1
 		}
 		e.peerInputs[e.peerFocus].Focus()
 		return a, nil
 
 	case "enter":
-		if e.peerFocus == peerSubStepCount-1 {
+		if e.peerFocus == editorPeerFieldCount-1 {
 			// On the last peer field: save the peer and exit peer edit mode
 			return a.editorSavePeer()
 		}
@@ -328,18 +414,17 @@ func (a App) editorUpdatePeerEdit(msg tea.KeyMsg) (App, tea.Cmd) {
 func (a App) editorSavePeer() (App, tea.Cmd) {
 	e := &a.editor
 
-	peer := buildPeerFromInputs(e.peerInputs)
-
-	// Validate public key is required
-	if peer.PublicKey == "" {
-		e.err = fmt.Errorf("public key is required")
+	updated, diags := e.build()
+	e.diags = diags
+	if wg.HasErrors(peerDiags(diags, e.peerIdx)) {
+		e.err = fmt.Errorf("peer %d has errors; fix the fields marked ✗", e.peerIdx+1)
 		return a, nil
 	}
 
 	e.err = nil
 
 	if e.peerIdx >= 0 && e.peerIdx < len(e.peers) {
-		e.peers[e.peerIdx] = peer
+		e.peers[e.peerIdx] = updated.Peers[e.peerIdx]
 	}
 
 	e.peerInputs[e.peerFocus].Blur()
@@ -349,60 +434,46 @@ func (a App) editorSavePeer() (App, tea.Cmd) {
 	return a, nil
 }
 
-// editorSave builds the updated interface, validates, and saves.
-func (a App) editorSave() (App, tea.Cmd) {
-	e := &a.editor
-
-	// Build updated interface from inputs
-	address := strings.TrimSpace(e.inputs[editorFieldAddress].Value())
-	portStr := strings.TrimSpace(e.inputs[editorFieldListenPort].Value())
-	dns := strings.TrimSpace(e.inputs[editorFieldDNS].Value())
-	mtuStr := strings.TrimSpace(e.inputs[editorFieldMTU].Value())
+// saveAction writes an edited profile after its diff has been reviewed,
+// then applies the change to its interface if that is up.
+type saveAction struct {
+	mgr     *wg.Manager
+	old     *wg.Interface // the profile as it was before editing
+	profile *wg.Interface
+}
 
-	// Validate
-	if address == "" {
-		e.err = fmt.Errorf("address is required")
-		return a, nil
+func (s saveAction) execute() tea.Msg {
+	if err := s.mgr.SaveConfig(s.profile.Dir, s.profile); err != nil {
+		return errMsg{err: err}
 	}
+	applied, up, err := applySaved(s.mgr, s.old, s.profile)
+	return editorSavedMsg{profile: s.profile, applied: applied, applyErr: err, isUp: up}
+}
 
-	port := 0
-	if portStr != "" {
-		var err error
-		port, err = strconv.Atoi(portStr)
-		if err != nil {
-			e.err = fmt.Errorf("listen port must be a number")
-			return a, nil
-		}
-	}
+// editorSaved returns to the detail view with the saved profile.
+func (a App) editorSaved(msg editorSavedMsg) (App, tea.Cmd) {
+	a.detail = newDetailModel(msg.profile, msg.isUp, a.list.health, a.list.peerHealth)
+	a.currentView = viewDetail
+	a.message = fmt.Sprintf("Saved profile %q", msg.profile.Name) + appliedNote(msg.applied)
+	a.err = applyError(msg.applyErr)
+	return a, clearMessages()
+}
 
-	mtu := 0
-	if mtuStr != "" {
-		var err error
-		mtu, err = strconv.Atoi(mtuStr)
-		if err != nil {
-			e.err = fmt.Errorf("MTU must be a number")
-			return a, nil
-		}
+// editorSave builds the updated interface, validates, and asks for
+// confirmation with a diff against the file on disk.
+func (a App) editorSave() (App, tea.Cmd) {
+	e := &a.editor
+
+	updated, diags := e.build()
+	e.diags = diags
+	if wg.HasErrors(diags) {
+		e.err = fmt.Errorf("config has errors; fix the fields marked ✗ before saving")
+		return a, nil
 	}
 
 	e.err = nil
 
-	updated := &wg.Interface{
-		Name:       e.profile.Name,
-		PrivateKey: e.profile.PrivateKey,
-		Address:    address,
-		ListenPort: port,
-		DNS:        dns,
-		MTU:        mtu,
-		Peers:      e.peers,
-	}
-
-	return a, func() tea.Msg {
-		if err := wg.SaveConfig(configDir, updated); err != nil {
-			return errMsg{err: err}
-		}
-		return editorSavedMsg{profile: updated}
-	}
+	return a, diffConfig(a.mgr, updated)
 }
 
 // view renders the editor UI.
@@ -414,12 +485,17 @@ func (e editorModel) view(width, height int) string {
 
 	// Read-only fields
 	if e.profile.PrivateKey != "" {
-		pubKey := truncateKey(e.profile.PrivateKey, 20)
-		// Try to show the public key if we can derive it; otherwise show note
-		b.WriteString("  " + labelStyle.Render("Private Key:") + descStyle.Render(pubKey+" (read-only)"))
+		// Never render the private key itself; its public key identifies it.
+		pubKey := "(invalid private key)"
+		if k, err := wg.DerivePublicKey(e.profile.PrivateKey); err == nil {
+			pubKey = truncateKey(k, 20) + " (read-only)"
+		}
+		b.WriteString("  " + labelStyle.Render("Public Key:") + descStyle.Render(pubKey))
+		b.WriteString(inlineDiags(e.diags, -1, "PrivateKey"))
 		b.WriteString("\n")
 	}
 	b.WriteString("  " + labelStyle.Render("Name:") + descStyle.Render(e.profile.Name+" (read-only)"))
+	b.WriteString(inlineDiags(e.diags, -1, "Name"))
 	b.WriteString("\n\n")
 
 	if e.editingPeer {
@@ -450,12 +526,18 @@ func (e editorModel) view(width, height int) string {
 }
 
 // editorFieldLabels maps field indices to labels.
-var editorFieldLabels = [editorFieldCount]string{
+var editorFieldLabels = append([]string{
 	"Address:",
 	"Listen Port:",
 	"DNS:",
-	"MTU:",
-}
+}, advFieldLabels[:]...)
+
+// editorFieldKeys maps field indices to the config keys they edit.
+var editorFieldKeys = append([]string{
+	"Address",
+	"ListenPort",
+	"DNS",
+}, advFieldKeys[:]...)
 
 // viewInterfaceFields renders the interface text input fields.
 func (e editorModel) viewInterfaceFields() string {
@@ -468,6 +550,7 @@ func (e editorModel) viewInterfaceFields() string {
 			cursor = "> "
 		}
 		b.WriteString(cursor + label + e.inputs[i].View())
+		b.WriteString(inlineDiags(e.diags, -1, editorFieldKeys[i]))
 		b.WriteString("\n")
 	}
 
@@ -487,8 +570,7 @@ func (e editorModel) viewPeersList() string {
 		b.WriteString("\n")
 	} else {
 		for i, p := range e.peers {
-			key := truncateKey(p.PublicKey, 12)
-			line := fmt.Sprintf("  %d. %s  %s", i+1, key, p.AllowedIPs)
+			line := fmt.Sprintf("  %d. %s  %s", i+1, peerLabel(p), wg.FormatPrefixes(p.AllowedIPs))
 			if p.Endpoint != "" {
 				line += "  " + p.Endpoint
 			}
@@ -497,8 +579,12 @@ func (e editorModel) viewPeersList() string {
 			} else {
 				line = descStyle.Render(line)
 			}
-			b.WriteString(line)
+			b.WriteString(line + expiredMark(p))
 			b.WriteString("\n")
+			for _, d := range peerDiags(e.diags, i) {
+				b.WriteString("     " + renderDiag(d, d.Field+": "+d.Message))
+				b.WriteString("\n")
+			}
 		}
 	}
 
@@ -519,13 +605,21 @@ func (e editorModel) viewPeerEdit() string {
 	b.WriteString("  " + descStyle.Render(fmt.Sprintf("Editing Peer %d", peerNum)))
 	b.WriteString("\n\n")
 
-	for i := 0; i < peerSubStepCount; i++ {
-		label := labelStyle.Render(peerStepLabels[i] + ":")
+	for i := 0; i < editorPeerFieldCount; i++ {
+		if i == peerMetaName {
+			b.WriteString("\n")
+		}
+		label := labelStyle.Render(editorPeerLabel(i) + ":")
 		cursor := "  "
 		if i == e.peerFocus {
 			cursor = "> "
 		}
 		b.WriteString(cursor + label + e.peerInputs[i].View())
+		b.WriteString(inlineDiags(e.diags, e.peerIdx, editorPeerKey(i)))
+		b.WriteString("\n")
+	}
+	if created := e.peers[e.peerIdx].Created; !created.IsZero() {
+		b.WriteString("  " + labelStyle.Render("Created:") + descStyle.Render(formatMetaDate(created)+" (read-only)"))
 		b.WriteString("\n")
 	}
 
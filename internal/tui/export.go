@@ -20,8 +20,20 @@ type exportModel struct {
 	saving    bool
 	err       error
 	message   string
+
+	// Client bundle export: bundleInputs are the destination and the
+	// server endpoint for clients stored without one.
+	bundling     bool
+	bundleInputs []textinput.Model
+	bundleFocus  int
 }
 
+// Bundle inputs of the export view.
+const (
+	bundlePath = iota
+	bundleEndpoint
+)
+
 func newExportModel(profile *wg.Interface) exportModel {
 	confText := wg.MarshalConfig(profile)
 
@@ -36,19 +48,58 @@ func newExportModel(profile *wg.Interface) exportModel {
 	ti.CharLimit = 256
 	ti.SetValue(fmt.Sprintf("%s.conf", profile.Name))
 
+	bundlePathInput := textinput.New()
+	bundlePathInput.Placeholder = "a .zip file or a directory"
+	bundlePathInput.CharLimit = 256
+	bundlePathInput.SetValue(fmt.Sprintf("%s-clients.zip", profile.Name))
+
+	endpointInput := textinput.New()
+	port := profile.ListenPort
+	if port == 0 {
+		port = 51820
+	}
+	endpointInput.Placeholder = fmt.Sprintf("vpn.example.com:%d (if a client has none)", port)
+	endpointInput.CharLimit = 200
+
 	return exportModel{
-		profile:   profile,
-		showQR:    false,
-		qrString:  qrString,
-		confText:  confText,
-		pathInput: ti,
-		err:       err, // capture QR generation error if any
+		profile:      profile,
+		showQR:       false,
+		qrString:     qrString,
+		confText:     confText,
+		pathInput:    ti,
+		err:          err, // capture QR generation error if any
+		bundleInputs: []textinput.Model{bundlePathInput, endpointInput},
 	}
 }
 
 // exportSavedMsg is sent after an export file has been saved.
 type exportSavedMsg struct{ path string }
 
+// bundleSavedMsg is sent after a client bundle has been written.
+type bundleSavedMsg struct {
+	path    string
+	clients int
+}
+
+// writeBundle exports the stored client configs of profile to path.
+func writeBundle(mgr *wg.Manager, profile *wg.Interface, path, endpoint string) tea.Cmd {
+	return func() tea.Msg {
+		clients, err := mgr.Clients(profile)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		files, err := wg.ClientBundle(profile, clients, endpoint)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if err := wg.WriteBundle(path, files); err != nil {
+			return errMsg{err: err}
+		}
+		// Each client is a .conf and a .png; the last file is the manifest.
+		return bundleSavedMsg{path: path, clients: (len(files) - 1) / 2}
+	}
+}
+
 func (a App) updateExport(msg tea.Msg) (App, tea.Cmd) {
 	ex := &a.exportView
 
@@ -60,9 +111,20 @@ func (a App) updateExport(msg tea.Msg) (App, tea.Cmd) {
 		ex.pathInput.Blur()
 		return a, nil
 
+	case bundleSavedMsg:
+		ex.bundling = false
+		ex.message = fmt.Sprintf("Saved %d client configs to %s", msg.clients, msg.path)
+		ex.err = nil
+		ex.bundleInputs[ex.bundleFocus].Blur()
+		return a, nil
+
 	case tea.KeyMsg:
 		key := msg.String()
 
+		if ex.bundling {
+			return a.exportHandleBundle(msg)
+		}
+
 		if ex.saving {
 			switch key {
 			case "enter":
@@ -114,6 +176,18 @@ func (a App) updateExport(msg tea.Msg) (App, tea.Cmd) {
 			ex.pathInput.Focus()
 			return a, nil
 
+		case "b":
+			// Enter client bundle mode
+			if len(ex.profile.Peers) == 0 {
+				return a, nil
+			}
+			ex.bundling = true
+			ex.message = ""
+			ex.err = nil
+			ex.bundleFocus = bundlePath
+			ex.bundleInputs[bundlePath].Focus()
+			return a, nil
+
 		case "esc":
 			// Go back to detail view
 			a.currentView = viewDetail
@@ -124,9 +198,65 @@ func (a App) updateExport(msg tea.Msg) (App, tea.Cmd) {
 	return a, nil
 }
 
+// exportHandleBundle handles keys while asking where to write a client
+// bundle.
+func (a App) exportHandleBundle(msg tea.KeyMsg) (App, tea.Cmd) {
+	ex := &a.exportView
+
+	switch msg.String() {
+	case "tab", "shift+tab", "up", "down":
+		ex.bundleInputs[ex.bundleFocus].Blur()
+		ex.bundleFocus = 1 - ex.bundleFocus
+		ex.bundleInputs[ex.bundleFocus].Focus()
+		return a, nil
+
+	case "enter":
+		path := strings.TrimSpace(ex.bundleInputs[bundlePath].Value())
+		if path == "" {
+			ex.err = fmt.Errorf("file or directory path is required")
+			return a, nil
+		}
+		ex.err = nil
+		endpoint := strings.TrimSpace(ex.bundleInputs[bundleEndpoint].Value())
+		return a, writeBundle(a.mgr, ex.profile, path, endpoint)
+
+	case "esc":
+		ex.bundling = false
+		ex.err = nil
+		ex.bundleInputs[ex.bundleFocus].Blur()
+		return a, nil
+	}
+
+	var cmd tea.Cmd
+	ex.bundleInputs[ex.bundleFocus], cmd = ex.bundleInputs[ex.bundleFocus].Update(msg)
+	return a, cmd
+}
+
 func (e exportModel) view(width, height int) string {
 	var b strings.Builder
 
+	if e.bundling {
+		b.WriteString(titleStyle.Render("Export Clients: " + e.profile.Name))
+		b.WriteString("\n\n")
+
+		b.WriteString("  " + descStyle.Render("A .conf and QR code PNG for every client whose keys were generated here, and a manifest of all peers."))
+		b.WriteString("\n\n")
+		b.WriteString("  " + labelStyle.Render("Save to:") + e.bundleInputs[bundlePath].View())
+		b.WriteString("\n")
+		b.WriteString("  " + labelStyle.Render("Endpoint:") + e.bundleInputs[bundleEndpoint].View())
+		b.WriteString("\n\n")
+
+		if e.err != nil {
+			b.WriteString("  " + wrapError(e.err, width))
+			b.WriteString("\n\n")
+		}
+
+		help := helpKey("tab", "next field") + "  " + helpKey("enter", "export") + "  " + helpKey("esc", "cancel")
+		b.WriteString(help)
+
+		return b.String()
+	}
+
 	if e.saving {
 		// Save mode
 		b.WriteString(titleStyle.Render("Export: " + e.profile.Name))
@@ -177,7 +307,11 @@ func (e exportModel) view(width, height int) string {
 			b.WriteString("\n\n")
 		}
 
-		help := helpKey("q", "show QR") + "  " + helpKey("s", "save to file") + "  " + helpKey("esc", "back")
+		help := helpKey("q", "show QR") + "  " + helpKey("s", "save to file") + "  "
+		if len(e.profile.Peers) > 0 {
+			help += helpKey("b", "client bundle") + "  "
+		}
+		help += helpKey("esc", "back")
 		b.WriteString(help)
 	}
 
@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/mlu/wireguard-tui/internal/notify"
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// healthInterval is how often the App checks the health of active
+// tunnels in the background, whatever view is open.
+const healthInterval = 15 * time.Second
+
+type healthTickMsg struct{}
+
+// healthMsg carries the health of every active interface and of its
+// peers, by public key.
+type healthMsg struct {
+	health map[string]wg.Health
+	peers  map[string]map[string]wg.Health
+	err    error
+}
+
+// checkHealth reads the status of all active interfaces and classifies
+// them.
+func checkHealth(mgr *wg.Manager) tea.Cmd {
+	return func() tea.Msg {
+		all, err := mgr.GetAllStatus()
+		if err != nil {
+			return healthMsg{err: err}
+		}
+		now := time.Now()
+		health := make(map[string]wg.Health, len(all))
+		peers := make(map[string]map[string]wg.Health, len(all))
+		for _, s := range all {
+			health[s.Name] = wg.InterfaceHealth(s, now)
+			peers[s.Name] = make(map[string]wg.Health, len(s.Peers))
+			for _, p := range s.Peers {
+				peers[s.Name][p.PublicKey] = wg.PeerHealth(p, now)
+			}
+		}
+		return healthMsg{health: health, peers: peers}
+	}
+}
+
+func healthTick() tea.Cmd {
+	return tea.Tick(healthInterval, func(time.Time) tea.Msg {
+		return healthTickMsg{}
+	})
+}
+
+// updateHealth handles the background health check. It runs before view
+// delegation, so the loop survives navigation. Failed checks (e.g. before
+// the password is entered) keep the previous result. Each result also
+// drives desktop notifications.
+func (a App) updateHealth(msg tea.Msg) (App, tea.Cmd) {
+	switch msg := msg.(type) {
+	case healthTickMsg:
+		return a, checkHealth(a.mgr)
+
+	case healthMsg:
+		if msg.err == nil {
+			a.list.health = msg.health
+			a.detail.health = msg.health
+			a.list.peerHealth = msg.peers
+			a.detail.peerHealth = msg.peers
+			a.alert = healthAlert(msg.health)
+			if a.watcher != nil {
+				if ns := a.watcher.Update(msg.health); len(ns) > 0 {
+					return a, tea.Batch(healthTick(), sendNotifications(a.notifier, ns))
+				}
+			}
+		}
+		return a, healthTick()
+	}
+	return a, nil
+}
+
+// sendNotifications shows ns in the background. Failures are dropped: a
+// missing notification daemon must not interrupt the UI.
+func sendNotifications(n notify.Notifier, ns []notify.Notification) tea.Cmd {
+	return func() tea.Msg {
+		_ = notify.SendAll(n, ns)
+		return nil
+	}
+}
+
+// expectDown tells the notification watcher that name was brought down
+// from the UI, so its disappearance is not reported.
+func (a App) expectDown(name string, nowUp bool) {
+	if a.watcher != nil && !nowUp {
+		a.watcher.Expect(name)
+	}
+}
+
+// healthAlert is the status-line warning about stale or dead tunnels, or
+// "" when all are fine.
+func healthAlert(health map[string]wg.Health) string {
+	var bad []string
+	for name, h := range health {
+		if h >= wg.HealthStale {
+			bad = append(bad, fmt.Sprintf("%s is %s", name, h))
+		}
+	}
+	if len(bad) == 0 {
+		return ""
+	}
+	sort.Strings(bad)
+	return "⚠ " + strings.Join(bad, ", ") + " (no recent handshake)"
+}
+
+var healthColors = map[wg.Health]lipgloss.Color{
+	wg.HealthHealthy: colorGreen,
+	wg.HealthNever:   colorYellow,
+	wg.HealthStale:   colorYellow,
+	wg.HealthDead:    colorRed,
+}
+
+// healthLabel renders h as a colored dot and name.
+func healthLabel(h wg.Health) string {
+	return lipgloss.NewStyle().Foreground(healthColors[h]).Render("● " + h.String())
+}
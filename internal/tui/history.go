@@ -0,0 +1,255 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// historyModel lists the backups of a profile and shows what restoring one
+// would change.
+type historyModel struct {
+	profile *wg.Interface
+	backups []wg.Backup
+	cursor  int
+	loading bool
+
+	// diff is the rendered diff from the current config to the selected
+	// backup; showDiff switches the view from the list to it.
+	diff     string
+	showDiff bool
+	offset   int // first diff line shown
+
+	err error
+}
+
+type historyLoadedMsg struct {
+	backups []wg.Backup
+	err     error
+}
+
+type historyDiffMsg struct {
+	diff string
+	err  error
+}
+
+func newHistoryModel(profile *wg.Interface) historyModel {
+	return historyModel{profile: profile, loading: true}
+}
+
+func loadHistory(mgr *wg.Manager, profile *wg.Interface) tea.Cmd {
+	return func() tea.Msg {
+		backups, err := mgr.History(profile)
+		return historyLoadedMsg{backups: backups, err: err}
+	}
+}
+
+// diffBackup diffs the profile's current config against backup b, with
+// keys redacted.
+func diffBackup(mgr *wg.Manager, profile *wg.Interface, b wg.Backup) tea.Cmd {
+	return func() tea.Msg {
+		text, err := mgr.ReadBackup(b)
+		if err != nil {
+			return historyDiffMsg{err: err}
+		}
+		diff := wg.UnifiedDiff("current", formatBackupTime(b.Time),
+			wg.RedactKeys(wg.MarshalConfig(profile)), wg.RedactKeys(text))
+		return historyDiffMsg{diff: diff}
+	}
+}
+
+// restoreAction restores a profile from a backup after confirmation.
+type restoreAction struct {
+	mgr     *wg.Manager
+	profile *wg.Interface
+	backup  wg.Backup
+}
+
+func (r restoreAction) execute() tea.Msg {
+	restored, err := r.mgr.Restore(r.profile, r.backup)
+	if err != nil {
+		return errMsg{err}
+	}
+	applied, err := r.mgr.Apply(r.profile, restored)
+	return restoredMsg{profile: restored, when: r.backup.Time, applied: applied, applyErr: err}
+}
+
+type restoredMsg struct {
+	profile  *wg.Interface
+	when     time.Time
+	applied  wg.ApplyReport
+	applyErr error
+}
+
+func formatBackupTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func (a App) updateHistory(msg tea.Msg) (App, tea.Cmd) {
+	h := &a.history
+
+	switch msg := msg.(type) {
+	case historyLoadedMsg:
+		h.loading = false
+		h.backups = msg.backups
+		h.err = msg.err
+		return a, nil
+
+	case historyDiffMsg:
+		h.diff = msg.diff
+		h.err = msg.err
+		h.showDiff = msg.err == nil
+		h.offset = 0
+		return a, nil
+
+	case tea.KeyMsg:
+		if h.showDiff {
+			switch msg.String() {
+			case "up", "k":
+				if h.offset > 0 {
+					h.offset--
+				}
+			case "down", "j":
+				h.offset++
+			case "r":
+				return a.confirmRestore()
+			case "esc":
+				h.showDiff = false
+			}
+			return a, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if h.cursor > 0 {
+				h.cursor--
+			}
+		case "down", "j":
+			if h.cursor < len(h.backups)-1 {
+				h.cursor++
+			}
+		case "enter":
+			if len(h.backups) > 0 {
+				return a, diffBackup(a.mgr, h.profile, h.backups[h.cursor])
+			}
+		case "r":
+			if len(h.backups) > 0 {
+				return a.confirmRestore()
+			}
+		case "esc":
+			a.currentView = viewDetail
+		}
+	}
+
+	return a, nil
+}
+
+// confirmRestore asks before restoring the selected backup.
+func (a App) confirmRestore() (App, tea.Cmd) {
+	h := a.history
+	b := h.backups[h.cursor]
+	a.confirm = newConfirmModel(
+		fmt.Sprintf("Restore %q to the version from %s?", h.profile.Name, formatBackupTime(b.Time)),
+		restoreAction{mgr: a.mgr, profile: h.profile, backup: b},
+		viewHistory,
+	)
+	a.currentView = viewConfirm
+	return a, nil
+}
+
+// renderDiff colors a unified diff: additions green, removals red, hunk
+// headers in the accent color.
+func renderDiff(diff string) string {
+	addStyle := lipgloss.NewStyle().Foreground(colorGreen)
+	delStyle := lipgloss.NewStyle().Foreground(colorRed)
+	hunkStyle := lipgloss.NewStyle().Foreground(colorAccent)
+
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = descStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = hunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = addStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = delStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (h historyModel) view(width, height int) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("History: " + h.profile.Name))
+	b.WriteString("\n\n")
+
+	switch {
+	case h.loading:
+		b.WriteString("  " + descStyle.Render("Loading..."))
+		b.WriteString("\n\n")
+
+	case h.showDiff:
+		b.WriteString("  " + descStyle.Render("Restoring "+formatBackupTime(h.backups[h.cursor].Time)+" would change:"))
+		b.WriteString("\n\n")
+		if h.diff == "" {
+			b.WriteString("  " + descStyle.Render("Identical to the current config."))
+		} else {
+			lines := strings.Split(renderDiff(h.diff), "\n")
+			// Leave room for the title, caption and help.
+			visible := max(height-9, 5)
+			offset := min(h.offset, max(len(lines)-visible, 0))
+			b.WriteString(strings.Join(lines[offset:min(offset+visible, len(lines))], "\n"))
+		}
+		b.WriteString("\n\n")
+		help := helpKey("j/k", "scroll") + "  " + helpKey("r", "restore") + "  " + helpKey("esc", "back")
+		b.WriteString(help)
+		return b.String()
+
+	case len(h.backups) == 0:
+		b.WriteString("  " + descStyle.Render("No earlier versions. A backup is kept each time the profile is saved."))
+		b.WriteString("\n\n")
+
+	default:
+		for i, bk := range h.backups {
+			cursor := "  "
+			if i == h.cursor {
+				cursor = "> "
+			}
+			b.WriteString(cursor + valueStyle.Render(formatBackupTime(bk.Time)) + "  " + descStyle.Render(formatAge(bk.Time)))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if h.err != nil {
+		b.WriteString("  " + wrapError(h.err, width))
+		b.WriteString("\n\n")
+	}
+
+	help := helpKey("enter", "diff") + "  " + helpKey("r", "restore") + "  " + helpKey("esc", "back")
+	b.WriteString(help)
+
+	return b.String()
+}
+
+// formatAge renders how long ago t was, e.g. "3h ago".
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
@@ -16,6 +16,7 @@ type importModel struct {
 	pathInput textinput.Model
 	preview   string
 	parsed    *wg.Interface
+	diags     []wg.Diagnostic
 	err       error
 }
 
@@ -33,14 +34,46 @@ func newImportModel() importModel {
 	}
 }
 
+// importAction saves an imported profile.
+type importAction struct {
+	mgr     *wg.Manager
+	profile *wg.Interface
+}
+
+func (i importAction) execute() tea.Msg {
+	if err := i.mgr.SaveConfig(i.profile.Dir, i.profile); err != nil {
+		return errMsg{err: err}
+	}
+	return importDoneMsg{name: i.profile.Name}
+}
+
+// importDone returns to the list after a profile was imported.
+func (a App) importDone(name string) (App, tea.Cmd) {
+	a.message = fmt.Sprintf("Imported profile %q", name)
+	a.currentView = viewList
+	return a, tea.Batch(loadProfiles(a.mgr), clearMessages())
+}
+
 func (a App) updateImport(msg tea.Msg) (App, tea.Cmd) {
 	im := &a.importView
 
 	switch msg := msg.(type) {
 	case importDoneMsg:
-		a.message = fmt.Sprintf("Imported profile %q", msg.name)
-		a.currentView = viewList
-		return a, tea.Batch(loadProfiles(), clearMessages())
+		return a.importDone(msg.name)
+
+	case configDiffMsg:
+		action := importAction{mgr: a.mgr, profile: msg.iface}
+		if !msg.exists || msg.diff == "" {
+			return a, action.execute
+		}
+		a.confirm = newDiffConfirmModel(
+			fmt.Sprintf("Profile %q already exists. Replace it?", msg.iface.Name),
+			msg.diff,
+			action,
+			viewImport,
+		)
+		a.currentView = viewConfirm
+		return a, nil
 
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -71,26 +104,28 @@ func (a App) updateImport(msg tea.Msg) (App, tea.Cmd) {
 				iface.Name = name
 
 				im.parsed = iface
-				im.preview = wg.MarshalConfig(iface)
+				im.diags = wg.Validate(iface)
+				im.preview = annotateConfig(wg.MarshalConfig(iface), im.diags)
 				im.err = nil
 				im.pathInput.Blur()
 				return a, nil
 			}
 
-			// Second enter: confirm import — save to /etc/wireguard/
-			iface := im.parsed
-			name := iface.Name
-			return a, func() tea.Msg {
-				if err := wg.SaveConfig(configDir, iface); err != nil {
-					return errMsg{err: err}
-				}
-				return importDoneMsg{name: name}
+			// Second enter: confirm import — save to the first profile
+			// root, showing a diff first if that replaces a profile.
+			if wg.HasErrors(im.diags) {
+				im.err = fmt.Errorf("config has errors; fix the source file and load it again")
+				return a, nil
 			}
+			iface := im.parsed
+			iface.Dir = a.mgr.ConfigDirs()[0]
+			return a, diffConfig(a.mgr, iface)
 
 		case "esc":
 			if im.parsed != nil {
 				// Clear preview, go back to path input
 				im.parsed = nil
+				im.diags = nil
 				im.preview = ""
 				im.err = nil
 				im.pathInput.Focus()
@@ -132,6 +167,11 @@ func (i importModel) view(width, height int) string {
 		b.WriteString("  " + labelStyle.Render("Import as:") + valueStyle.Render(name))
 		b.WriteString("\n\n")
 
+		if i.err != nil {
+			b.WriteString("  " + wrapError(i.err, width))
+			b.WriteString("\n\n")
+		}
+
 		help := helpKey("enter", "confirm import") + "  " + helpKey("esc", "back")
 		b.WriteString(help)
 	} else {
@@ -2,6 +2,8 @@ package tui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,7 +16,13 @@ import (
 type listModel struct {
 	profiles []*wg.Interface
 	active   map[string]bool
+	health   map[string]wg.Health // of active interfaces, from the health check
+	dirs     []string             // profile roots, shown when there is more than one
 	cursor   int
+
+	// peerHealth is the health of the peers of each active interface, by
+	// public key, from the same check; the detail view shows it.
+	peerHealth map[string]map[string]wg.Health
 }
 
 func newListModel() listModel {
@@ -26,16 +34,18 @@ func newListModel() listModel {
 type profilesLoadedMsg struct {
 	profiles []*wg.Interface
 	active   map[string]bool
+	dirs     []string
+	err      error // a root that failed to load while others succeeded
 }
 
-func loadProfiles() tea.Cmd {
+func loadProfiles(mgr *wg.Manager) tea.Cmd {
 	return func() tea.Msg {
-		profiles, err := wg.LoadConfigsFromDir(configDir)
-		if err != nil {
-			return errMsg{err: err}
+		profiles, loadErr := mgr.LoadProfiles()
+		if loadErr != nil && len(profiles) == 0 {
+			return errMsg{err: loadErr}
 		}
 
-		activeList, err := wg.ListInterfaces()
+		activeList, err := mgr.ListInterfaces()
 		if err != nil {
 			return errMsg{err: err}
 		}
@@ -48,6 +58,8 @@ func loadProfiles() tea.Cmd {
 		return profilesLoadedMsg{
 			profiles: profiles,
 			active:   active,
+			dirs:     mgr.ConfigDirs(),
+			err:      loadErr,
 		}
 	}
 }
@@ -57,9 +69,14 @@ func (a App) updateList(msg tea.Msg) (App, tea.Cmd) {
 	case profilesLoadedMsg:
 		a.list.profiles = msg.profiles
 		a.list.active = msg.active
+		a.list.dirs = msg.dirs
 		if a.list.cursor >= len(a.list.profiles) && len(a.list.profiles) > 0 {
 			a.list.cursor = len(a.list.profiles) - 1
 		}
+		if msg.err != nil {
+			a.err = msg.err
+			return a, clearMessages()
+		}
 		return a, nil
 
 	case toggledMsg:
@@ -87,12 +104,18 @@ func (a App) updateList(msg tea.Msg) (App, tea.Cmd) {
 			if len(a.list.profiles) > 0 {
 				p := a.list.profiles[a.list.cursor]
 				isUp := a.list.active[p.Name]
-				a.detail = newDetailModel(p, isUp)
+				a.detail = newDetailModel(p, isUp, a.list.health, a.list.peerHealth)
 				a.currentView = viewDetail
 			}
 		case "n":
-			a.wizard = newWizardModel()
+			a.wizard = newWizardModel(a.mgr.ConfigDirs()[0], a.list.profiles)
 			a.currentView = viewWizard
+		case "s":
+			seq := a.dashboard.seq
+			a.dashboard = newDashboardModel()
+			a.dashboard.seq = seq
+			a.currentView = viewDashboard
+			return a, a.dashboard.resume(a.mgr)
 		case "i":
 			a.importView = newImportModel()
 			a.currentView = viewImport
@@ -105,14 +128,15 @@ func (a App) updateList(msg tea.Msg) (App, tea.Cmd) {
 				return a, nil
 			}
 			if len(a.list.profiles) > 0 {
-				name := a.list.profiles[a.list.cursor].Name
+				profile := a.list.profiles[a.list.cursor]
+				name := profile.Name
 				if teleport.HasToken(teleport.CredentialDir, name) {
 					a.toggling = true
 					a.message = "Regenerating Teleport config..."
-					return a, teleportToggleCmd(name)
+					return a, teleportToggleCmd(a.mgr, profile)
 				}
 				return a, func() tea.Msg {
-					nowUp, err := wg.Toggle(name)
+					nowUp, err := a.mgr.Toggle(profile.Target())
 					if err != nil {
 						return errMsg{err}
 					}
@@ -122,7 +146,7 @@ func (a App) updateList(msg tea.Msg) (App, tea.Cmd) {
 		}
 
 	case refreshMsg:
-		return a, loadProfiles()
+		return a, loadProfiles(a.mgr)
 	}
 
 	return a, nil
@@ -136,7 +160,7 @@ func (l listModel) view(width, height int) string {
 
 	if len(l.profiles) == 0 {
 		b.WriteString("\n")
-		b.WriteString(descStyle.Render("No profiles found in " + configDir))
+		b.WriteString(descStyle.Render("No profiles found in " + strings.Join(displayDirs(l.dirs), ", ")))
 		b.WriteString("\n")
 		b.WriteString(descStyle.Render("Press [n] to create a new profile or [i] to import one."))
 		b.WriteString("\n")
@@ -153,6 +177,9 @@ func (l listModel) view(width, height int) string {
 			status := statusDown
 			if l.active[p.Name] {
 				status = statusUp
+				if h, ok := l.health[p.Name]; ok && h != wg.HealthHealthy {
+					status += " " + healthLabel(h)
+				}
 			}
 
 			peerCount := fmt.Sprintf("%d peer", len(p.Peers))
@@ -162,9 +189,12 @@ func (l listModel) view(width, height int) string {
 
 			line := cursor +
 				nameStyle.Render(p.Name) + " " +
-				addrStyle.Render(p.Address) + " " +
+				addrStyle.Render(wg.FormatPrefixes(p.Address)) + " " +
 				status + "  " +
 				descStyle.Render(peerCount)
+			if len(l.dirs) > 1 {
+				line += "  " + descStyle.Render(displayDir(p.Dir))
+			}
 
 			b.WriteString(line)
 			b.WriteString("\n")
@@ -175,9 +205,35 @@ func (l listModel) view(width, height int) string {
 	help := helpKey("n", "new") + "  " +
 		helpKey("a", "amplifi") + "  " +
 		helpKey("t", "toggle") + "  " +
+		helpKey("s", "dashboard") + "  " +
 		helpKey("i", "import") + "  " +
 		helpKey("q", "quit")
 	b.WriteString(help)
 
 	return b.String()
 }
+
+// displayDir shortens a profile root for display, writing the home
+// directory as ~.
+func displayDir(dir string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(dir, home+string(filepath.Separator)); ok {
+		return filepath.Join("~", rest)
+	}
+	return dir
+}
+
+// displayDirs applies displayDir to each root.
+func displayDirs(dirs []string) []string {
+	out := make([]string, len(dirs))
+	for i, d := range dirs {
+		out[i] = displayDir(d)
+	}
+	return out
+}
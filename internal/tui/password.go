@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// passwordModel asks for the sudo password. Privileged commands never read
+// the terminal themselves, so this is the only place a password is typed.
+type passwordModel struct {
+	input textinput.Model
+	prev  viewType // view to return to
+	retry tea.Cmd  // run after authenticating, e.g. the load that failed
+	busy  bool
+	err   error
+}
+
+// passwordNeededMsg opens the password view.
+type passwordNeededMsg struct{ retry tea.Cmd }
+
+// authDoneMsg reports the result of wg.Authenticate.
+type authDoneMsg struct{ err error }
+
+func newPasswordModel(prev viewType, retry tea.Cmd) passwordModel {
+	ti := textinput.New()
+	ti.Placeholder = "password"
+	ti.EchoMode = textinput.EchoPassword
+	ti.EchoCharacter = '•'
+	ti.CharLimit = 256
+	ti.Focus()
+
+	return passwordModel{input: ti, prev: prev, retry: retry}
+}
+
+// checkPassword asks for the password up front when the privilege method
+// needs one, and otherwise loads profiles.
+func checkPassword(mgr *wg.Manager) tea.Cmd {
+	return func() tea.Msg {
+		needed, err := mgr.NeedsPassword()
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if needed {
+			return passwordNeededMsg{retry: loadProfiles(mgr)}
+		}
+		return loadProfiles(mgr)()
+	}
+}
+
+func (a App) updatePassword(msg tea.Msg) (App, tea.Cmd) {
+	pm := &a.password
+
+	switch msg := msg.(type) {
+	case authDoneMsg:
+		pm.busy = false
+		if msg.err != nil {
+			pm.err = msg.err
+			pm.input.SetValue("")
+			return a, nil
+		}
+		a.currentView = pm.prev
+		if pm.retry != nil {
+			return a, pm.retry
+		}
+		a.message = "Authenticated; try again"
+		return a, tea.Batch(loadProfiles(a.mgr), clearMessages())
+
+	case tea.KeyMsg:
+		if pm.busy {
+			return a, nil
+		}
+		switch msg.String() {
+		case "enter":
+			password := pm.input.Value()
+			if password == "" {
+				pm.err = fmt.Errorf("password is required")
+				return a, nil
+			}
+			pm.busy = true
+			pm.err = nil
+			mgr := a.mgr
+			return a, func() tea.Msg {
+				return authDoneMsg{err: mgr.Authenticate(password)}
+			}
+		case "esc":
+			pm.input.SetValue("")
+			a.currentView = pm.prev
+			return a, nil
+		}
+
+		var cmd tea.Cmd
+		pm.input, cmd = pm.input.Update(msg)
+		return a, cmd
+	}
+
+	return a, nil
+}
+
+func (p passwordModel) view(width, height int) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Authentication Required"))
+	b.WriteString("\n\n")
+
+	b.WriteString("  " + descStyle.Render("Managing WireGuard needs administrator rights."))
+	b.WriteString("\n\n")
+
+	b.WriteString("  " + labelStyle.Render("sudo password:") + p.input.View())
+	b.WriteString("\n\n")
+
+	if p.busy {
+		b.WriteString("  " + descStyle.Render("Checking..."))
+		b.WriteString("\n\n")
+	}
+	if p.err != nil {
+		b.WriteString("  " + wrapError(p.err, width))
+		b.WriteString("\n\n")
+	}
+
+	help := helpKey("enter", "submit") + "  " + helpKey("esc", "cancel")
+	b.WriteString(help)
+
+	return b.String()
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+
+	wg "github.com/mlu/wireguard-tui/internal/wg"
+)
+
+// Peer metadata fields of the editor's peer form. They follow the config
+// fields (peerStepPubKey through peerStepKeepalive) in the same input
+// slice.
+const (
+	peerMetaName = peerSubStepCount + iota
+	peerMetaOwner
+	peerMetaNotes
+	peerMetaExpires
+	editorPeerFieldCount
+)
+
+// peerMetaLabels and peerMetaKeys describe the metadata fields, indexed
+// from peerMetaName.
+var (
+	peerMetaLabels = [...]string{"Name", "Owner", "Notes", "Expires"}
+	peerMetaKeys   = [...]string{"Name", "Owner", "Notes", "Expires"}
+)
+
+// makePeerMetaInputs creates the metadata inputs of the editor's peer form.
+func makePeerMetaInputs() []textinput.Model {
+	inputs := make([]textinput.Model, editorPeerFieldCount-peerSubStepCount)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].CharLimit = 100
+	}
+	inputs[peerMetaName-peerSubStepCount].Placeholder = "laptop (optional)"
+	inputs[peerMetaOwner-peerSubStepCount].Placeholder = "alice@example.com (optional)"
+	inputs[peerMetaNotes-peerSubStepCount].Placeholder = "optional"
+	inputs[peerMetaNotes-peerSubStepCount].CharLimit = 200
+	inputs[peerMetaExpires-peerSubStepCount].Placeholder = "YYYY-MM-DD (optional)"
+	return inputs
+}
+
+// populatePeerMetaInputs fills the metadata inputs from a peer.
+func populatePeerMetaInputs(inputs []textinput.Model, peer wg.Peer) {
+	inputs[peerMetaName].SetValue(peer.Name)
+	inputs[peerMetaOwner].SetValue(peer.Owner)
+	inputs[peerMetaNotes].SetValue(peer.Notes)
+	inputs[peerMetaExpires].SetValue(wg.FormatMetaTime(peer.Expires))
+}
+
+// parsePeerMetaInputs applies the metadata inputs to base, the peer at
+// index idx. Created is not editable and is kept.
+func parsePeerMetaInputs(inputs []textinput.Model, base wg.Peer, idx int) (wg.Peer, []wg.Diagnostic) {
+	var diags []wg.Diagnostic
+	base.Name = strings.TrimSpace(inputs[peerMetaName].Value())
+	base.Owner = strings.TrimSpace(inputs[peerMetaOwner].Value())
+	base.Notes = strings.TrimSpace(inputs[peerMetaNotes].Value())
+	expires, err := wg.ParseMetaTime(inputs[peerMetaExpires].Value())
+	if err != nil {
+		diags = append(diags, inputDiag(idx, "Expires", err))
+	}
+	base.Expires = expires
+	return base, diags
+}
+
+// editorPeerLabel and editorPeerKey return the label and config key of
+// field i of the editor's peer form.
+func editorPeerLabel(i int) string {
+	if i >= peerSubStepCount {
+		return peerMetaLabels[i-peerSubStepCount]
+	}
+	return peerStepLabels[i]
+}
+
+func editorPeerKey(i int) string {
+	if i >= peerSubStepCount {
+		return peerMetaKeys[i-peerSubStepCount]
+	}
+	return peerStepKeys[i]
+}
+
+// peerLabel names a peer in lists: its Name metadata, or its shortened
+// public key.
+func peerLabel(p wg.Peer) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return truncateKey(p.PublicKey, 12)
+}
+
+// peersByKey indexes the configured peers of the profile called name, so
+// live status can show their metadata. It returns nil if there is no such
+// profile.
+func peersByKey(profiles []*wg.Interface, name string) map[string]wg.Peer {
+	for _, p := range profiles {
+		if p.Name != name {
+			continue
+		}
+		peers := make(map[string]wg.Peer, len(p.Peers))
+		for _, peer := range p.Peers {
+			peers[peer.PublicKey] = peer
+		}
+		return peers
+	}
+	return nil
+}
+
+// peerMetaLines renders a peer's metadata below its name, one labelled
+// line per field set, each prefixed with indent.
+func peerMetaLines(p wg.Peer, indent string) string {
+	var b strings.Builder
+	if p.Owner != "" {
+		b.WriteString(indent + labelStyle.Render("Owner:") + valueStyle.Render(p.Owner) + "\n")
+	}
+	if p.Notes != "" {
+		b.WriteString(indent + labelStyle.Render("Notes:") + valueStyle.Render(p.Notes) + "\n")
+	}
+	if !p.Created.IsZero() {
+		b.WriteString(indent + labelStyle.Render("Created:") + valueStyle.Render(formatMetaDate(p.Created)) + "\n")
+	}
+	if !p.Expires.IsZero() {
+		b.WriteString(indent + labelStyle.Render("Expires:") + valueStyle.Render(formatMetaDate(p.Expires)) + expiredMark(p) + "\n")
+	}
+	return b.String()
+}
+
+// expiredMark flags a peer past its expiry date, which prune-expired
+// would remove. It is empty for other peers.
+func expiredMark(p wg.Peer) string {
+	if !p.Expired(time.Now()) {
+		return ""
+	}
+	return " " + errorStyle.Render("expired")
+}
+
+// formatMetaDate shows a metadata time as written for a plain date, and
+// as local date and time otherwise.
+func formatMetaDate(t time.Time) string {
+	s := wg.FormatMetaTime(t)
+	if len(s) == len("2006-01-02") {
+		return s
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// filterPeers returns the indexes of the peers of p matching query.
+func filterPeers(p *wg.Interface, query string) []int {
+	var idx []int
+	for i, peer := range p.Peers {
+		if peer.Matches(query) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// peerCount describes how many of total peers a search matched.
+func peerCount(matched, total int, query string) string {
+	if query == "" {
+		return fmt.Sprintf("%d", total)
+	}
+	return fmt.Sprintf("%d of %d matching %q", matched, total, query)
+}
+
+// expiredCount notes how many peers of p have expired, or is empty.
+func expiredCount(p *wg.Interface) string {
+	n := len(wg.ExpiredPeers(p, time.Now()))
+	if n == 0 {
+		return ""
+	}
+	return " " + errorStyle.Render(fmt.Sprintf("(%d expired: prune-expired removes them)", n))
+}
@@ -11,56 +11,104 @@ import (
 	wg "github.com/mlu/wireguard-tui/internal/wg"
 )
 
-type statusTickMsg struct{}
+type statusTickMsg struct{ seq int }
 
 type statusDataMsg struct {
+	seq    int
 	status *wg.InterfaceStatus
 	err    error
 }
 
+// statusInterval is how often the live status view refreshes.
+const statusInterval = 2 * time.Second
+
+// statusWindows are the throughput graph windows the w key cycles
+// through. History is kept for the longest one.
+var statusWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
 type statusModel struct {
 	name    string
 	status  *wg.InterfaceStatus
 	loading bool
 	err     error
+
+	rates  *wg.Throughput
+	window int // index into statusWindows
+
+	peers map[string]wg.Peer // configured peers by public key, for metadata
+
+	back viewType // where esc returns to
+
+	// seq tags refresh ticks, so ticks from an earlier status view don't
+	// start a second refresh loop or show another interface's status.
+	seq int
 }
 
-func newStatusModel(name string) statusModel {
-	return statusModel{name: name, loading: true}
+// newStatusModel returns the status view of the named interface. prevSeq
+// is the seq of the status view it replaces.
+func newStatusModel(name string, back viewType, prevSeq int) statusModel {
+	return statusModel{
+		name:    name,
+		loading: true,
+		rates:   newThroughput(),
+		back:    back,
+		seq:     prevSeq + 1,
+	}
+}
+
+// newThroughput returns a rate history long enough for every window.
+func newThroughput() *wg.Throughput {
+	return wg.NewThroughput(statusWindows[len(statusWindows)-1])
 }
 
-func (s statusModel) init() tea.Cmd {
-	return fetchStatus(s.name)
+func (s statusModel) init(mgr *wg.Manager) tea.Cmd {
+	return fetchStatus(mgr, s.name, s.seq)
 }
 
-func fetchStatus(name string) tea.Cmd {
+func fetchStatus(mgr *wg.Manager, name string, seq int) tea.Cmd {
 	return func() tea.Msg {
-		st, err := wg.GetStatus(name)
-		return statusDataMsg{status: st, err: err}
+		st, err := mgr.GetStatus(name)
+		return statusDataMsg{seq: seq, status: st, err: err}
 	}
 }
 
-func statusTick() tea.Cmd {
-	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
-		return statusTickMsg{}
+func statusTick(seq int) tea.Cmd {
+	return tea.Tick(statusInterval, func(t time.Time) tea.Msg {
+		return statusTickMsg{seq: seq}
 	})
 }
 
 func (a App) updateStatus(msg tea.Msg) (App, tea.Cmd) {
 	switch msg := msg.(type) {
 	case statusDataMsg:
+		if msg.seq != a.status.seq {
+			return a, nil
+		}
 		a.status.status = msg.status
 		a.status.err = msg.err
 		a.status.loading = false
-		return a, statusTick()
+		if msg.status != nil {
+			a.status.rates.Add(time.Now(), msg.status)
+		}
+		return a, statusTick(a.status.seq)
 
 	case statusTickMsg:
-		return a, fetchStatus(a.status.name)
+		if msg.seq != a.status.seq {
+			return a, nil
+		}
+		return a, fetchStatus(a.mgr, a.status.name, a.status.seq)
 
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc", "q":
-			a.currentView = viewDetail
+			a.status.seq++ // stop refreshing
+			a.currentView = a.status.back
+			if a.status.back == viewDashboard {
+				return a, a.dashboard.resume(a.mgr)
+			}
+			return a, nil
+		case "w":
+			a.status.window = (a.status.window + 1) % len(statusWindows)
 			return a, nil
 		}
 	}
@@ -68,13 +116,16 @@ func (a App) updateStatus(msg tea.Msg) (App, tea.Cmd) {
 	return a, nil
 }
 
-// formatHandshake formats a time.Duration as a human-readable "ago" string.
-func formatHandshake(d time.Duration) string {
-	if d == 0 {
+// formatHandshake formats a handshake time as a human-readable "ago" string.
+func formatHandshake(t time.Time) string {
+	if t.IsZero() {
 		return "never"
 	}
 
-	totalSeconds := int(d.Seconds())
+	totalSeconds := int(time.Since(t).Seconds())
+	if totalSeconds < 0 {
+		totalSeconds = 0
+	}
 	hours := totalSeconds / 3600
 	minutes := (totalSeconds % 3600) / 60
 	seconds := totalSeconds % 60
@@ -93,6 +144,97 @@ func formatHandshake(d time.Duration) string {
 	return strings.Join(parts, "") + " ago"
 }
 
+// formatBytes formats a byte count with binary units, as `wg show` does
+// (e.g. "1.50 MiB").
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit && exp < 3; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+// formatRate formats a rate in bytes per second, e.g. "1.50 MiB/s".
+func formatRate(bytesPerSec float64) string {
+	return formatBytes(uint64(bytesPerSec)) + "/s"
+}
+
+// formatWindow renders a graph window like "5m".
+func formatWindow(d time.Duration) string {
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values over the last window before now as width
+// columns, scaled to peak. Each column shows the highest value that falls
+// in it; columns without samples (before history started) are blank, so
+// a new graph fills in from the right.
+func sparkline(rates []wg.Rate, value func(wg.Rate) float64, peak float64, now time.Time, window time.Duration, width int) string {
+	cols := make([]float64, width)
+	has := make([]bool, width)
+	for _, r := range rates {
+		age := now.Sub(r.At)
+		col := width - 1 - int(float64(width)*age.Seconds()/window.Seconds())
+		if col < 0 || col >= width {
+			continue
+		}
+		cols[col] = max(cols[col], value(r))
+		has[col] = true
+	}
+
+	var b strings.Builder
+	for i, v := range cols {
+		switch {
+		case !has[i]:
+			b.WriteByte(' ')
+		case peak <= 0:
+			b.WriteRune(sparkBlocks[0])
+		default:
+			b.WriteRune(sparkBlocks[int(v/peak*float64(len(sparkBlocks)-1)+0.5)])
+		}
+	}
+	return b.String()
+}
+
+// rateLines renders a peer's current rate and, once there is history,
+// rx/tx sparklines with peak and average over the window.
+func (s statusModel) rateLines(peer string, width int) string {
+	now := time.Now()
+	window := statusWindows[s.window]
+	rates := s.rates.Rates(peer, now, window)
+	if len(rates) == 0 {
+		return "  " + labelStyle.Render("Rate:") + descStyle.Render("measuring...") + "\n"
+	}
+	st := wg.Stats(rates)
+
+	// The graph gets one column per refresh, up to what fits in the box.
+	cols := int(window / statusInterval)
+	cols = max(min(cols, width-50), 10)
+
+	var b strings.Builder
+	b.WriteString("  " + labelStyle.Render("Rate:") + valueStyle.Render(fmt.Sprintf("\u2193 %s  \u2191 %s", formatRate(st.Current.Rx), formatRate(st.Current.Tx))) + "\n")
+	graph := lipgloss.NewStyle().Foreground(colorGreen)
+	for _, dir := range []struct {
+		arrow     string
+		value     func(wg.Rate) float64
+		peak, avg float64
+	}{
+		{"\u2193", func(r wg.Rate) float64 { return r.Rx }, st.Peak.Rx, st.Average.Rx},
+		{"\u2191", func(r wg.Rate) float64 { return r.Tx }, st.Peak.Tx, st.Average.Tx},
+	} {
+		line := sparkline(rates, dir.value, dir.peak, now, window, cols)
+		fmt.Fprintf(&b, "  %s %s %s\n", dir.arrow, graph.Render(line),
+			descStyle.Render(fmt.Sprintf("peak %s  avg %s", formatRate(dir.peak), formatRate(dir.avg))))
+	}
+	return b.String()
+}
+
 func (s statusModel) view(width, height int) string {
 	var b strings.Builder
 
@@ -124,6 +266,9 @@ func (s statusModel) view(width, height int) string {
 	if st.ListenPort != 0 {
 		b.WriteString("  " + labelStyle.Render("Listen Port:") + valueStyle.Render(fmt.Sprintf("%d", st.ListenPort)) + "\n")
 	}
+	if st.FwMark != 0 {
+		b.WriteString("  " + labelStyle.Render("FwMark:") + valueStyle.Render(fmt.Sprintf("0x%x", st.FwMark)) + "\n")
+	}
 
 	b.WriteString("\n")
 
@@ -138,28 +283,35 @@ func (s statusModel) view(width, height int) string {
 		var peerContent strings.Builder
 
 		truncatedKey := truncateKey(peer.PublicKey, 12)
-		fmt.Fprintf(&peerContent, "Peer %d: %s\n", i+1, valueStyle.Render(truncatedKey))
+		meta, ok := s.peers[peer.PublicKey]
+		if ok && meta.Name != "" {
+			fmt.Fprintf(&peerContent, "Peer %d: %s %s\n", i+1, valueStyle.Render(meta.Name), descStyle.Render(truncatedKey))
+		} else {
+			fmt.Fprintf(&peerContent, "Peer %d: %s\n", i+1, valueStyle.Render(truncatedKey))
+		}
+		if ok {
+			peerContent.WriteString(peerMetaLines(meta, "  "))
+		}
 
 		if peer.Endpoint != "" {
 			peerContent.WriteString("  " + labelStyle.Render("Endpoint:") + valueStyle.Render(peer.Endpoint) + "\n")
 		}
-		if peer.AllowedIPs != "" {
-			peerContent.WriteString("  " + labelStyle.Render("Allowed IPs:") + valueStyle.Render(peer.AllowedIPs) + "\n")
+		if peer.HasPresharedKey {
+			peerContent.WriteString("  " + labelStyle.Render("Preshared Key:") + valueStyle.Render("(hidden)") + "\n")
+		}
+		if len(peer.AllowedIPs) > 0 {
+			peerContent.WriteString("  " + labelStyle.Render("Allowed IPs:") + valueStyle.Render(wg.FormatPrefixes(peer.AllowedIPs)) + "\n")
 		}
 
 		peerContent.WriteString("  " + labelStyle.Render("Latest Handshake:") + valueStyle.Render(formatHandshake(peer.LatestHandshake)) + "\n")
+		peerContent.WriteString("  " + labelStyle.Render("Health:") + healthLabel(wg.PeerHealth(peer, time.Now())) + "\n")
 
-		if peer.TransferRx != "" || peer.TransferTx != "" {
-			rx := peer.TransferRx
-			tx := peer.TransferTx
-			if rx == "" {
-				rx = "0 B"
-			}
-			if tx == "" {
-				tx = "0 B"
-			}
+		if peer.TransferRx != 0 || peer.TransferTx != 0 {
+			rx := formatBytes(peer.TransferRx)
+			tx := formatBytes(peer.TransferTx)
 			peerContent.WriteString("  " + labelStyle.Render("Transfer:") + valueStyle.Render(fmt.Sprintf("\u2193 %s  \u2191 %s", rx, tx)) + "\n")
 		}
+		peerContent.WriteString(s.rateLines(peer.PublicKey, width))
 
 		if peer.PersistentKeepalive > 0 {
 			peerContent.WriteString("  " + labelStyle.Render("Keepalive:") + valueStyle.Render(fmt.Sprintf("every %ds", peer.PersistentKeepalive)) + "\n")
@@ -183,7 +335,7 @@ func (s statusModel) view(width, height int) string {
 	b.WriteString("\n")
 	b.WriteString("  " + descStyle.Render("Refreshing every 2s...") + "\n")
 	b.WriteString("\n")
-	b.WriteString(helpKey("esc", "back"))
+	b.WriteString(helpKey("w", "window: "+formatWindow(statusWindows[s.window])) + "  " + helpKey("esc", "back"))
 
 	return b.String()
 }
@@ -6,6 +6,7 @@ var (
 	// Colors
 	colorGreen  = lipgloss.Color("42")
 	colorRed    = lipgloss.Color("196")
+	colorYellow = lipgloss.Color("214")
 	colorDim    = lipgloss.Color("240")
 	colorAccent = lipgloss.Color("63")
 	colorWhite  = lipgloss.Color("255")
@@ -42,6 +43,9 @@ var (
 	successStyle = lipgloss.NewStyle().
 		Foreground(colorGreen)
 
+	warningStyle = lipgloss.NewStyle().
+		Foreground(colorYellow)
+
 	// Detail labels
 	labelStyle = lipgloss.NewStyle().
 		Foreground(colorDim).
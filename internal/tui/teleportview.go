@@ -64,13 +64,14 @@ func (a App) updateTeleport(msg tea.Msg) (App, tea.Cmd) {
 			return a, nil
 		}
 		iface.Name = msg.name
-		if err := wg.SaveConfig(configDir, iface); err != nil {
+		iface.Dir = a.mgr.ConfigDirs()[0]
+		if err := a.mgr.SaveConfig(iface.Dir, iface); err != nil {
 			a.teleportView.err = fmt.Errorf("saving config: %w", err)
 			return a, nil
 		}
 		a.message = fmt.Sprintf("Teleport profile %q created", msg.name)
 		a.currentView = viewList
-		return a, tea.Batch(loadProfiles(), clearMessages())
+		return a, tea.Batch(loadProfiles(a.mgr), clearMessages())
 
 	case teleportErrMsg:
 		a.teleportView.connecting = false
@@ -85,7 +86,7 @@ func (a App) updateTeleport(msg tea.Msg) (App, tea.Cmd) {
 		switch msg.String() {
 		case "esc":
 			a.currentView = viewList
-			return a, loadProfiles()
+			return a, loadProfiles(a.mgr)
 
 		case "tab", "shift+tab":
 			if a.teleportView.mode == teleportSetup {
@@ -156,16 +157,17 @@ type teleportToggleDoneMsg struct {
 // teleportToggleCmd regenerates the Teleport config before toggling.
 // If the interface is up, it just brings it down (no regen needed).
 // If the interface is down, it regenerates config via WebRTC then brings it up.
-func teleportToggleCmd(name string) tea.Cmd {
+func teleportToggleCmd(mgr *wg.Manager, profile *wg.Interface) tea.Cmd {
+	name := profile.Name
 	return func() tea.Msg {
-		up, err := wg.IsUp(name)
+		up, err := mgr.IsUp(profile.Target())
 		if err != nil {
 			return errMsg{fmt.Errorf("checking interface state: %w", err)}
 		}
 
 		// Toggling OFF: just bring it down, no regen needed
 		if up {
-			if err := wg.Down(name); err != nil {
+			if err := mgr.Down(profile.Target()); err != nil {
 				return errMsg{err}
 			}
 			return teleportToggleDoneMsg{name: name, nowUp: false}
@@ -182,12 +184,13 @@ func teleportToggleCmd(name string) tea.Cmd {
 			return errMsg{fmt.Errorf("parsing generated config: %w", err)}
 		}
 		iface.Name = name
+		iface.Dir = profile.Dir
 
-		if err := wg.SaveConfig(configDir, iface); err != nil {
+		if err := mgr.SaveConfig(iface.Dir, iface); err != nil {
 			return errMsg{fmt.Errorf("saving config: %w", err)}
 		}
 
-		if err := wg.Up(name); err != nil {
+		if err := mgr.Up(iface.Target()); err != nil {
 			return errMsg{err}
 		}
 
@@ -1,9 +1,9 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -13,8 +13,18 @@ import (
 	wg "github.com/mlu/wireguard-tui/internal/wg"
 )
 
-// wizardStepCount is the total number of main wizard steps.
-const wizardStepCount = 6
+// Main wizard steps. Steps before wizardStepAdvanced each have a single
+// text input in wizardModel.inputs, indexed by step.
+const (
+	wizardStepName = iota
+	wizardStepAddress
+	wizardStepListenPort
+	wizardStepDNS
+	wizardStepAdvanced
+	wizardStepPeers
+	wizardStepReview
+	wizardStepCount
+)
 
 // Peer sub-step indices within the peer sub-wizard.
 const (
@@ -28,8 +38,12 @@ const (
 
 // wizardModel holds the state for the multi-step profile creation wizard.
 type wizardModel struct {
-	step   int               // 0-5 main steps
-	inputs []textinput.Model // one per main step (0-3)
+	step   int               // one of the wizardStep* constants
+	inputs []textinput.Model // one per single-input step (name through DNS)
+
+	// Routing & Hooks step
+	advInputs []textinput.Model
+	advFocus  int
 
 	// Key generation for the interface
 	privateKey string
@@ -46,43 +60,43 @@ type wizardModel struct {
 	generatedPeerPrivKey string
 	generatedPeerPubKey  string
 
+	// peerKeys maps the public keys of added peers whose key pair was
+	// generated to their private keys, which are stored with the profile
+	// as client configs for export.
+	peerKeys map[string]string
+
 	// For generated preshared key display
 	generatedPSK string
 
+	dir string // profile root the new config is saved to
+
+	// diags holds the validation findings for the field(s) the user last
+	// tried to leave; errors keep them on that step.
+	diags []wg.Diagnostic
+
 	err error
 }
 
-// isValidInterfaceName checks that a name is safe for use as a Linux
-// network interface name: non-empty, at most 15 characters, and only
-// containing alphanumeric characters, hyphens, and underscores.
-func isValidInterfaceName(name string) bool {
-	if len(name) == 0 || len(name) > 15 {
-		return false
-	}
-	for _, c := range name {
-		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_') { //nolint:staticcheck // QF1001 De Morgan's form is less readable here
-			return false
-		}
+// suggestInterfaceName returns the first wgN name not used by any loaded
+// profile, in any root. Names must be unique across roots because wg-quick
+// names the interface after the file.
+func suggestInterfaceName(profiles []*wg.Interface) string {
+	taken := make(map[string]bool, len(profiles))
+	for _, p := range profiles {
+		taken[p.Name] = true
 	}
-	return true
-}
-
-// suggestInterfaceName returns the next available wgN name by checking
-// which .conf files already exist in configDir.
-func suggestInterfaceName() string {
 	for i := 0; ; i++ {
-		name := fmt.Sprintf("wg%d", i)
-		path := filepath.Join(configDir, name+".conf")
-		if _, err := os.Stat(path); os.IsNotExist(err) {
+		if name := fmt.Sprintf("wg%d", i); !taken[name] {
 			return name
 		}
 	}
 }
 
 // newWizardModel creates a new wizard model with generated keys and
-// initialized text inputs for all steps.
-func newWizardModel() wizardModel {
-	w := wizardModel{}
+// initialized text inputs for all steps. The profile is saved to dir;
+// profiles lists the existing ones so the suggested name is free.
+func newWizardModel(dir string, profiles []*wg.Interface) wizardModel {
+	w := wizardModel{dir: dir}
 
 	// Generate interface keypair
 	privKey, pubKey, err := wg.GenerateKeyPair()
@@ -93,33 +107,32 @@ func newWizardModel() wizardModel {
 		w.publicKey = pubKey
 	}
 
-	// Initialize main step inputs (steps 0-3)
-	w.inputs = make([]textinput.Model, 4)
+	// Initialize single-input step inputs
+	w.inputs = make([]textinput.Model, wizardStepAdvanced)
 
-	// Step 0: Interface Name
-	w.inputs[0] = textinput.New()
-	w.inputs[0].Placeholder = "wg0"
-	w.inputs[0].SetValue(suggestInterfaceName())
-	w.inputs[0].Focus()
-	w.inputs[0].CharLimit = 15
+	w.inputs[wizardStepName] = textinput.New()
+	w.inputs[wizardStepName].Placeholder = "wg0"
+	w.inputs[wizardStepName].SetValue(suggestInterfaceName(profiles))
+	w.inputs[wizardStepName].Focus()
+	w.inputs[wizardStepName].CharLimit = 15
 
-	// Step 1: Address
-	w.inputs[1] = textinput.New()
-	w.inputs[1].Placeholder = "10.0.0.1/24"
-	w.inputs[1].SetValue("10.0.0.1/24")
-	w.inputs[1].CharLimit = 43
+	w.inputs[wizardStepAddress] = textinput.New()
+	w.inputs[wizardStepAddress].Placeholder = "10.0.0.1/24"
+	w.inputs[wizardStepAddress].SetValue("10.0.0.1/24")
+	w.inputs[wizardStepAddress].CharLimit = 200
 
-	// Step 2: Listen Port
-	w.inputs[2] = textinput.New()
-	w.inputs[2].Placeholder = "51820"
-	w.inputs[2].SetValue("51820")
-	w.inputs[2].CharLimit = 5
+	w.inputs[wizardStepListenPort] = textinput.New()
+	w.inputs[wizardStepListenPort].Placeholder = "51820"
+	w.inputs[wizardStepListenPort].SetValue("51820")
+	w.inputs[wizardStepListenPort].CharLimit = 5
 
-	// Step 3: DNS
-	w.inputs[3] = textinput.New()
-	w.inputs[3].Placeholder = "1.1.1.1, 8.8.8.8"
-	w.inputs[3].SetValue("1.1.1.1, 8.8.8.8")
-	w.inputs[3].CharLimit = 100
+	w.inputs[wizardStepDNS] = textinput.New()
+	w.inputs[wizardStepDNS].Placeholder = "1.1.1.1, 8.8.8.8"
+	w.inputs[wizardStepDNS].SetValue("1.1.1.1, 8.8.8.8")
+	w.inputs[wizardStepDNS].CharLimit = 100
+
+	// Routing & Hooks step: all optional, empty by default
+	w.advInputs = makeAdvancedInputs(&wg.Interface{})
 
 	// Initialize peer sub-step inputs
 	w.peerInputs = makePeerInputs()
@@ -171,7 +184,7 @@ func (a App) updateWizard(msg tea.Msg) (App, tea.Cmd) {
 	case configSavedMsg:
 		a.message = fmt.Sprintf("Created profile %q", msg.name)
 		a.currentView = viewList
-		return a, tea.Batch(loadProfiles(), clearMessages())
+		return a, tea.Batch(loadProfiles(a.mgr), clearMessages())
 
 	case tea.KeyMsg:
 		key := msg.String()
@@ -181,8 +194,8 @@ func (a App) updateWizard(msg tea.Msg) (App, tea.Cmd) {
 			return a.wizardHandleEsc()
 		}
 
-		// Review step (step 5) has its own key handling
-		if w.step == 5 {
+		// Review step has its own key handling
+		if w.step == wizardStepReview {
 			return a.wizardHandleReview(key)
 		}
 
@@ -191,18 +204,23 @@ func (a App) updateWizard(msg tea.Msg) (App, tea.Cmd) {
 			return a.wizardHandleAskMore(key)
 		}
 
-		// Peer sub-wizard (step 4, addingPeer == true)
-		if w.step == 4 && w.addingPeer {
+		// Peer sub-wizard
+		if w.step == wizardStepPeers && w.addingPeer {
 			return a.wizardHandlePeerStep(msg)
 		}
 
-		// Main steps 0-3: text inputs
-		if w.step >= 0 && w.step <= 3 {
+		// Single-input steps
+		if w.step < wizardStepAdvanced {
 			return a.wizardHandleMainStep(msg)
 		}
 
-		// Step 4 peer summary (not adding, not asking)
-		if w.step == 4 && !w.addingPeer {
+		// Routing & Hooks form
+		if w.step == wizardStepAdvanced {
+			return a.wizardHandleAdvancedStep(msg)
+		}
+
+		// Peer summary (not adding, not asking)
+		if w.step == wizardStepPeers && !w.addingPeer {
 			switch key {
 			case "enter":
 				// Start adding a new peer
@@ -216,7 +234,7 @@ func (a App) updateWizard(msg tea.Msg) (App, tea.Cmd) {
 			case "n":
 				// Continue to review (only if peers exist)
 				if len(w.peers) > 0 {
-					w.step = 5
+					w.step = wizardStepReview
 				}
 			}
 			return a, nil
@@ -229,6 +247,7 @@ func (a App) updateWizard(msg tea.Msg) (App, tea.Cmd) {
 // wizardHandleEsc handles the escape key at any wizard step.
 func (a App) wizardHandleEsc() (App, tea.Cmd) {
 	w := &a.wizard
+	w.diags = nil
 
 	if w.askingMore {
 		// Cancel asking, go back to last peer sub-step
@@ -239,14 +258,15 @@ func (a App) wizardHandleEsc() (App, tea.Cmd) {
 		return a, nil
 	}
 
-	if w.step == 5 {
+	if w.step == wizardStepReview {
 		// Review back to peer step
-		w.step = 4
+		w.err = nil
+		w.step = wizardStepPeers
 		w.addingPeer = false
 		return a, nil
 	}
 
-	if w.step == 4 && w.addingPeer {
+	if w.step == wizardStepPeers && w.addingPeer {
 		if w.peerStep > 0 {
 			w.peerInputs[w.peerStep].Blur()
 			w.peerStep--
@@ -261,22 +281,29 @@ func (a App) wizardHandleEsc() (App, tea.Cmd) {
 			}
 			return a, nil
 		}
-		// At first peer sub-step, go back to step 3
+		// At first peer sub-step, go back to the Routing & Hooks step
 		w.addingPeer = false
 		w.peerInputs[0].Blur()
 		// If we have peers already, just go back to non-adding state
 		if len(w.peers) > 0 {
-			w.step = 4
+			w.step = wizardStepPeers
 			return a, nil
 		}
-		w.step = 3
-		w.inputs[3].Focus()
+		w.step = wizardStepAdvanced
+		w.advInputs[w.advFocus].Focus()
+		return a, nil
+	}
+
+	if w.step == wizardStepPeers && !w.addingPeer {
+		w.step = wizardStepAdvanced
+		w.advInputs[w.advFocus].Focus()
 		return a, nil
 	}
 
-	if w.step == 4 && !w.addingPeer {
-		w.step = 3
-		w.inputs[3].Focus()
+	if w.step == wizardStepAdvanced {
+		w.advInputs[w.advFocus].Blur()
+		w.step = wizardStepDNS
+		w.inputs[w.step].Focus()
 		return a, nil
 	}
 
@@ -292,53 +319,26 @@ func (a App) wizardHandleEsc() (App, tea.Cmd) {
 	return a, nil
 }
 
-// wizardHandleMainStep handles key events for main steps 0-3.
+// wizardHandleMainStep handles key events for the single-input steps.
 func (a App) wizardHandleMainStep(msg tea.KeyMsg) (App, tea.Cmd) {
 	w := &a.wizard
 
 	if msg.String() == "enter" {
-		val := strings.TrimSpace(w.inputs[w.step].Value())
-		// Validate required fields
-		if w.step == 0 {
-			if val == "" {
-				w.err = fmt.Errorf("interface name is required")
-				return a, nil
-			}
-			if !isValidInterfaceName(val) {
-				w.err = fmt.Errorf("invalid interface name: use only a-z, A-Z, 0-9, hyphen, underscore (max 15 chars)")
-				return a, nil
-			}
-		}
-		if w.step == 1 && val == "" {
-			w.err = fmt.Errorf("address is required")
-			return a, nil
-		}
-		if w.step == 2 && val == "" {
-			w.err = fmt.Errorf("listen port is required")
+		_, diags := w.build()
+		w.diags = fieldDiags(diags, -1, wizardStepKeys[w.step])
+		if wg.HasErrors(w.diags) {
 			return a, nil
 		}
-		if w.step == 2 {
-			if _, err := strconv.Atoi(val); err != nil {
-				w.err = fmt.Errorf("listen port must be a number")
-				return a, nil
-			}
-		}
 
+		w.diags = nil
 		w.err = nil
 		w.inputs[w.step].Blur()
 		w.step++
 
-		if w.step <= 3 {
+		if w.step < wizardStepAdvanced {
 			w.inputs[w.step].Focus()
 		} else {
-			// Moving to step 4: start peer sub-wizard
-			w.addingPeer = true
-			w.peerStep = 0
-			w.peerInputs = makePeerInputs()
-			w.peerInputs[0].Focus()
-			w.generatedPeerPrivKey = ""
-			w.generatedPeerPubKey = ""
-			w.generatedPSK = ""
+			w.advInputs[w.advFocus].Focus()
 		}
 		return a, nil
 	}
@@ -349,6 +349,59 @@ func (a App) wizardHandleMainStep(msg tea.KeyMsg) (App, tea.Cmd) {
 	return a, cmd
 }
 
+// wizardHandleAdvancedStep handles key events for the Routing & Hooks form.
+// Tab moves between fields; enter advances and, on the last field, validates
+// the form and starts the peer sub-wizard.
+func (a App) wizardHandleAdvancedStep(msg tea.KeyMsg) (App, tea.Cmd) {
+	w := &a.wizard
+
+	switch msg.String() {
+	case "tab", "down":
+		w.advInputs[w.advFocus].Blur()
+		w.advFocus = (w.advFocus + 1) % advFieldCount
+		w.advInputs[w.advFocus].Focus()
+		return a, nil
+
+	case "shift+tab", "up":
+		w.advInputs[w.advFocus].Blur()
+		w.advFocus = (w.advFocus + advFieldCount - 1) % advFieldCount
+		w.advInputs[w.advFocus].Focus()
+		return a, nil
+
+	case "enter":
+		if w.advFocus < advFieldCount-1 {
+			w.advInputs[w.advFocus].Blur()
+			w.advFocus++
+			w.advInputs[w.advFocus].Focus()
+			return a, nil
+		}
+		_, diags := w.build()
+		w.diags = nil
+		for _, key := range advFieldKeys {
+			w.diags = append(w.diags, fieldDiags(diags, -1, key)...)
+		}
+		if wg.HasErrors(w.diags) {
+			return a, nil
+		}
+
+		w.diags = nil
+		w.advInputs[w.advFocus].Blur()
+		w.step = wizardStepPeers
+		w.addingPeer = true
+		w.peerStep = 0
+		w.peerInputs = makePeerInputs()
+		w.peerInputs[0].Focus()
+		w.generatedPeerPrivKey = ""
+		w.generatedPeerPubKey = ""
+		w.generatedPSK = ""
+		return a, nil
+	}
+
+	var cmd tea.Cmd
+	w.advInputs[w.advFocus], cmd = w.advInputs[w.advFocus].Update(msg)
+	return a, cmd
+}
+
 // wizardHandlePeerStep handles key events for the peer sub-wizard.
 func (a App) wizardHandlePeerStep(msg tea.KeyMsg) (App, tea.Cmd) {
 	w := &a.wizard
@@ -380,22 +433,14 @@ func (a App) wizardHandlePeerStep(msg tea.KeyMsg) (App, tea.Cmd) {
 	}
 
 	if key == "enter" {
-		val := strings.TrimSpace(w.peerInputs[w.peerStep].Value())
-
-		// Validate required peer fields
-		if w.peerStep == peerStepPubKey && val == "" {
-			w.err = fmt.Errorf("public key is required (enter a key or press 'g' to generate)")
+		iface, diags := w.build()
+		idx := len(w.peers)
+		w.diags = fieldDiags(diags, idx, peerStepKeys[w.peerStep])
+		if wg.HasErrors(w.diags) {
 			return a, nil
 		}
 
-		// Validate keepalive is a number if provided
-		if w.peerStep == peerStepKeepalive && val != "" {
-			if _, err := strconv.Atoi(val); err != nil {
-				w.err = fmt.Errorf("persistent keepalive must be a number")
-				return a, nil
-			}
-		}
-
+		w.diags = nil
 		w.err = nil
 		w.peerInputs[w.peerStep].Blur()
 
@@ -405,20 +450,14 @@ func (a App) wizardHandlePeerStep(msg tea.KeyMsg) (App, tea.Cmd) {
 			return a, nil
 		}
 
-		// Completed all peer sub-steps: build the peer
-		keepalive := 0
-		if ka := strings.TrimSpace(w.peerInputs[peerStepKeepalive].Value()); ka != "" {
-			keepalive, _ = strconv.Atoi(ka)
-		}
-
-		peer := wg.Peer{
-			PublicKey:           strings.TrimSpace(w.peerInputs[peerStepPubKey].Value()),
-			AllowedIPs:          strings.TrimSpace(w.peerInputs[peerStepAllowedIPs].Value()),
-			Endpoint:            strings.TrimSpace(w.peerInputs[peerStepEndpoint].Value()),
-			PresharedKey:        strings.TrimSpace(w.peerInputs[peerStepPSK].Value()),
-			PersistentKeepalive: keepalive,
+		// Completed all peer sub-steps: keep the peer as validated
+		w.peers = append(w.peers, iface.Peers[idx])
+		if w.generatedPeerPrivKey != "" && w.generatedPeerPubKey == iface.Peers[idx].PublicKey {
+			if w.peerKeys == nil {
+				w.peerKeys = make(map[string]string)
+			}
+			w.peerKeys[w.generatedPeerPubKey] = w.generatedPeerPrivKey
 		}
-		w.peers = append(w.peers, peer)
 		w.addingPeer = false
 		w.askingMore = true
 		w.generatedPeerPrivKey = ""
@@ -450,7 +489,7 @@ func (a App) wizardHandleAskMore(key string) (App, tea.Cmd) {
 	case "n":
 		w.askingMore = false
 		w.addingPeer = false
-		w.step = 5 // Advance to review
+		w.step = wizardStepReview
 	}
 
 	return a, nil
@@ -463,10 +502,18 @@ func (a App) wizardHandleReview(key string) (App, tea.Cmd) {
 	switch key {
 	case "c":
 		// Build and save the config
-		iface := a.wizardBuildInterface()
+		iface, diags := w.build()
+		if wg.HasErrors(diags) {
+			w.err = fmt.Errorf("config has errors; go back and fix them before saving")
+			return a, nil
+		}
 		name := iface.Name
+		keys := w.peerKeys
 		return a, func() tea.Msg {
-			if err := wg.SaveConfig(configDir, iface); err != nil {
+			if err := a.mgr.SaveConfig(iface.Dir, iface); err != nil {
+				return errMsg{err: err}
+			}
+			if err := saveGeneratedClients(a.mgr, iface, keys); err != nil {
 				return errMsg{err: err}
 			}
 			return configSavedMsg{name: name}
@@ -474,7 +521,8 @@ func (a App) wizardHandleReview(key string) (App, tea.Cmd) {
 
 	case "b":
 		// Back to peer step
-		w.step = 4
+		w.err = nil
+		w.step = wizardStepPeers
 		w.addingPeer = false
 		return a, nil
 
@@ -487,20 +535,84 @@ func (a App) wizardHandleReview(key string) (App, tea.Cmd) {
 	return a, nil
 }
 
-// wizardBuildInterface constructs a wg.Interface from the current wizard state.
-func (a App) wizardBuildInterface() *wg.Interface {
-	w := &a.wizard
+// saveGeneratedClients stores the client config of every peer of iface
+// whose key pair the wizard generated and that has a client address,
+// named peer1, peer2, ... after the peer's position, so they can be
+// exported as a bundle later.
+func saveGeneratedClients(mgr *wg.Manager, iface *wg.Interface, keys map[string]string) error {
+	for i, p := range iface.Peers {
+		priv, ok := keys[p.PublicKey]
+		if !ok {
+			continue
+		}
+		client, err := wg.ClientConfig(iface, p, fmt.Sprintf("peer%d", i+1), priv)
+		if errors.Is(err, wg.ErrNoClientAddress) {
+			// Not a client of this profile (e.g. its server, routing
+			// 0.0.0.0/0): there is no config to hand out.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := mgr.SaveClient(iface, client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// build constructs a wg.Interface from the current wizard state, including
+// the peer being added, and validates it. Inputs that don't parse are
+// reported as diagnostics against their config key.
+func (w wizardModel) build() (*wg.Interface, []wg.Diagnostic) {
+	var inputDiags []wg.Diagnostic
+
+	name := strings.TrimSpace(w.inputs[wizardStepName].Value())
+	if name == "" {
+		inputDiags = append(inputDiags, inputDiag(-1, "Name", fmt.Errorf("is required")))
+	}
+
+	address, err := wg.ParsePrefixes(w.inputs[wizardStepAddress].Value())
+	switch {
+	case err != nil:
+		inputDiags = append(inputDiags, inputDiag(-1, "Address", err))
+	case len(address) == 0:
+		inputDiags = append(inputDiags, inputDiag(-1, "Address", fmt.Errorf("is required")))
+	}
+
+	portStr := strings.TrimSpace(w.inputs[wizardStepListenPort].Value())
+	port, err := strconv.Atoi(portStr)
+	switch {
+	case portStr == "":
+		inputDiags = append(inputDiags, inputDiag(-1, "ListenPort", fmt.Errorf("is required")))
+	case err != nil:
+		inputDiags = append(inputDiags, inputDiag(-1, "ListenPort", fmt.Errorf("must be a number")))
+	}
 
-	port, _ := strconv.Atoi(strings.TrimSpace(w.inputs[2].Value()))
+	dns, search, err := wg.ParseDNS(w.inputs[wizardStepDNS].Value())
+	if err != nil {
+		inputDiags = append(inputDiags, inputDiag(-1, "DNS", err))
+	}
 
-	return &wg.Interface{
-		Name:       strings.TrimSpace(w.inputs[0].Value()),
-		Address:    strings.TrimSpace(w.inputs[1].Value()),
+	iface := &wg.Interface{
+		Name:       name,
+		Dir:        w.dir,
+		Address:    address,
 		ListenPort: port,
 		PrivateKey: w.privateKey,
-		DNS:        strings.TrimSpace(w.inputs[3].Value()),
-		Peers:      w.peers,
+		DNS:        dns,
+		DNSSearch:  search,
+		Peers:      slices.Clone(w.peers),
+	}
+	inputDiags = append(inputDiags, applyAdvancedInputs(w.advInputs, iface)...)
+
+	if w.addingPeer {
+		peer, peerDiags := parsePeerInputs(w.peerInputs, wg.Peer{}, len(iface.Peers))
+		iface.Peers = append(iface.Peers, peer)
+		inputDiags = append(inputDiags, peerDiags...)
 	}
+
+	return iface, mergeDiags(inputDiags, wg.Validate(iface))
 }
 
 // view renders the wizard UI based on the current step.
@@ -520,32 +632,43 @@ func (w wizardModel) view(width, height int) string {
 	}
 
 	switch {
-	case w.step <= 3:
+	case w.step < wizardStepAdvanced:
 		return w.viewMainStep(width)
-	case w.step == 4 && w.askingMore:
+	case w.step == wizardStepAdvanced:
+		return w.viewAdvancedStep(width)
+	case w.step == wizardStepPeers && w.askingMore:
 		return w.viewAskMore()
-	case w.step == 4 && w.addingPeer:
+	case w.step == wizardStepPeers && w.addingPeer:
 		return w.viewPeerStep(width)
-	case w.step == 4 && !w.addingPeer:
+	case w.step == wizardStepPeers && !w.addingPeer:
 		return w.viewPeerSummary()
-	case w.step == 5:
+	case w.step == wizardStepReview:
 		return w.viewReview(width)
 	}
 
 	return ""
 }
 
+// wizardStepKeys maps the single-input steps to the config keys they edit.
+var wizardStepKeys = [wizardStepAdvanced]string{
+	"Name",
+	"Address",
+	"ListenPort",
+	"DNS",
+}
+
 // stepLabels returns a human-readable label for each main step.
 var stepLabels = [wizardStepCount]string{
 	"Interface Name",
 	"Address",
 	"Listen Port",
 	"DNS",
+	"Routing & Hooks",
 	"Peers",
 	"Review & Confirm",
 }
 
-// viewMainStep renders steps 0-3 (text input steps).
+// viewMainStep renders the single-input steps.
 func (w wizardModel) viewMainStep(width int) string {
 	var b strings.Builder
 
@@ -567,19 +690,20 @@ func (w wizardModel) viewMainStep(width int) string {
 
 	// Context help for current step
 	switch w.step {
-	case 0:
+	case wizardStepName:
 		b.WriteString("  " + descStyle.Render("Name for the WireGuard interface (e.g., wg0, wg1)"))
-	case 1:
-		b.WriteString("  " + descStyle.Render("VPN address with CIDR notation (e.g., 10.0.0.1/24)"))
-	case 2:
+	case wizardStepAddress:
+		b.WriteString("  " + descStyle.Render("VPN addresses with CIDR notation, comma-separated (e.g., 10.0.0.1/24, fd00::1/64)"))
+	case wizardStepListenPort:
 		b.WriteString("  " + descStyle.Render("UDP port to listen on"))
-	case 3:
-		b.WriteString("  " + descStyle.Render("DNS servers, comma-separated"))
+	case wizardStepDNS:
+		b.WriteString("  " + descStyle.Render("DNS servers and search domains, comma-separated"))
 	}
 	b.WriteString("\n\n")
 
 	// Render the input
 	b.WriteString("  " + w.inputs[w.step].View())
+	b.WriteString(inlineDiags(w.diags, -1, wizardStepKeys[w.step]))
 	b.WriteString("\n\n")
 
 	// Error
@@ -595,6 +719,42 @@ func (w wizardModel) viewMainStep(width int) string {
 	return b.String()
 }
 
+// viewAdvancedStep renders the optional Routing & Hooks form.
+func (w wizardModel) viewAdvancedStep(width int) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("New Profile"))
+	b.WriteString("\n\n")
+
+	indicator := fmt.Sprintf("Step %d/%d: %s", w.step+1, wizardStepCount, stepLabels[w.step])
+	b.WriteString(descStyle.Render(indicator))
+	b.WriteString("\n\n")
+
+	b.WriteString("  " + descStyle.Render("Optional policy routing and wg-quick hooks (%i expands to the interface name)"))
+	b.WriteString("\n\n")
+
+	for i := 0; i < advFieldCount; i++ {
+		cursor := "  "
+		if i == w.advFocus {
+			cursor = "> "
+		}
+		b.WriteString(cursor + labelStyle.Render(advFieldLabels[i]) + w.advInputs[i].View())
+		b.WriteString(inlineDiags(w.diags, -1, advFieldKeys[i]))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if w.err != nil {
+		b.WriteString("  " + wrapError(w.err, width))
+		b.WriteString("\n\n")
+	}
+
+	help := helpKey("tab", "next field") + "  " + helpKey("enter", "next") + "  " + helpKey("esc", "back")
+	b.WriteString(help)
+
+	return b.String()
+}
+
 // peerStepLabels returns labels for each peer sub-step.
 var peerStepLabels = [peerSubStepCount]string{
 	"Public Key",
@@ -604,6 +764,15 @@ var peerStepLabels = [peerSubStepCount]string{
 	"Persistent Keepalive",
 }
 
+// peerStepKeys maps peer sub-steps to the config keys they edit.
+var peerStepKeys = [peerSubStepCount]string{
+	"PublicKey",
+	"AllowedIPs",
+	"Endpoint",
+	"PresharedKey",
+	"PersistentKeepalive",
+}
+
 // viewPeerStep renders the peer sub-wizard.
 func (w wizardModel) viewPeerStep(width int) string {
 	var b strings.Builder
@@ -615,7 +784,7 @@ func (w wizardModel) viewPeerStep(width int) string {
 
 	// Step indicator
 	indicator := fmt.Sprintf("Step %d/%d: %s > Peer %d > %s",
-		5, wizardStepCount, stepLabels[4], peerNum, peerStepLabels[w.peerStep])
+		wizardStepPeers+1, wizardStepCount, stepLabels[wizardStepPeers], peerNum, peerStepLabels[w.peerStep])
 	b.WriteString(descStyle.Render(indicator))
 	b.WriteString("\n\n")
 
@@ -630,7 +799,7 @@ func (w wizardModel) viewPeerStep(width int) string {
 			b.WriteString("\n")
 			b.WriteString("  " + labelStyle.Render("Private key:") + valueStyle.Render(w.generatedPeerPrivKey))
 			b.WriteString("\n")
-			b.WriteString("  " + descStyle.Render("(give this private key to the peer)"))
+			b.WriteString("  " + descStyle.Render("(kept for client bundles if its Allowed IPs are a host address in this profile's subnet)"))
 			b.WriteString("\n")
 			b.WriteString("  " + labelStyle.Render("Public key:") + valueStyle.Render(w.generatedPeerPubKey))
 			b.WriteString("\n")
@@ -656,6 +825,7 @@ func (w wizardModel) viewPeerStep(width int) string {
 
 	// Render the input
 	b.WriteString("  " + w.peerInputs[w.peerStep].View())
+	b.WriteString(inlineDiags(w.diags, len(w.peers), peerStepKeys[w.peerStep]))
 	b.WriteString("\n\n")
 
 	// Error
@@ -681,7 +851,7 @@ func (w wizardModel) viewAskMore() string {
 	b.WriteString(titleStyle.Render("New Profile"))
 	b.WriteString("\n\n")
 
-	indicator := fmt.Sprintf("Step %d/%d: %s", 5, wizardStepCount, stepLabels[4])
+	indicator := fmt.Sprintf("Step %d/%d: %s", wizardStepPeers+1, wizardStepCount, stepLabels[wizardStepPeers])
 	b.WriteString(descStyle.Render(indicator))
 	b.WriteString("\n\n")
 
@@ -702,7 +872,7 @@ func (w wizardModel) viewPeerSummary() string {
 	b.WriteString(titleStyle.Render("New Profile"))
 	b.WriteString("\n\n")
 
-	indicator := fmt.Sprintf("Step %d/%d: %s", 5, wizardStepCount, stepLabels[4])
+	indicator := fmt.Sprintf("Step %d/%d: %s", wizardStepPeers+1, wizardStepCount, stepLabels[wizardStepPeers])
 	b.WriteString(descStyle.Render(indicator))
 	b.WriteString("\n\n")
 
@@ -733,21 +903,14 @@ func (w wizardModel) viewReview(width int) string {
 	b.WriteString(titleStyle.Render("New Profile"))
 	b.WriteString("\n\n")
 
-	indicator := fmt.Sprintf("Step %d/%d: %s", 6, wizardStepCount, stepLabels[5])
+	indicator := fmt.Sprintf("Step %d/%d: %s", wizardStepReview+1, wizardStepCount, stepLabels[wizardStepReview])
 	b.WriteString(descStyle.Render(indicator))
 	b.WriteString("\n\n")
 
-	// Build the interface to marshal for preview
-	port, _ := strconv.Atoi(strings.TrimSpace(w.inputs[2].Value()))
-	iface := &wg.Interface{
-		Name:       strings.TrimSpace(w.inputs[0].Value()),
-		Address:    strings.TrimSpace(w.inputs[1].Value()),
-		ListenPort: port,
-		PrivateKey: w.privateKey,
-		DNS:        strings.TrimSpace(w.inputs[3].Value()),
-		Peers:      w.peers,
-	}
-
+	// Build the interface to marshal for preview. Each step refused to
+	// continue on errors in its own fields, but warnings and cross-peer
+	// problems are only visible here.
+	iface, diags := w.build()
 	// Public key info
 	if w.publicKey != "" {
 		b.WriteString("  " + labelStyle.Render("Public key:") + valueStyle.Render(w.publicKey))
@@ -756,14 +919,19 @@ func (w wizardModel) viewReview(width int) string {
 		b.WriteString("\n\n")
 	}
 
-	// Config preview in a box
-	config := wg.MarshalConfig(iface)
+	// Config preview in a box, annotated with any diagnostics
+	config := annotateConfig(wg.MarshalConfig(iface), diags)
 	configBox := boxStyle.Render(config)
 	b.WriteString(configBox)
 	b.WriteString("\n\n")
 
+	if w.err != nil {
+		b.WriteString("  " + wrapError(w.err, width))
+		b.WriteString("\n\n")
+	}
+
 	// File path
-	b.WriteString("  " + labelStyle.Render("Will save to:") + valueStyle.Render(filepath.Join(configDir, iface.Name+".conf")))
+	b.WriteString("  " + labelStyle.Render("Will save to:") + valueStyle.Render(displayDir(iface.Path())))
 	b.WriteString("\n\n")
 
 	// Help
@@ -0,0 +1,334 @@
+package wg
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// StripConfig returns the parts of iface that wg(8) understands, like
+// `wg-quick strip`: the private key, listen port and fwmark, and the
+// peers without their metadata. wg-quick's own keys (Address, DNS, MTU,
+// Table, hooks, SaveConfig) are left out.
+func StripConfig(iface *Interface) string {
+	var b strings.Builder
+	b.WriteString("[Interface]\n")
+	writeFields(&b, []field{
+		{"PrivateKey", optString(iface.PrivateKey)},
+		{"ListenPort", optInt(iface.ListenPort)},
+		{"FwMark", optFwMark(iface.FwMark)},
+	}, nil, "\n")
+	for i := range iface.Peers {
+		b.WriteString("\n[Peer]\n")
+		writeFields(&b, peerFields(&iface.Peers[i]), metaSkip, "\n")
+	}
+	return b.String()
+}
+
+// metaSkip leaves the metadata comments out of peerFields.
+var metaSkip = func() map[string]bool {
+	skip := make(map[string]bool, len(peerMetaKeys))
+	for _, k := range peerMetaKeys {
+		skip[metaKeyPrefix+k] = true
+	}
+	return skip
+}()
+
+// ApplyReport says how a config change reached a running interface.
+type ApplyReport struct {
+	Up      bool     // the interface was running; nothing is applied otherwise
+	Live    []string // what changed on the running interface, e.g. "peers", "Address"
+	Restart []string // config keys that only take effect when it is restarted
+}
+
+// String summarizes the report, e.g. "applied live: peers, MTU; restart
+// needed for: PostUp". It is empty when nothing was changed.
+func (r ApplyReport) String() string {
+	var parts []string
+	if len(r.Live) > 0 {
+		parts = append(parts, "applied live: "+strings.Join(r.Live, ", "))
+	}
+	if len(r.Restart) > 0 {
+		parts = append(parts, "restart needed for: "+strings.Join(r.Restart, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// restartKeys are the wg-quick keys that only take effect on up or down.
+var restartKeys = []string{"PreUp", "PostUp", "PreDown", "PostDown", "SaveConfig"}
+
+// Apply brings a running interface from the config old to updated
+// without restarting it, the way `wg-quick up` would have set it up:
+// peers, keys, listen port and fwmark through `wg syncconf`, then
+// addresses and MTU with ip(8), routes for changed AllowedIPs, and DNS
+// through resolvconf. What can't be changed live, such as the routing
+// table, hooks, or a default route (which wg-quick sets up with policy
+// routing), is listed in the report's Restart. Nothing is run when the
+// interface is down.
+//
+// On failure the report holds what was applied before the failing step.
+func (m *Manager) Apply(old, updated *Interface) (ApplyReport, error) {
+	var r ApplyReport
+	name := interfaceName(updated.Target())
+	defer m.lock(name)()
+
+	up, err := m.IsUp(updated.Target())
+	if err != nil {
+		return r, fmt.Errorf("checking interface state: %w", err)
+	}
+	if !up {
+		return r, nil
+	}
+	r.Up = true
+
+	oldVals := fieldValues(interfaceFields(old))
+	newVals := fieldValues(interfaceFields(updated))
+	changed := func(key string) bool { return !slices.Equal(oldVals[key], newVals[key]) }
+
+	if stripped := StripConfig(updated); stripped != StripConfig(old) {
+		_, err := m.run(Command{Name: "wg", Args: []string{"syncconf", name, "/dev/stdin"}, Stdin: []byte(stripped), Privileged: true})
+		if err != nil {
+			return r, fmt.Errorf("applying peers: %w", err)
+		}
+		for _, key := range []string{"PrivateKey", "ListenPort", "FwMark"} {
+			if changed(key) {
+				r.Live = append(r.Live, key)
+			}
+		}
+		if !slices.EqualFunc(old.Peers, updated.Peers, samePeer) {
+			r.Live = append(r.Live, "peers")
+		}
+	}
+
+	if changed("Address") {
+		if err := m.applyAddresses(name, old.Address, updated.Address); err != nil {
+			return r, fmt.Errorf("applying Address: %w", err)
+		}
+		r.Live = append(r.Live, "Address")
+	}
+
+	if changed("MTU") {
+		if updated.MTU == 0 {
+			// wg-quick derives it from the default route's device on up.
+			r.Restart = append(r.Restart, "MTU")
+		} else {
+			if _, err := m.runPrivileged(nil, "ip", "link", "set", "mtu", strconv.Itoa(updated.MTU), "up", "dev", name); err != nil {
+				return r, fmt.Errorf("applying MTU: %w", err)
+			}
+			r.Live = append(r.Live, "MTU")
+		}
+	}
+
+	if changed("Table") {
+		r.Restart = append(r.Restart, "Table")
+	} else if updated.Table != "off" {
+		routed, restart, err := m.applyRoutes(name, old, updated)
+		if routed {
+			r.Live = append(r.Live, "routes")
+		}
+		if restart {
+			r.Restart = append(r.Restart, "default route")
+		}
+		if err != nil {
+			return r, fmt.Errorf("applying routes: %w", err)
+		}
+	}
+
+	if changed("DNS") {
+		if err := m.applyDNS(name, updated); err != nil {
+			return r, fmt.Errorf("applying DNS: %w", err)
+		}
+		r.Live = append(r.Live, "DNS")
+	}
+
+	for _, key := range restartKeys {
+		if changed(key) {
+			r.Restart = append(r.Restart, key)
+		}
+	}
+	return r, nil
+}
+
+// samePeer reports whether two peers have the same wg settings, ignoring
+// metadata.
+func samePeer(a, b Peer) bool {
+	return slices.EqualFunc(peerFields(&a), peerFields(&b), func(x, y field) bool {
+		return metaSkip[x.key] || slices.Equal(x.values, y.values)
+	})
+}
+
+// applyAddresses adds and removes interface addresses like wg-quick's
+// add_addr.
+func (m *Manager) applyAddresses(name string, old, updated []netip.Prefix) error {
+	for _, p := range old {
+		if !slices.Contains(updated, p) {
+			if _, err := m.runPrivileged(nil, "ip", ipFamily(p), "address", "del", p.String(), "dev", name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, p := range updated {
+		if !slices.Contains(old, p) {
+			if _, err := m.runPrivileged(nil, "ip", ipFamily(p), "address", "add", p.String(), "dev", name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyRoutes adds routes for AllowedIPs that are new and removes those
+// for AllowedIPs that are gone, like wg-quick's add_route. With the
+// automatic table, prefixes inside an interface address's subnet need no
+// route, default routes are left for a restart, which sets up wg-quick's
+// policy routing (restart reports that one changed), and like wg-quick a
+// prefix already covered by a route on the device gets none of its own;
+// so a route is only deleted if it is there. New routes are added
+// narrowest first, in wg-quick's order.
+func (m *Manager) applyRoutes(name string, oldIface, updatedIface *Interface) (routed, restart bool, err error) {
+	table := updatedIface.Table
+	auto := table == "" || table == "auto"
+	old, updated := allowedIPs(oldIface), allowedIPs(updatedIface)
+	route := func(action string, p netip.Prefix) error {
+		args := []string{ipFamily(p), "route", action, p.String(), "dev", name}
+		if !auto {
+			args = append(args, "table", table)
+		}
+		_, err := m.runPrivileged(nil, "ip", args...)
+		return err
+	}
+	for _, p := range old {
+		if slices.Contains(updated, p) || auto && onLink(p, oldIface.Address) {
+			continue
+		}
+		if auto && p.Bits() == 0 {
+			restart = true
+			continue
+		}
+		if auto {
+			exists, err := m.hasRoute(name, "exact", p)
+			if err != nil {
+				return routed, restart, err
+			}
+			if !exists {
+				continue
+			}
+		}
+		if err := route("del", p); err != nil {
+			return routed, restart, err
+		}
+		routed = true
+	}
+	updated = slices.Clone(updated)
+	slices.SortStableFunc(updated, func(a, b netip.Prefix) int { return b.Bits() - a.Bits() })
+	for _, p := range updated {
+		if slices.Contains(old, p) || auto && onLink(p, updatedIface.Address) {
+			continue
+		}
+		if auto && p.Bits() == 0 {
+			restart = true
+			continue
+		}
+		if auto {
+			covered, err := m.hasRoute(name, "match", p)
+			if err != nil {
+				return routed, restart, err
+			}
+			if covered {
+				continue
+			}
+		}
+		if err := route("add", p); err != nil {
+			return routed, restart, err
+		}
+		routed = true
+	}
+	return routed, restart, nil
+}
+
+// hasRoute reports whether `ip route show dev name <selector> p` lists a
+// route: with "match", one that covers p; with "exact", p itself.
+func (m *Manager) hasRoute(name, selector string, p netip.Prefix) (bool, error) {
+	out, err := m.runPrivileged(nil, "ip", ipFamily(p), "route", "show", "dev", name, selector, p.String())
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// allowedIPs returns the AllowedIPs of all peers of iface, masked and
+// without duplicates, in the order wg-quick would route them.
+func allowedIPs(iface *Interface) []netip.Prefix {
+	var all []netip.Prefix
+	for _, p := range iface.Peers {
+		for _, prefix := range p.AllowedIPs {
+			if prefix = prefix.Masked(); !slices.Contains(all, prefix) {
+				all = append(all, prefix)
+			}
+		}
+	}
+	return all
+}
+
+// onLink reports whether p lies inside the subnet of one of addrs, which
+// the kernel already routes to the interface.
+func onLink(p netip.Prefix, addrs []netip.Prefix) bool {
+	for _, a := range addrs {
+		if a.Bits() <= p.Bits() && a.Masked().Contains(p.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipFamily returns the ip(8) flag for the address family of p.
+func ipFamily(p netip.Prefix) string {
+	if p.Addr().Is4() {
+		return "-4"
+	}
+	return "-6"
+}
+
+// resolvconfOrder is read for the resolvconf interface prefix, as
+// wg-quick does.
+var resolvconfOrder = "/etc/resolvconf/interface-order"
+
+var resolvconfPrefixRE = regexp.MustCompile(`^([A-Za-z0-9-]+)\*$`)
+
+// applyDNS replaces the interface's resolvconf entry like wg-quick's
+// set_dns, or deletes it when the config has no DNS left.
+func (m *Manager) applyDNS(name string, iface *Interface) error {
+	record := resolvconfPrefix() + name
+	if len(iface.DNS) == 0 && len(iface.DNSSearch) == 0 {
+		_, err := m.runPrivileged(nil, "resolvconf", "-d", record, "-f")
+		return err
+	}
+	var b strings.Builder
+	for _, addr := range iface.DNS {
+		fmt.Fprintf(&b, "nameserver %s\n", addr)
+	}
+	if len(iface.DNSSearch) > 0 {
+		fmt.Fprintf(&b, "search %s\n", strings.Join(iface.DNSSearch, " "))
+	}
+	_, err := m.runPrivileged([]byte(b.String()), "resolvconf", "-a", record, "-m", "0", "-x")
+	return err
+}
+
+// resolvconfPrefix returns the prefix resolvconf gives tunnel entries
+// ("tun." on Debian's resolvconf), or "" when it uses none.
+func resolvconfPrefix() string {
+	data, err := os.ReadFile(resolvconfOrder)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if m := resolvconfPrefixRE.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			return m[1] + "."
+		}
+	}
+	return ""
+}
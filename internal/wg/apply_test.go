@@ -0,0 +1,192 @@
+package wg
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const applyConfig = `[Interface]
+PrivateKey = ` + testPrivKey + `
+Address = 10.0.0.1/24
+ListenPort = 51820
+DNS = 10.0.0.53
+MTU = 1420
+PostUp = iptables -A FORWARD -i %i -j ACCEPT
+
+[Peer]
+# Name = laptop
+PublicKey = ` + testPubKey1 + `
+AllowedIPs = 10.0.0.2/32
+`
+
+func TestStripConfig(t *testing.T) {
+	iface, err := ParseConfigFromString(applyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[Interface]\nPrivateKey = " + testPrivKey + "\nListenPort = 51820\n\n[Peer]\nPublicKey = " + testPubKey1 + "\nAllowedIPs = 10.0.0.2/32\n"
+	if got := StripConfig(iface); got != want {
+		t.Errorf("StripConfig() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	// Tests must not depend on the host's resolvconf setup.
+	defer func(orig string) { resolvconfOrder = orig }(resolvconfOrder)
+	resolvconfOrder = filepath.Join(t.TempDir(), "interface-order")
+
+	tests := []struct {
+		name        string
+		setup       func(*Interface) // applied to the old and the updated config
+		edit        func(*Interface)
+		script      []fakeCall
+		wantLive    []string
+		wantRestart []string
+		wantErr     string
+	}{
+		{
+			name:   "interface down",
+			edit:   func(i *Interface) { i.MTU = 1380 },
+			script: []fakeCall{{cmd: "wg show wg0", exit: 1}},
+		},
+		{
+			name:   "metadata only",
+			edit:   func(i *Interface) { i.Peers[0].Owner = "alice" },
+			script: []fakeCall{{cmd: "wg show wg0"}},
+		},
+		{
+			name: "live changes",
+			edit: func(i *Interface) {
+				i.Address = mustPrefixes("10.0.0.1/24, fd00::1/64")
+				i.MTU = 1380
+				i.DNS = nil
+				i.Peers = append(i.Peers, Peer{PublicKey: testPubKey2, AllowedIPs: mustPrefixes("10.0.0.3/32, 192.168.50.0/24")})
+			},
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg syncconf wg0 /dev/stdin"},
+				{cmd: "ip -6 address add fd00::1/64 dev wg0"},
+				{cmd: "ip link set mtu 1380 up dev wg0"},
+				{cmd: "ip -4 route show dev wg0 match 192.168.50.0/24"},
+				{cmd: "ip -4 route add 192.168.50.0/24 dev wg0"},
+				{cmd: "resolvconf -d wg0 -f"},
+			},
+			wantLive: []string{"peers", "Address", "MTU", "routes", "DNS"},
+		},
+		{
+			name: "restart needed",
+			edit: func(i *Interface) {
+				i.PostUp = nil
+				i.Peers[0].AllowedIPs = mustPrefixes("0.0.0.0/0")
+			},
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg syncconf wg0 /dev/stdin"},
+			},
+			wantLive:    []string{"peers"},
+			wantRestart: []string{"default route", "PostUp"},
+		},
+		{
+			name:  "covered prefixes",
+			setup: withSubnetPeer,
+			edit: func(i *Interface) {
+				// 192.168.50.7/32 never got a route of its own: the /24 was
+				// routed when it was added live.
+				i.Peers[0].AllowedIPs = mustPrefixes("10.0.0.2/32, 192.168.50.9/32")
+			},
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg syncconf wg0 /dev/stdin"},
+				{cmd: "ip -4 route show dev wg0 exact 192.168.50.7/32"},
+				{cmd: "ip -4 route show dev wg0 match 192.168.50.9/32", stdout: "192.168.50.0/24 scope link\n"},
+			},
+			wantLive: []string{"peers"},
+		},
+		{
+			name:  "routed prefix removed",
+			setup: withSubnetPeer,
+			edit:  func(i *Interface) { i.Peers[0].AllowedIPs = mustPrefixes("10.0.0.2/32") },
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg syncconf wg0 /dev/stdin"},
+				{cmd: "ip -4 route show dev wg0 exact 192.168.50.7/32", stdout: "192.168.50.7 scope link\n"},
+				{cmd: "ip -4 route del 192.168.50.7/32 dev wg0"},
+			},
+			wantLive: []string{"peers", "routes"},
+		},
+		{
+			name: "table change",
+			edit: func(i *Interface) {
+				i.Table = "1234"
+				i.ListenPort = 51821
+			},
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg syncconf wg0 /dev/stdin"},
+			},
+			wantLive:    []string{"ListenPort"},
+			wantRestart: []string{"Table"},
+		},
+		{
+			name: "failing step",
+			edit: func(i *Interface) { i.ListenPort = 51821; i.MTU = 1380 },
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg syncconf wg0 /dev/stdin"},
+				{cmd: "ip link set mtu 1380 up dev wg0", exit: 2, stderr: "boom\n"},
+			},
+			wantLive: []string{"ListenPort"},
+			wantErr:  "applying MTU: ip link set mtu 1380 up dev wg0: exit status 2: boom",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			old, err := ParseConfigFromString(applyConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			old.Name, old.Dir = "wg0", t.TempDir()
+			updated, _ := ParseConfigFromString(applyConfig)
+			updated.Name, updated.Dir = old.Name, old.Dir
+			if tc.setup != nil {
+				tc.setup(old)
+				tc.setup(updated)
+			}
+			tc.edit(updated)
+
+			m := newTestManager(t, tc.script...)
+			r, err := m.Apply(old, updated)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Fatalf("Apply() returned error: %v", err)
+			case tc.wantErr != "" && (err == nil || err.Error() != tc.wantErr):
+				t.Fatalf("Apply() error = %v, want %q", err, tc.wantErr)
+			}
+			if !slices.Equal(r.Live, tc.wantLive) || !slices.Equal(r.Restart, tc.wantRestart) {
+				t.Errorf("Apply() = live %q, restart %q; want %q, %q", r.Live, r.Restart, tc.wantLive, tc.wantRestart)
+			}
+			if wantUp := tc.script[0].exit == 0; r.Up != wantUp {
+				t.Errorf("Apply() Up = %v, want %v", r.Up, wantUp)
+			}
+		})
+	}
+}
+
+// withSubnetPeer gives the first peer of i a host in 192.168.50.0/24 and
+// adds a peer routing that whole subnet.
+func withSubnetPeer(i *Interface) {
+	i.Peers[0].AllowedIPs = mustPrefixes("10.0.0.2/32, 192.168.50.7/32")
+	i.Peers = append(i.Peers, Peer{PublicKey: testPubKey2, AllowedIPs: mustPrefixes("10.0.0.3/32, 192.168.50.0/24")})
+}
+
+func TestApplyReportString(t *testing.T) {
+	r := ApplyReport{Up: true, Live: []string{"peers", "MTU"}, Restart: []string{"PostUp"}}
+	if got, want := r.String(), "applied live: peers, MTU; restart needed for: PostUp"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := (ApplyReport{Up: true}).String(); got != "" {
+		t.Errorf("empty report String() = %q", got)
+	}
+}
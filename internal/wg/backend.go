@@ -0,0 +1,126 @@
+package wg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend reads live WireGuard device state.
+type Backend interface {
+	// Status returns the runtime status of the named interface.
+	Status(name string) (*InterfaceStatus, error)
+	// Interfaces returns the names of all active WireGuard interfaces,
+	// sorted. It returns an empty slice when none are active.
+	Interfaces() ([]string, error)
+	// AllStatus returns the status of every active interface, sorted by
+	// name.
+	AllStatus() ([]*InterfaceStatus, error)
+}
+
+// errBackendUnavailable marks errors meaning a backend can't be used at all
+// on this system (no kernel support, missing privileges), as opposed to
+// errors about a particular interface.
+var errBackendUnavailable = errors.New("backend unavailable")
+
+// cliBackend reads device state by running `wg show ... dump` through a
+// Manager's runner and parsing its tab-separated output.
+type cliBackend struct {
+	m *Manager
+}
+
+// Status runs `wg show <name> dump`.
+func (c cliBackend) Status(name string) (*InterfaceStatus, error) {
+	out, err := c.m.runWg("show", name, "dump")
+	if err != nil {
+		return nil, fmt.Errorf("getting status for %s: %w", name, err)
+	}
+	return parseWgDump(name, out)
+}
+
+// AllStatus runs `wg show all dump`.
+func (c cliBackend) AllStatus() ([]*InterfaceStatus, error) {
+	out, err := c.m.runWg("show", "all", "dump")
+	if err != nil {
+		return nil, fmt.Errorf("getting status: %w", err)
+	}
+	all, err := parseWgDumpAll(out)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all, nil
+}
+
+// Interfaces runs `wg show interfaces` and splits the output on whitespace.
+func (c cliBackend) Interfaces() ([]string, error) {
+	out, err := c.m.runWg("show", "interfaces")
+	if err != nil {
+		return nil, fmt.Errorf("listing interfaces: %w", err)
+	}
+
+	names := strings.Fields(out)
+	sort.Strings(names)
+	return names, nil
+}
+
+// fallbackBackend uses primary until it reports errBackendUnavailable, then
+// switches to fallback for the rest of the process lifetime.
+type fallbackBackend struct {
+	primary  Backend
+	fallback Backend
+
+	mu          sync.Mutex
+	useFallback bool
+}
+
+// active returns the backend to try.
+func (f *fallbackBackend) active() Backend {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.useFallback {
+		return f.fallback
+	}
+	return f.primary
+}
+
+// failed records that the primary backend is unusable if err says so, and
+// reports whether the call should be retried on the fallback.
+func (f *fallbackBackend) failed(b Backend, err error) bool {
+	if b != f.primary || !errors.Is(err, errBackendUnavailable) {
+		return false
+	}
+	f.mu.Lock()
+	f.useFallback = true
+	f.mu.Unlock()
+	return true
+}
+
+func (f *fallbackBackend) Status(name string) (*InterfaceStatus, error) {
+	b := f.active()
+	st, err := b.Status(name)
+	if err != nil && f.failed(b, err) {
+		return f.fallback.Status(name)
+	}
+	return st, err
+}
+
+func (f *fallbackBackend) Interfaces() ([]string, error) {
+	b := f.active()
+	names, err := b.Interfaces()
+	if err != nil && f.failed(b, err) {
+		return f.fallback.Interfaces()
+	}
+	return names, err
+}
+
+func (f *fallbackBackend) AllStatus() ([]*InterfaceStatus, error) {
+	b := f.active()
+	all, err := b.AllStatus()
+	if err != nil && f.failed(b, err) {
+		return f.fallback.AllStatus()
+	}
+	return all, err
+}
@@ -0,0 +1,181 @@
+package wg
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+)
+
+// BundleQRSize is the width and height in pixels of the QR codes in a
+// client bundle.
+const BundleQRSize = 512
+
+// ManifestName is the name of the manifest in a client bundle.
+const ManifestName = "manifest.txt"
+
+// BundleFile is one file of a client bundle.
+type BundleFile struct {
+	Name string
+	Data []byte
+}
+
+// ClientBundle builds the files for onboarding the clients of server: for
+// every peer with a stored client config, <client>.conf and <client>.png
+// (a QR code for the mobile apps), plus a manifest listing every peer's
+// name, addresses and public key. Stored clients that are no longer peers
+// of server are left out.
+//
+// Each config is rebuilt from the current server and peer (see
+// bundleClient), so edits to the server's key, the peer's preshared key or
+// its AllowedIPs since the client was stored are picked up. endpoint fills
+// in clients saved without the server's address. A client that can't be
+// exported is listed in the manifest with the reason; it is an error only
+// when no client can be.
+func ClientBundle(server *Interface, clients []*Interface, endpoint string) ([]BundleFile, error) {
+	byKey := make(map[string]*Interface, len(clients))
+	for _, c := range clients {
+		pub, err := DerivePublicKey(c.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("client %s: %w", c.Name, err)
+		}
+		byKey[pub] = c
+	}
+
+	var files []BundleFile
+	var skipped []error
+	var manifest bytes.Buffer
+	tw := tabwriter.NewWriter(&manifest, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "NAME\tADDRESS\tPUBLIC KEY\tFILES\n")
+	for i, p := range server.Peers {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("peer %d", i+1)
+		}
+		stored, ok := byKey[p.PublicKey]
+		if !ok {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t(private key not stored)\n", name, FormatPrefixes(p.AllowedIPs), p.PublicKey)
+			continue
+		}
+
+		c, err := bundleClient(server, p, stored, endpoint)
+		var png []byte
+		if err == nil {
+			png, err = GenerateQRPNG(c, BundleQRSize)
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("client %s: %w", stored.Name, err))
+			fmt.Fprintf(tw, "%s\t%s\t%s\t(not exported: %v)\n", stored.Name, FormatPrefixes(p.AllowedIPs), p.PublicKey, err)
+			continue
+		}
+		files = append(files,
+			BundleFile{Name: c.Name + ".conf", Data: []byte(MarshalConfig(c))},
+			BundleFile{Name: c.Name + ".png", Data: png},
+		)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s.conf, %s.png\n", c.Name, FormatPrefixes(c.Address), p.PublicKey, c.Name, c.Name)
+	}
+	if len(files) == 0 {
+		if len(skipped) > 0 {
+			return nil, fmt.Errorf("no client can be exported: %w", errors.Join(skipped...))
+		}
+		return nil, errors.New("no peer has a stored client config: only clients added from here can be exported")
+	}
+	if err := tw.Flush(); err != nil {
+		return nil, err
+	}
+
+	header := fmt.Sprintf("# Clients of WireGuard profile %s\n\n", server.Name)
+	files = append(files, BundleFile{Name: ManifestName, Data: append([]byte(header), manifest.Bytes()...)})
+	return files, nil
+}
+
+// errNoEndpoint is why a client without the server's address is skipped.
+var errNoEndpoint = errors.New("no server endpoint; give one")
+
+// bundleClient rebuilds the config of the client stored as stored, whose
+// peer on server is p: the server's key, the preshared key and the
+// client's addresses come from server and p as they are now (see
+// ClientConfig), while what only the client config records (DNS, MTU,
+// the server endpoint, the routes and keepalive it asked for) comes from
+// stored. endpoint is used when stored has none.
+func bundleClient(server *Interface, p Peer, stored *Interface, endpoint string) (*Interface, error) {
+	c, err := ClientConfig(server, p, stored.Name, stored.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	c.DNS, c.DNSSearch, c.MTU = stored.DNS, stored.DNSSearch, stored.MTU
+	sp := &c.Peers[0]
+	sp.Endpoint = endpoint
+	if len(stored.Peers) > 0 {
+		old := stored.Peers[0]
+		if old.Endpoint != "" {
+			sp.Endpoint = old.Endpoint
+		}
+		if len(old.AllowedIPs) > 0 {
+			sp.AllowedIPs = old.AllowedIPs
+		}
+		sp.PersistentKeepalive = old.PersistentKeepalive
+	}
+	if sp.Endpoint == "" {
+		return nil, errNoEndpoint
+	}
+	return c, nil
+}
+
+// WriteBundleZip writes files to w as a zip archive.
+func WriteBundleZip(w io.Writer, files []BundleFile) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// WriteBundle saves files to path: as a zip archive if path ends in
+// ".zip", otherwise into the directory path, which is created if needed.
+// Files are mode 0600, since the configs contain private keys.
+func WriteBundle(path string, files []BundleFile) error {
+	if filepath.Ext(path) == ".zip" {
+		return writeUserFile(path, func(w io.Writer) error {
+			return WriteBundleZip(w, files)
+		})
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return err
+	}
+	for _, f := range files {
+		err := writeUserFile(filepath.Join(path, f.Name), func(w io.Writer) error {
+			_, err := w.Write(f.Data)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeUserFile creates or truncates path with mode 0600 and fills it
+// with write.
+func writeUserFile(path string, write func(io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
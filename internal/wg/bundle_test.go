@@ -0,0 +1,210 @@
+package wg
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newBundleServer returns a server in dir with two clients added and
+// stored, plus one peer whose key was not generated here.
+func newBundleServer(t *testing.T, m *Manager, dir string) *Interface {
+	t.Helper()
+	server, err := ParseConfigFromString("[Interface]\nPrivateKey = " + testPrivKey + "\nAddress = 10.0.0.1/24\nListenPort = 51820\n\n[Peer]\nPublicKey = " + testPubKey2 + "\nAllowedIPs = 10.0.0.2/32\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Name, server.Dir = "wg0", dir
+
+	for _, name := range []string{"laptop", "phone"} {
+		var client *Interface
+		server, client, err = AddClient(server, ClientOptions{Name: name, Endpoint: "vpn.example.com:51820"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SaveClient(server, client); err != nil {
+			t.Fatalf("SaveClient(%s) returned error: %v", name, err)
+		}
+	}
+	return server
+}
+
+func TestClients(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+
+	if clients, err := m.Clients(&Interface{Name: "wg0", Dir: dir}); err != nil || clients != nil {
+		t.Fatalf("Clients() without any = %v, %v; want none", clients, err)
+	}
+
+	server := newBundleServer(t, m, dir)
+	clients, err := m.Clients(server)
+	if err != nil {
+		t.Fatalf("Clients() returned error: %v", err)
+	}
+	if len(clients) != 2 || clients[0].Name != "laptop" || clients[1].Name != "phone" {
+		t.Fatalf("Clients() = %v, want laptop and phone", clients)
+	}
+	if got := FormatPrefixes(clients[1].Address); got != "10.0.0.4/32" {
+		t.Errorf("phone Address = %s, want 10.0.0.4/32", got)
+	}
+	path := filepath.Join(dir, clientsDir, "wg0", "laptop.conf")
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("client file mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+
+	// Stored clients are not profiles.
+	if err := m.SaveConfig(dir, server); err != nil {
+		t.Fatal(err)
+	}
+	if configs, err := m.LoadConfigsFromDir(dir); err != nil || len(configs) != 1 {
+		t.Errorf("LoadConfigsFromDir() = %d configs, %v; want 1", len(configs), err)
+	}
+}
+
+func TestClientBundle(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+	server := newBundleServer(t, m, dir)
+	clients, err := m.Clients(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ClientBundle(server, clients, "")
+	if err != nil {
+		t.Fatalf("ClientBundle() returned error: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, " "); got != "laptop.conf laptop.png phone.conf phone.png manifest.txt" {
+		t.Fatalf("bundle files = %s", got)
+	}
+	if !bytes.HasPrefix(files[1].Data, []byte("\x89PNG")) {
+		t.Errorf("laptop.png is not a PNG")
+	}
+	manifest := string(files[4].Data)
+	for _, want := range []string{"peer 1", testPubKey2, "(private key not stored)", "laptop", "10.0.0.3/32", server.Peers[1].PublicKey, "phone.conf, phone.png"} {
+		if !strings.Contains(manifest, want) {
+			t.Errorf("manifest missing %q:\n%s", want, manifest)
+		}
+	}
+
+	zipPath := filepath.Join(dir, "clients.zip")
+	if err := WriteBundle(zipPath, files); err != nil {
+		t.Fatalf("WriteBundle(zip) returned error: %v", err)
+	}
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("reading bundle zip: %v", err)
+	}
+	defer zr.Close()
+	if len(zr.File) != len(files) || zr.File[0].Name != "laptop.conf" {
+		t.Errorf("zip holds %d files, first %q", len(zr.File), zr.File[0].Name)
+	}
+
+	outDir := filepath.Join(dir, "out")
+	if err := WriteBundle(outDir, files); err != nil {
+		t.Fatalf("WriteBundle(dir) returned error: %v", err)
+	}
+	conf, err := os.ReadFile(filepath.Join(outDir, "phone.conf"))
+	if err != nil || !strings.Contains(string(conf), "Endpoint = vpn.example.com:51820") {
+		t.Errorf("phone.conf = %q, %v", conf, err)
+	}
+}
+
+func TestClientBundleErrors(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+	server := newBundleServer(t, m, dir)
+	clients, err := m.Clients(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ClientBundle(server, nil, ""); err == nil {
+		t.Error("ClientBundle() without stored clients succeeded")
+	}
+
+	// A client saved without an endpoint needs one given; without it, it
+	// is listed but the others are still exported.
+	clients[0].Peers[0].Endpoint = ""
+	files, err := ClientBundle(server, clients, "")
+	if err != nil {
+		t.Fatalf("ClientBundle() with one client lacking an endpoint returned error: %v", err)
+	}
+	if len(files) != 3 || files[0].Name != "phone.conf" || !strings.Contains(string(files[2].Data), "(not exported: no server endpoint; give one)") {
+		t.Errorf("bundle = %d files, manifest:\n%s", len(files), files[len(files)-1].Data)
+	}
+	files, err = ClientBundle(server, clients, "203.0.113.1:51820")
+	if err != nil {
+		t.Fatalf("ClientBundle() with endpoint returned error: %v", err)
+	}
+	if !strings.Contains(string(files[0].Data), "Endpoint = 203.0.113.1:51820") {
+		t.Errorf("laptop.conf = %s, want the given endpoint", files[0].Data)
+	}
+	if clients[0].Peers[0].Endpoint != "" {
+		t.Error("ClientBundle() modified the stored client")
+	}
+
+	// A peer routed elsewhere has no client address and is skipped; with
+	// the other one unexportable too, the bundle fails.
+	routed := *server
+	routed.Peers = slices.Clone(server.Peers)
+	routed.Peers[2].AllowedIPs = mustPrefixes("0.0.0.0/0, ::/0")
+	files, err = ClientBundle(&routed, clients, "")
+	if err == nil || !strings.Contains(err.Error(), "client phone: "+ErrNoClientAddress.Error()) || !strings.Contains(err.Error(), "client laptop") {
+		t.Errorf("ClientBundle() with no exportable client = %d files, error %v", len(files), err)
+	}
+}
+
+func TestClientBundleFollowsServerEdits(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+	server := newBundleServer(t, m, dir)
+	clients, err := m.Clients(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate the server's key and the laptop's preshared key and move the
+	// laptop after the clients were stored.
+	priv, wantPub, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	psk, err := GeneratePresharedKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := *server
+	edited.PrivateKey = priv
+	edited.Peers = slices.Clone(server.Peers)
+	edited.Peers[1].PresharedKey = psk
+	edited.Peers[1].AllowedIPs = mustPrefixes("10.0.0.13/32, 192.168.7.0/24")
+
+	files, err := ClientBundle(&edited, clients, "")
+	if err != nil {
+		t.Fatalf("ClientBundle() returned error: %v", err)
+	}
+	laptop, err := ParseConfigFromString(string(files[0].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := laptop.Peers[0]
+	if got := FormatPrefixes(laptop.Address); got != "10.0.0.13/32" {
+		t.Errorf("laptop Address = %s, want the peer's current host address", got)
+	}
+	if sp.PublicKey != wantPub || sp.PresharedKey != psk {
+		t.Errorf("laptop server peer = key %s, psk %s; want the current ones", sp.PublicKey, sp.PresharedKey)
+	}
+	if sp.Endpoint != "vpn.example.com:51820" || laptop.PrivateKey != clients[0].PrivateKey {
+		t.Errorf("laptop lost its stored endpoint or key: %+v", laptop)
+	}
+}
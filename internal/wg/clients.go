@@ -0,0 +1,93 @@
+package wg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// clientsDir is where the configs of clients whose keys were generated
+// here are kept, so they can be exported again: the client of server
+// dir/name.conf lives in dir/.clients/name/<client>.conf. Like the
+// history, it is invisible to wg-quick and LoadConfigsFromDir.
+const clientsDir = ".clients"
+
+// clientDirFor returns the client config directory of profile name in dir.
+func clientDirFor(dir, name string) string {
+	return filepath.Join(dir, clientsDir, name)
+}
+
+// SaveClient keeps the config of a client of server, including its
+// private key, replacing any stored client of the same name.
+func (m *Manager) SaveClient(server, client *Interface) error {
+	if !ValidInterfaceName(client.Name) {
+		return fmt.Errorf("invalid client name %q", client.Name)
+	}
+	cdir := clientDirFor(server.Dir, server.Name)
+	if err := m.mkdirAll(cdir); err != nil {
+		return fmt.Errorf("saving client %s: %w", client.Name, err)
+	}
+	path := filepath.Join(cdir, client.Name+".conf")
+	if err := m.writeFile(path, []byte(MarshalConfig(client))); err != nil {
+		return fmt.Errorf("saving client %s: %w", client.Name, err)
+	}
+	return nil
+}
+
+// Clients returns the stored client configs of server, sorted by name.
+// Clients may have been removed from the server since; match them to its
+// peers by public key.
+func (m *Manager) Clients(server *Interface) ([]*Interface, error) {
+	cdir := clientDirFor(server.Dir, server.Name)
+	names, err := m.listDir(cdir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing clients of %s: %w", server.Name, err)
+	}
+	sort.Strings(names)
+
+	var clients []*Interface
+	for _, n := range names {
+		if !strings.HasSuffix(n, ".conf") {
+			continue
+		}
+		data, err := m.readFile(filepath.Join(cdir, n))
+		if err != nil {
+			return nil, fmt.Errorf("reading client %s: %w", n, err)
+		}
+		client, err := ParseConfigFromString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parsing client %s: %w", n, err)
+		}
+		client.Name = strings.TrimSuffix(n, ".conf")
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// deleteClients removes the stored configs of the clients behind peers of
+// server, matched by public key, so revoked clients' private keys don't
+// linger. Peers without a stored client are skipped.
+func (m *Manager) deleteClients(server *Interface, peers []Peer) error {
+	clients, err := m.Clients(server)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, c := range clients {
+		pub, err := DerivePublicKey(c.PrivateKey)
+		if err != nil || !slices.ContainsFunc(peers, func(p Peer) bool { return p.PublicKey == pub }) {
+			continue
+		}
+		if err := m.removeFile(filepath.Join(clientDirFor(server.Dir, server.Name), c.Name+".conf")); err != nil {
+			errs = append(errs, fmt.Errorf("deleting client %s: %w", c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
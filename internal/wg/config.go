@@ -1,35 +1,71 @@
 package wg
 
 import (
-	"bufio"
 	"bytes"
-	"context"
+	"errors"
 	"fmt"
 	"io"
-	"os/exec"
+	"io/fs"
+	"net/netip"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// DefaultConfigDir is where wg-quick looks up a config by interface name.
+const DefaultConfigDir = "/etc/wireguard"
+
 // Interface represents a WireGuard interface configuration.
 type Interface struct {
 	Name       string
-	Address    string
+	Dir        string         // profile root the config was loaded from; not part of the file
+	Address    []netip.Prefix // interface addresses, host bits set (e.g. 10.0.0.1/24)
 	ListenPort int
 	PrivateKey string
-	DNS        string
+	DNS        []netip.Addr // resolver addresses
+	DNSSearch  []string     // search domains, from non-IP DNS entries
 	MTU        int
-	Peers      []Peer
+
+	// wg-quick routing and hook settings
+	Table      string // "off", "auto", or a routing table number/name; empty means auto
+	FwMark     uint32 // 0 means off
+	PreUp      []string
+	PostUp     []string
+	PreDown    []string
+	PostDown   []string
+	SaveConfig bool
+
+	Peers []Peer
+
+	// layout records the [Interface] section as it was read from disk so
+	// that MarshalConfig can reproduce comments, unknown keys and ordering.
+	layout layout
+
+	// newline is the file's line ending, "\r\n" or "\n" (the default when
+	// empty), used for lines MarshalConfig adds; noFinalNewline records
+	// that the file's last line had none.
+	newline        string
+	noFinalNewline bool
 }
 
 // Peer represents a WireGuard peer configuration.
 type Peer struct {
 	PublicKey           string
 	PresharedKey        string
-	AllowedIPs          string
+	AllowedIPs          []netip.Prefix
 	Endpoint            string
 	PersistentKeepalive int
+
+	// Metadata, kept as "# Key = value" comments (see peermeta.go).
+	Name    string
+	Owner   string
+	Notes   string
+	Created time.Time
+	Expires time.Time // zero means never
+
+	// layout records the [Peer] section as it was read from disk.
+	layout layout
 }
 
 // sectionKind tracks which section we are currently parsing.
@@ -47,31 +83,73 @@ func ParseConfigFromString(s string) (*Interface, error) {
 }
 
 // ParseConfig reads a WireGuard .conf format from r and returns the parsed Interface.
-// Lines starting with # are treated as comments and skipped.
-// Empty lines are skipped. Parse errors include line number context.
+// Comments, blank lines and keys that are not modelled (PostUp, Table, ...) are
+// kept alongside the typed fields, as are line endings (LF or CRLF) and a
+// missing final newline, so MarshalConfig reproduces the file exactly when
+// nothing was edited. Parse errors include line number context.
 func ParseConfig(r io.Reader) (*Interface, error) {
-	iface := &Interface{}
-	scanner := bufio.NewScanner(r)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	text := string(data)
+
+	iface := &Interface{noFinalNewline: text != "" && !strings.HasSuffix(text, "\n")}
 	section := sectionNone
 	lineNum := 0
 
-	for scanner.Scan() {
+	// current returns the layout that lines are being recorded into: the
+	// interface before the first [Peer] header, the latest peer after it.
+	current := func() *layout {
+		if len(iface.Peers) == 0 {
+			return &iface.layout
+		}
+		return &iface.Peers[len(iface.Peers)-1].layout
+	}
+
+	for text != "" {
 		lineNum++
-		line := strings.TrimSpace(scanner.Text())
+		raw, rest, found := strings.Cut(text, "\n")
+		text = rest
+		var eol string
+		if found {
+			eol = "\n"
+			if strings.HasSuffix(raw, "\r") {
+				raw, eol = raw[:len(raw)-1], "\r\n"
+			}
+			if iface.newline == "" {
+				iface.newline = eol
+			}
+		}
+		line := strings.TrimSpace(stripComment(raw))
+
+		// Peer metadata comments are recorded like keys, so edits to them
+		// are written back in place. Like other comments, those directly
+		// above a [Peer] header move to that peer; values are applied once
+		// every line has found its section.
+		if key, value, ok := parseMetaComment(raw); ok && setPeerMeta(&Peer{}, key, value) {
+			current().lines = append(current().lines, rawLine{key: key, text: raw, eol: eol})
+			continue
+		}
 
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
+		// Keep empty lines and comments verbatim
+		if line == "" {
+			current().lines = append(current().lines, rawLine{text: raw, eol: eol})
 			continue
 		}
 
 		// Check for section headers
 		if line == "[Interface]" {
 			section = sectionInterface
+			current().lines = append(current().lines, rawLine{text: raw, eol: eol, header: true})
 			continue
 		}
 		if line == "[Peer]" {
 			section = sectionPeer
+			// Comments directly above a [Peer] header describe that peer.
+			trivia := current().takeTrailingTrivia()
 			iface.Peers = append(iface.Peers, Peer{})
+			current().lines = append(trivia, rawLine{text: raw, eol: eol, header: true})
 			continue
 		}
 
@@ -85,6 +163,7 @@ func ParseConfig(r io.Reader) (*Interface, error) {
 
 		switch section {
 		case sectionInterface:
+			key = canonicalKey(interfaceKeys, key)
 			if err := setInterfaceField(iface, key, value, lineNum); err != nil {
 				return nil, err
 			}
@@ -92,28 +171,59 @@ func ParseConfig(r io.Reader) (*Interface, error) {
 			if len(iface.Peers) == 0 {
 				return nil, fmt.Errorf("line %d: key %q outside of [Peer] section", lineNum, key)
 			}
+			key = canonicalKey(peerKeys, key)
 			if err := setPeerField(&iface.Peers[len(iface.Peers)-1], key, value, lineNum); err != nil {
 				return nil, err
 			}
 		default:
 			return nil, fmt.Errorf("line %d: key %q outside of any section", lineNum, key)
 		}
+		current().lines = append(current().lines, rawLine{key: key, text: raw, eol: eol, comment: trailingComment(raw)})
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading config: %w", err)
+	iface.layout.orig = fieldValues(interfaceFields(iface))
+	for i := range iface.Peers {
+		for _, ln := range iface.Peers[i].layout.lines {
+			if key, value, ok := parseMetaComment(ln.text); ok && isMetaKey(ln.key) {
+				setPeerMeta(&iface.Peers[i], key, value)
+			}
+		}
+		iface.Peers[i].layout.orig = fieldValues(peerFields(&iface.Peers[i]))
 	}
 
 	return iface, nil
 }
 
+// trailingComment returns the # comment at the end of a key line, with the
+// space before it, so it survives when the key's value is rewritten.
+func trailingComment(line string) string {
+	i := strings.IndexByte(line, '#')
+	if i < 0 {
+		return ""
+	}
+	return line[len(strings.TrimRight(line[:i], " \t")):]
+}
+
+// stripComment removes a trailing # comment from a config line, matching
+// how wg-quick ignores everything after the first #.
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
 // setInterfaceField sets a field on the Interface from a key/value pair.
 func setInterfaceField(iface *Interface, key, value string, lineNum int) error {
 	switch key {
 	case "PrivateKey":
 		iface.PrivateKey = value
 	case "Address":
-		iface.Address = value
+		addrs, err := ParsePrefixes(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid Address %q: %w", lineNum, value, err)
+		}
+		iface.Address = append(iface.Address, addrs...)
 	case "ListenPort":
 		port, err := strconv.Atoi(value)
 		if err != nil {
@@ -121,15 +231,46 @@ func setInterfaceField(iface *Interface, key, value string, lineNum int) error {
 		}
 		iface.ListenPort = port
 	case "DNS":
-		iface.DNS = value
+		servers, search, err := ParseDNS(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid DNS %q: %w", lineNum, value, err)
+		}
+		iface.DNS = append(iface.DNS, servers...)
+		iface.DNSSearch = append(iface.DNSSearch, search...)
 	case "MTU":
 		mtu, err := strconv.Atoi(value)
 		if err != nil {
 			return fmt.Errorf("line %d: invalid MTU %q: %w", lineNum, value, err)
 		}
 		iface.MTU = mtu
+	case "Table":
+		table, err := ParseTable(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid Table %q: %w", lineNum, value, err)
+		}
+		iface.Table = table
+	case "FwMark":
+		mark, err := ParseFwMark(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid FwMark %q: %w", lineNum, value, err)
+		}
+		iface.FwMark = mark
+	case "PreUp":
+		iface.PreUp = append(iface.PreUp, value)
+	case "PostUp":
+		iface.PostUp = append(iface.PostUp, value)
+	case "PreDown":
+		iface.PreDown = append(iface.PreDown, value)
+	case "PostDown":
+		iface.PostDown = append(iface.PostDown, value)
+	case "SaveConfig":
+		save, err := ParseSaveConfig(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid SaveConfig %q: %w", lineNum, value, err)
+		}
+		iface.SaveConfig = save
 	default:
-		// Ignore unknown keys for forward compatibility
+		// Unknown keys are kept in the layout and written back unchanged
 	}
 	return nil
 }
@@ -142,7 +283,11 @@ func setPeerField(peer *Peer, key, value string, lineNum int) error {
 	case "PresharedKey":
 		peer.PresharedKey = value
 	case "AllowedIPs":
-		peer.AllowedIPs = value
+		ips, err := ParsePrefixes(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid AllowedIPs %q: %w", lineNum, value, err)
+		}
+		peer.AllowedIPs = append(peer.AllowedIPs, ips...)
 	case "Endpoint":
 		peer.Endpoint = value
 	case "PersistentKeepalive":
@@ -152,126 +297,301 @@ func setPeerField(peer *Peer, key, value string, lineNum int) error {
 		}
 		peer.PersistentKeepalive = keepalive
 	default:
-		// Ignore unknown keys for forward compatibility
+		// Unknown keys are kept in the layout and written back unchanged
 	}
 	return nil
 }
 
-// MarshalConfig serializes an Interface back to WireGuard .conf format.
-// Optional fields with zero/empty values are omitted.
-// Peer sections are separated by blank lines.
-func MarshalConfig(iface *Interface) string {
-	var b strings.Builder
+// interfaceKeys lists the [Interface] keys modelled by Interface, in the
+// order MarshalConfig writes them.
+var interfaceKeys = []string{
+	"PrivateKey", "Address", "ListenPort", "DNS", "MTU",
+	"Table", "FwMark", "PreUp", "PostUp", "PreDown", "PostDown", "SaveConfig",
+}
 
-	b.WriteString("[Interface]\n")
-	fmt.Fprintf(&b, "PrivateKey = %s\n", iface.PrivateKey)
-	fmt.Fprintf(&b, "Address = %s\n", iface.Address)
-	if iface.ListenPort != 0 {
-		fmt.Fprintf(&b, "ListenPort = %d\n", iface.ListenPort)
+// peerKeys lists the [Peer] keys modelled by Peer, in the order
+// MarshalConfig writes them.
+var peerKeys = []string{"PublicKey", "PresharedKey", "AllowedIPs", "Endpoint", "PersistentKeepalive"}
+
+// interfaceFields returns the serialized values of every modelled
+// [Interface] key. Zero/empty values produce no lines.
+func interfaceFields(iface *Interface) []field {
+	return []field{
+		{"PrivateKey", optString(iface.PrivateKey)},
+		{"Address", optString(FormatPrefixes(iface.Address))},
+		{"ListenPort", optInt(iface.ListenPort)},
+		{"DNS", optString(FormatDNS(iface.DNS, iface.DNSSearch))},
+		{"MTU", optInt(iface.MTU)},
+		{"Table", optString(iface.Table)},
+		{"FwMark", optFwMark(iface.FwMark)},
+		{"PreUp", iface.PreUp},
+		{"PostUp", iface.PostUp},
+		{"PreDown", iface.PreDown},
+		{"PostDown", iface.PostDown},
+		{"SaveConfig", optBool(iface.SaveConfig)},
 	}
-	if iface.DNS != "" {
-		fmt.Fprintf(&b, "DNS = %s\n", iface.DNS)
+}
+
+// peerFields returns the serialized values of every modelled [Peer] key,
+// metadata comments first. Zero/empty values produce no lines.
+func peerFields(peer *Peer) []field {
+	return append(peerMetaFields(peer), []field{
+		{"PublicKey", optString(peer.PublicKey)},
+		{"PresharedKey", optString(peer.PresharedKey)},
+		{"AllowedIPs", optString(FormatPrefixes(peer.AllowedIPs))},
+		{"Endpoint", optString(peer.Endpoint)},
+		{"PersistentKeepalive", optInt(peer.PersistentKeepalive)},
+	}...)
+}
+
+// ParsePrefixes parses a comma-separated list of CIDR prefixes, as used by
+// Address and AllowedIPs. A bare IP address is taken as a single-host prefix
+// (/32 or /128), matching wg and wg-quick. Host bits are preserved.
+func ParsePrefixes(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			addr, err := netip.ParseAddr(part)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR prefix", part)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid CIDR prefix", part)
+		}
+		prefixes = append(prefixes, prefix)
 	}
-	if iface.MTU != 0 {
-		fmt.Fprintf(&b, "MTU = %d\n", iface.MTU)
+	return prefixes, nil
+}
+
+// FormatPrefixes renders prefixes as a comma-separated list.
+func FormatPrefixes(prefixes []netip.Prefix) string {
+	parts := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		parts[i] = p.String()
 	}
+	return strings.Join(parts, ", ")
+}
 
-	for _, peer := range iface.Peers {
-		b.WriteString("\n[Peer]\n")
-		fmt.Fprintf(&b, "PublicKey = %s\n", peer.PublicKey)
-		if peer.PresharedKey != "" {
-			fmt.Fprintf(&b, "PresharedKey = %s\n", peer.PresharedKey)
+// ParseDNS splits a comma-separated DNS value into resolver addresses and
+// search domains. Like wg-quick, entries that parse as IP addresses are
+// resolvers and everything else is a search domain.
+func ParseDNS(s string) (servers []netip.Addr, search []string, err error) {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(part); err == nil {
+			servers = append(servers, addr)
+			continue
 		}
-		fmt.Fprintf(&b, "AllowedIPs = %s\n", peer.AllowedIPs)
-		if peer.Endpoint != "" {
-			fmt.Fprintf(&b, "Endpoint = %s\n", peer.Endpoint)
+		if strings.ContainsAny(part, " \t/") {
+			return nil, nil, fmt.Errorf("%q is neither an IP address nor a search domain", part)
 		}
-		if peer.PersistentKeepalive != 0 {
-			fmt.Fprintf(&b, "PersistentKeepalive = %d\n", peer.PersistentKeepalive)
+		search = append(search, part)
+	}
+	return servers, search, nil
+}
+
+// FormatDNS renders resolvers followed by search domains as a
+// comma-separated DNS value.
+func FormatDNS(servers []netip.Addr, search []string) string {
+	parts := make([]string, 0, len(servers)+len(search))
+	for _, addr := range servers {
+		parts = append(parts, addr.String())
+	}
+	parts = append(parts, search...)
+	return strings.Join(parts, ", ")
+}
+
+// ParseTable validates a wg-quick Table value: "off", "auto", a numeric
+// routing table id, or a table name from /etc/iproute2/rt_tables.
+func ParseTable(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return "", nil
+	case s == "off" || s == "auto":
+		return s, nil
+	}
+	if _, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return s, nil
+	}
+	for _, c := range s {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.') { //nolint:staticcheck // QF1001 De Morgan's form is less readable here
+			return "", fmt.Errorf("must be off, auto, a table number or a table name")
 		}
 	}
+	return s, nil
+}
+
+// ParseFwMark parses a firewall mark as accepted by wg: "off", or a 32-bit
+// number in decimal or 0x-prefixed hex.
+func ParseFwMark(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return 0, nil
+	}
+	mark, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("must be off or a 32-bit number")
+	}
+	return uint32(mark), nil
+}
+
+// ParseSaveConfig parses a SaveConfig value. Like wg-quick, only the exact
+// words true and false are accepted.
+func ParseSaveConfig(s string) (bool, error) {
+	switch strings.TrimSpace(s) {
+	case "true":
+		return true, nil
+	case "false", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("must be true or false")
+}
+
+// MarshalConfig serializes an Interface back to WireGuard .conf format.
+// Optional fields with zero/empty values are omitted.
+// Peer sections are separated by blank lines. Configs returned by
+// ParseConfig keep their comments, unknown keys and line order; only lines
+// whose values were changed are rewritten.
+func MarshalConfig(iface *Interface) string {
+	var b strings.Builder
+	nl := iface.newline
+	if nl == "" {
+		nl = "\n"
+	}
+
+	writeSection(&b, "[Interface]", interfaceFields(iface), iface.layout, nl)
 
+	for i := range iface.Peers {
+		peer := &iface.Peers[i]
+		if len(peer.layout.lines) == 0 {
+			b.WriteString(nl)
+		}
+		writeSection(&b, "[Peer]", peerFields(peer), peer.layout, nl)
+	}
+
+	if iface.noFinalNewline {
+		return strings.TrimSuffix(b.String(), nl)
+	}
 	return b.String()
 }
 
 // LoadConfigsFromDir reads all .conf files from the given directory and returns
 // the parsed Interface configurations. Each Interface's Name field is set from
-// the filename (without the .conf extension). Non-.conf files are ignored.
-func LoadConfigsFromDir(dir string) ([]*Interface, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
-
-	// List .conf files via sudo
-	cmd := exec.CommandContext(ctx, "sudo", "ls", dir)
-	output, err := cmd.CombinedOutput()
+// the filename (without the .conf extension) and Dir to dir. Non-.conf files
+// are ignored.
+func (m *Manager) LoadConfigsFromDir(dir string) ([]*Interface, error) {
+	names, err := m.listDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("listing %s: %w: %s", dir, err, strings.TrimSpace(string(output)))
+		return nil, fmt.Errorf("listing %s: %w", dir, err)
 	}
 
 	var configs []*Interface
-	for _, name := range strings.Fields(string(output)) {
+	for _, name := range names {
 		if !strings.HasSuffix(name, ".conf") {
 			continue
 		}
 
-		ctx2, cancel2 := context.WithTimeout(context.Background(), cmdTimeout)
-		catCmd := exec.CommandContext(ctx2, "sudo", "cat", filepath.Join(dir, name))
-		data, err := catCmd.Output()
-		cancel2()
+		data, err := m.readFile(filepath.Join(dir, name))
 		if err != nil {
 			return nil, fmt.Errorf("reading %s: %w", name, err)
 		}
 
-		iface, err := ParseConfig(strings.NewReader(string(data)))
+		iface, err := ParseConfig(bytes.NewReader(data))
 		if err != nil {
 			return nil, fmt.Errorf("parsing %s: %w", name, err)
 		}
 
 		iface.Name = strings.TrimSuffix(name, ".conf")
+		iface.Dir = dir
 		configs = append(configs, iface)
 	}
 
 	return configs, nil
 }
 
-// SaveConfig writes the Interface configuration to dir/name.conf with 0600 permissions.
-func SaveConfig(dir string, iface *Interface) error {
-	path := filepath.Join(dir, iface.Name+".conf")
-	content := MarshalConfig(iface)
-
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "sudo", "tee", path)
-	cmd.Stdin = strings.NewReader(content)
-	cmd.Stdout = nil // suppress tee's stdout echo
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("writing config %s: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+// LoadProfiles reads the profiles of every configured root, in root order.
+// Roots that don't exist are skipped. A root that can't be read doesn't
+// hide the others: its error is joined into the returned error alongside
+// the profiles that did load.
+func (m *Manager) LoadProfiles() ([]*Interface, error) {
+	var all []*Interface
+	var errs []error
+	for _, dir := range m.ConfigDirs() {
+		configs, err := m.LoadConfigsFromDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		all = append(all, configs...)
 	}
+	return all, errors.Join(errs...)
+}
 
-	// Set permissions
-	chmodCmd := exec.CommandContext(ctx, "sudo", "chmod", "0600", path)
-	if err := chmodCmd.Run(); err != nil {
-		return fmt.Errorf("setting permissions on %s: %w", path, err)
+// Path returns the location of the profile's config file.
+func (i *Interface) Path() string {
+	return filepath.Join(i.Dir, i.Name+".conf")
+}
+
+// Target returns the argument wg-quick needs to find the profile: its
+// name when it lives in DefaultConfigDir, its path otherwise.
+func (i *Interface) Target() string {
+	if i.Dir == "" || filepath.Clean(i.Dir) == DefaultConfigDir {
+		return i.Name
 	}
+	return i.Path()
+}
 
-	return nil
+// SaveConfig writes the Interface configuration to dir/name.conf with 0600
+// permissions. The write is atomic, and the version it replaces is kept in
+// the profile's history (see History).
+func (m *Manager) SaveConfig(dir string, iface *Interface) error {
+	return m.saveFile(dir, iface.Name, []byte(MarshalConfig(iface)))
 }
 
-// DeleteConfig removes the configuration file dir/name.conf.
-func DeleteConfig(dir string, name string) error {
+// DeleteConfig removes the configuration file dir/name.conf. Its last
+// version is kept in the profile's history.
+func (m *Manager) DeleteConfig(dir string, name string) error {
 	path := filepath.Join(dir, name+".conf")
-
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "sudo", "rm", path)
-	output, err := cmd.CombinedOutput()
+	old, err := m.readFile(path)
 	if err != nil {
-		return fmt.Errorf("removing config %s: %w: %s", path, err, strings.TrimSpace(string(output)))
+		return fmt.Errorf("removing config %s: %w", path, err)
+	}
+	if err := m.backup(dir, name, old); err != nil {
+		return fmt.Errorf("backing up %s: %w", path, err)
+	}
+	if err := m.removeFile(path); err != nil {
+		return fmt.Errorf("removing config %s: %w", path, err)
 	}
 	return nil
 }
+
+// DeleteProfile removes the profile's config, bringing its interface down
+// first if it is up. The config is kept if that fails, so a running tunnel
+// is never left without its config.
+func (m *Manager) DeleteProfile(iface *Interface) error {
+	up, err := m.IsUp(iface.Target())
+	if err != nil {
+		return fmt.Errorf("checking interface state: %w", err)
+	}
+	if up {
+		if err := m.Down(iface.Target()); err != nil {
+			return err
+		}
+	}
+	return m.DeleteConfig(iface.Dir, iface.Name)
+}
@@ -1,21 +1,13 @@
 package wg
 
 import (
+	"net/netip"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 )
 
-// skipWithoutSudo skips the test if passwordless sudo is unavailable.
-func skipWithoutSudo(t *testing.T) {
-	t.Helper()
-	if err := exec.Command("sudo", "-n", "true").Run(); err != nil {
-		t.Skip("skipping: passwordless sudo not available")
-	}
-}
-
 const sampleConfig = `[Interface]
 PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
 Address = 10.0.0.1/24
@@ -45,13 +37,13 @@ func TestParseConfig(t *testing.T) {
 	if iface.PrivateKey != "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=" {
 		t.Errorf("PrivateKey = %q, want %q", iface.PrivateKey, "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=")
 	}
-	if iface.Address != "10.0.0.1/24" {
+	if FormatPrefixes(iface.Address) != "10.0.0.1/24" {
 		t.Errorf("Address = %q, want %q", iface.Address, "10.0.0.1/24")
 	}
 	if iface.ListenPort != 51820 {
 		t.Errorf("ListenPort = %d, want %d", iface.ListenPort, 51820)
 	}
-	if iface.DNS != "1.1.1.1, 8.8.8.8" {
+	if FormatDNS(iface.DNS, iface.DNSSearch) != "1.1.1.1, 8.8.8.8" {
 		t.Errorf("DNS = %q, want %q", iface.DNS, "1.1.1.1, 8.8.8.8")
 	}
 	if iface.MTU != 1420 {
@@ -71,7 +63,7 @@ func TestParseConfig(t *testing.T) {
 	if p0.PresharedKey != "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=" {
 		t.Errorf("Peer[0].PresharedKey = %q, want %q", p0.PresharedKey, "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
 	}
-	if p0.AllowedIPs != "0.0.0.0/0, ::/0" {
+	if FormatPrefixes(p0.AllowedIPs) != "0.0.0.0/0, ::/0" {
 		t.Errorf("Peer[0].AllowedIPs = %q, want %q", p0.AllowedIPs, "0.0.0.0/0, ::/0")
 	}
 	if p0.Endpoint != "203.0.113.1:51820" {
@@ -86,7 +78,7 @@ func TestParseConfig(t *testing.T) {
 	if p1.PublicKey != "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=" {
 		t.Errorf("Peer[1].PublicKey = %q, want %q", p1.PublicKey, "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=")
 	}
-	if p1.AllowedIPs != "10.0.0.2/32" {
+	if FormatPrefixes(p1.AllowedIPs) != "10.0.0.2/32" {
 		t.Errorf("Peer[1].AllowedIPs = %q, want %q", p1.AllowedIPs, "10.0.0.2/32")
 	}
 	// These should be zero-values for the second peer
@@ -115,7 +107,7 @@ Address = 10.0.0.1/24
 	if iface.PrivateKey != "abc123=" {
 		t.Errorf("PrivateKey = %q, want %q", iface.PrivateKey, "abc123=")
 	}
-	if iface.Address != "10.0.0.1/24" {
+	if FormatPrefixes(iface.Address) != "10.0.0.1/24" {
 		t.Errorf("Address = %q, want %q", iface.Address, "10.0.0.1/24")
 	}
 }
@@ -139,15 +131,15 @@ ListenPort = notanumber
 func TestMarshalConfig(t *testing.T) {
 	iface := &Interface{
 		PrivateKey: "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=",
-		Address:    "10.0.0.1/24",
+		Address:    mustPrefixes("10.0.0.1/24"),
 		ListenPort: 51820,
-		DNS:        "1.1.1.1, 8.8.8.8",
+		DNS:        mustAddrs("1.1.1.1, 8.8.8.8"),
 		MTU:        1420,
 		Peers: []Peer{
 			{
 				PublicKey:           "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=",
 				PresharedKey:        "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
-				AllowedIPs:          "0.0.0.0/0, ::/0",
+				AllowedIPs:          mustPrefixes("0.0.0.0/0, ::/0"),
 				Endpoint:            "203.0.113.1:51820",
 				PersistentKeepalive: 25,
 			},
@@ -181,14 +173,14 @@ func TestMarshalConfig(t *testing.T) {
 func TestMarshalConfigOmitsEmptyOptionalFields(t *testing.T) {
 	iface := &Interface{
 		PrivateKey: "abc123=",
-		Address:    "10.0.0.1/24",
+		Address:    mustPrefixes("10.0.0.1/24"),
 		// ListenPort: 0 (zero value, should be omitted)
-		// DNS: "" (empty, should be omitted)
+		// DNS: mustAddrs("") (empty, should be omitted)
 		// MTU: 0 (zero value, should be omitted)
 		Peers: []Peer{
 			{
 				PublicKey:  "def456=",
-				AllowedIPs: "10.0.0.2/32",
+				AllowedIPs: mustPrefixes("10.0.0.2/32"),
 				// PresharedKey: "" (empty, should be omitted)
 				// Endpoint: "" (empty, should be omitted)
 				// PersistentKeepalive: 0 (zero value, should be omitted)
@@ -231,10 +223,10 @@ func TestMarshalConfigOmitsEmptyOptionalFields(t *testing.T) {
 func TestMarshalConfigPeerSeparation(t *testing.T) {
 	iface := &Interface{
 		PrivateKey: "abc123=",
-		Address:    "10.0.0.1/24",
+		Address:    mustPrefixes("10.0.0.1/24"),
 		Peers: []Peer{
-			{PublicKey: "peer1=", AllowedIPs: "10.0.0.2/32"},
-			{PublicKey: "peer2=", AllowedIPs: "10.0.0.3/32"},
+			{PublicKey: "peer1=", AllowedIPs: mustPrefixes("10.0.0.2/32")},
+			{PublicKey: "peer2=", AllowedIPs: mustPrefixes("10.0.0.3/32")},
 		},
 	}
 
@@ -249,7 +241,6 @@ func TestMarshalConfigPeerSeparation(t *testing.T) {
 }
 
 func TestLoadConfigsFromDir(t *testing.T) {
-	skipWithoutSudo(t)
 	dir := t.TempDir()
 
 	// Write two .conf files
@@ -274,7 +265,7 @@ Address = 10.0.0.2/24
 		t.Fatal(err)
 	}
 
-	configs, err := LoadConfigsFromDir(dir)
+	configs, err := newTestManager(t).LoadConfigsFromDir(dir)
 	if err != nil {
 		t.Fatalf("LoadConfigsFromDir returned error: %v", err)
 	}
@@ -297,10 +288,9 @@ Address = 10.0.0.2/24
 }
 
 func TestLoadConfigsFromDirEmpty(t *testing.T) {
-	skipWithoutSudo(t)
 	dir := t.TempDir()
 
-	configs, err := LoadConfigsFromDir(dir)
+	configs, err := newTestManager(t).LoadConfigsFromDir(dir)
 	if err != nil {
 		t.Fatalf("LoadConfigsFromDir returned error: %v", err)
 	}
@@ -310,16 +300,15 @@ func TestLoadConfigsFromDirEmpty(t *testing.T) {
 }
 
 func TestSaveConfig(t *testing.T) {
-	skipWithoutSudo(t)
 	dir := t.TempDir()
 
 	iface := &Interface{
 		Name:       "wg0",
 		PrivateKey: "testkey=",
-		Address:    "10.0.0.1/24",
+		Address:    mustPrefixes("10.0.0.1/24"),
 	}
 
-	if err := SaveConfig(dir, iface); err != nil {
+	if err := newTestManager(t).SaveConfig(dir, iface); err != nil {
 		t.Fatalf("SaveConfig returned error: %v", err)
 	}
 
@@ -346,7 +335,6 @@ func TestSaveConfig(t *testing.T) {
 }
 
 func TestDeleteConfig(t *testing.T) {
-	skipWithoutSudo(t)
 	dir := t.TempDir()
 
 	// Create a config file to delete
@@ -355,7 +343,7 @@ func TestDeleteConfig(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := DeleteConfig(dir, "wg0"); err != nil {
+	if err := newTestManager(t).DeleteConfig(dir, "wg0"); err != nil {
 		t.Fatalf("DeleteConfig returned error: %v", err)
 	}
 
@@ -365,10 +353,9 @@ func TestDeleteConfig(t *testing.T) {
 }
 
 func TestDeleteConfigNotFound(t *testing.T) {
-	skipWithoutSudo(t)
 	dir := t.TempDir()
 
-	err := DeleteConfig(dir, "nonexistent")
+	err := newTestManager(t).DeleteConfig(dir, "nonexistent")
 	if err == nil {
 		t.Error("expected error when deleting nonexistent config, got nil")
 	}
@@ -394,13 +381,13 @@ func TestRoundTrip(t *testing.T) {
 	if iface1.PrivateKey != iface2.PrivateKey {
 		t.Errorf("PrivateKey mismatch: %q vs %q", iface1.PrivateKey, iface2.PrivateKey)
 	}
-	if iface1.Address != iface2.Address {
+	if FormatPrefixes(iface1.Address) != FormatPrefixes(iface2.Address) {
 		t.Errorf("Address mismatch: %q vs %q", iface1.Address, iface2.Address)
 	}
 	if iface1.ListenPort != iface2.ListenPort {
 		t.Errorf("ListenPort mismatch: %d vs %d", iface1.ListenPort, iface2.ListenPort)
 	}
-	if iface1.DNS != iface2.DNS {
+	if FormatDNS(iface1.DNS, iface1.DNSSearch) != FormatDNS(iface2.DNS, iface2.DNSSearch) {
 		t.Errorf("DNS mismatch: %q vs %q", iface1.DNS, iface2.DNS)
 	}
 	if iface1.MTU != iface2.MTU {
@@ -420,7 +407,7 @@ func TestRoundTrip(t *testing.T) {
 		if p1.PresharedKey != p2.PresharedKey {
 			t.Errorf("Peer[%d].PresharedKey mismatch: %q vs %q", i, p1.PresharedKey, p2.PresharedKey)
 		}
-		if p1.AllowedIPs != p2.AllowedIPs {
+		if FormatPrefixes(p1.AllowedIPs) != FormatPrefixes(p2.AllowedIPs) {
 			t.Errorf("Peer[%d].AllowedIPs mismatch: %q vs %q", i, p1.AllowedIPs, p2.AllowedIPs)
 		}
 		if p1.Endpoint != p2.Endpoint {
@@ -431,3 +418,366 @@ func TestRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+const annotatedConfig = `# Office gateway, managed by ops
+[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+Address = 10.0.0.1/24
+ListenPort = 51820  # forwarded on the edge router
+Table = off
+FwMark = 0xca6c
+PostUp = iptables -A FORWARD -i %i -j ACCEPT
+PostUp = iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE
+PreDown = iptables -D FORWARD -i %i -j ACCEPT
+SaveConfig = false
+
+# alice's laptop
+[Peer]
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+AllowedIPs = 10.0.0.2/32
+
+[Peer]
+# build server
+PublicKey = TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=
+allowedips = 10.0.0.3/32
+PersistentKeepalive = 25
+`
+
+func TestRoundTripPreservesUnknownKeysAndComments(t *testing.T) {
+	iface, err := ParseConfig(strings.NewReader(annotatedConfig))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	if iface.ListenPort != 51820 {
+		t.Errorf("ListenPort = %d, want 51820 (inline comment should be ignored)", iface.ListenPort)
+	}
+	if FormatPrefixes(iface.Peers[1].AllowedIPs) != "10.0.0.3/32" {
+		t.Errorf("Peer[1].AllowedIPs = %q, want keys matched case-insensitively", iface.Peers[1].AllowedIPs)
+	}
+
+	if got := MarshalConfig(iface); got != annotatedConfig {
+		t.Errorf("MarshalConfig did not reproduce the input.\ngot:\n%s\nwant:\n%s", got, annotatedConfig)
+	}
+}
+
+func TestMarshalConfigRewritesOnlyEditedLines(t *testing.T) {
+	iface, err := ParseConfig(strings.NewReader(annotatedConfig))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+
+	iface.ListenPort = 51821
+	iface.MTU = 1380
+	iface.Peers[0].Endpoint = "203.0.113.7:51820"
+
+	want := strings.NewReplacer(
+		"ListenPort = 51820  # forwarded on the edge router\n", "ListenPort = 51821  # forwarded on the edge router\n",
+		"SaveConfig = false\n", "SaveConfig = false\nMTU = 1380\n",
+		"AllowedIPs = 10.0.0.2/32\n", "AllowedIPs = 10.0.0.2/32\nEndpoint = 203.0.113.7:51820\n",
+	).Replace(annotatedConfig)
+
+	if got := MarshalConfig(iface); got != want {
+		t.Errorf("MarshalConfig output mismatch.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRoundTripLineEndings(t *testing.T) {
+	edit := func(iface *Interface) {
+		iface.ListenPort = 51821
+		iface.Peers = append(iface.Peers, Peer{PublicKey: testPubKey2, AllowedIPs: mustPrefixes("10.0.0.4/32")})
+	}
+	added := "\n[Peer]\nPublicKey = " + testPubKey2 + "\nAllowedIPs = 10.0.0.4/32\n"
+
+	tests := []struct {
+		name  string
+		input string
+		want  string // after edit
+	}{
+		{
+			name:  "CRLF",
+			input: strings.ReplaceAll(annotatedConfig, "\n", "\r\n"),
+			want:  strings.ReplaceAll(strings.Replace(annotatedConfig, "ListenPort = 51820", "ListenPort = 51821", 1)+added, "\n", "\r\n"),
+		},
+		{
+			name:  "no final newline",
+			input: strings.TrimSuffix(annotatedConfig, "\n"),
+			want:  strings.TrimSuffix(strings.Replace(annotatedConfig, "ListenPort = 51820", "ListenPort = 51821", 1)+added, "\n"),
+		},
+		{
+			name:  "CRLF without final newline",
+			input: strings.TrimSuffix(strings.ReplaceAll(annotatedConfig, "\n", "\r\n"), "\r\n"),
+			want:  strings.TrimSuffix(strings.ReplaceAll(strings.Replace(annotatedConfig, "ListenPort = 51820", "ListenPort = 51821", 1)+added, "\n", "\r\n"), "\r\n"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			iface, err := ParseConfigFromString(tc.input)
+			if err != nil {
+				t.Fatalf("ParseConfig returned error: %v", err)
+			}
+			if iface.ListenPort != 51820 || iface.Peers[1].PersistentKeepalive != 25 {
+				t.Errorf("parsed ListenPort %d, PersistentKeepalive %d", iface.ListenPort, iface.Peers[1].PersistentKeepalive)
+			}
+			if got := MarshalConfig(iface); got != tc.input {
+				t.Errorf("MarshalConfig did not reproduce the input.\ngot:  %q\nwant: %q", got, tc.input)
+			}
+			edit(iface)
+			if got := MarshalConfig(iface); got != tc.want {
+				t.Errorf("edited MarshalConfig output mismatch.\ngot:  %q\nwant: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMarshalConfigKeepsInlineComments(t *testing.T) {
+	const config = `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+ListenPort = 51820  # forwarded on the edge router
+PostUp = iptables -A FORWARD -i %i -j ACCEPT # forward
+PostUp = iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE	# NAT
+`
+	iface, err := ParseConfigFromString(config)
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+	iface.ListenPort = 51821
+	iface.PostUp = []string{"iptables -A FORWARD -i %i -j ACCEPT", "iptables -t nat -A POSTROUTING -o eth1 -j MASQUERADE", "logger up"}
+
+	// Each rewritten line keeps the comment of the line it replaces.
+	want := `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+ListenPort = 51821  # forwarded on the edge router
+PostUp = iptables -A FORWARD -i %i -j ACCEPT # forward
+PostUp = iptables -t nat -A POSTROUTING -o eth1 -j MASQUERADE	# NAT
+PostUp = logger up
+`
+	if got := MarshalConfig(iface); got != want {
+		t.Errorf("MarshalConfig output mismatch.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarshalConfigDropsCommentsOfRemovedPeer(t *testing.T) {
+	iface, err := ParseConfig(strings.NewReader(annotatedConfig))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+
+	iface.Peers = iface.Peers[1:]
+	output := MarshalConfig(iface)
+
+	if strings.Contains(output, "alice's laptop") {
+		t.Error("comment above removed peer should be removed with it")
+	}
+	if !strings.Contains(output, "# build server") {
+		t.Error("comment inside remaining peer should be kept")
+	}
+	if !strings.Contains(output, "PostUp = iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE") {
+		t.Error("unknown interface keys should be kept")
+	}
+}
+
+func TestParseConfigRoutingAndHooks(t *testing.T) {
+	iface, err := ParseConfig(strings.NewReader(annotatedConfig))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+
+	if iface.Table != "off" {
+		t.Errorf("Table = %q, want %q", iface.Table, "off")
+	}
+	if iface.FwMark != 0xca6c {
+		t.Errorf("FwMark = %#x, want %#x", iface.FwMark, 0xca6c)
+	}
+	wantPostUp := []string{
+		"iptables -A FORWARD -i %i -j ACCEPT",
+		"iptables -t nat -A POSTROUTING -o eth0 -j MASQUERADE",
+	}
+	if strings.Join(iface.PostUp, "\n") != strings.Join(wantPostUp, "\n") {
+		t.Errorf("PostUp = %q, want %q", iface.PostUp, wantPostUp)
+	}
+	if len(iface.PreDown) != 1 || len(iface.PreUp) != 0 || len(iface.PostDown) != 0 {
+		t.Errorf("hooks = PreUp %q PreDown %q PostDown %q", iface.PreUp, iface.PreDown, iface.PostDown)
+	}
+	if iface.SaveConfig {
+		t.Error("SaveConfig = true, want false")
+	}
+}
+
+func TestMarshalConfigRoutingAndHooks(t *testing.T) {
+	iface := &Interface{
+		PrivateKey: "abc123=",
+		Address:    mustPrefixes("10.0.0.1/24"),
+		Table:      "1234",
+		FwMark:     51820,
+		PreUp:      []string{"echo pre"},
+		PostUp:     []string{"echo one", "echo two"},
+		PostDown:   []string{"echo down"},
+		SaveConfig: true,
+	}
+
+	want := `[Interface]
+PrivateKey = abc123=
+Address = 10.0.0.1/24
+Table = 1234
+FwMark = 0xca6c
+PreUp = echo pre
+PostUp = echo one
+PostUp = echo two
+PostDown = echo down
+SaveConfig = true
+`
+	if got := MarshalConfig(iface); got != want {
+		t.Errorf("MarshalConfig output mismatch.\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarshalConfigRewritesEditedHooks(t *testing.T) {
+	iface, err := ParseConfig(strings.NewReader(annotatedConfig))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+
+	iface.PostUp = []string{"echo replaced"}
+	output := MarshalConfig(iface)
+
+	if strings.Count(output, "PostUp") != 1 || !strings.Contains(output, "PostUp = echo replaced\nPreDown") {
+		t.Errorf("edited PostUp should replace both original lines in place, got:\n%s", output)
+	}
+}
+
+func TestParseConfigInvalidRoutingValues(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"fwmark", "FwMark = 0xfffffffff"},
+		{"table", "Table = main table"},
+		{"saveconfig", "SaveConfig = yes"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := "[Interface]\nPrivateKey = abc123=\n" + tc.line + "\n"
+			_, err := ParseConfig(strings.NewReader(input))
+			if err == nil {
+				t.Fatalf("expected error for %q, got nil", tc.line)
+			}
+			if !strings.Contains(err.Error(), "line 3") {
+				t.Errorf("error %q should contain line 3", err.Error())
+			}
+		})
+	}
+}
+
+// mustPrefixes parses a comma-separated prefix list for test fixtures.
+func mustPrefixes(s string) []netip.Prefix {
+	prefixes, err := ParsePrefixes(s)
+	if err != nil {
+		panic(err)
+	}
+	return prefixes
+}
+
+// mustAddrs parses a comma-separated address list for test fixtures.
+func mustAddrs(s string) []netip.Addr {
+	var addrs []netip.Addr
+	for _, part := range strings.Split(s, ",") {
+		addrs = append(addrs, netip.MustParseAddr(strings.TrimSpace(part)))
+	}
+	return addrs
+}
+
+func TestParseConfigMultipleAddressAndDNSLines(t *testing.T) {
+	input := `[Interface]
+PrivateKey = abc123=
+Address = 10.0.0.1/24
+Address = fd00::1/64
+DNS = 10.0.0.53, corp.example.com
+DNS = 2001:db8::53
+
+[Peer]
+PublicKey = def456=
+AllowedIPs = 10.0.0.0/24
+AllowedIPs = fd00::/64, 192.168.1.7
+`
+	iface, err := ParseConfig(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseConfig returned error: %v", err)
+	}
+
+	if got := FormatPrefixes(iface.Address); got != "10.0.0.1/24, fd00::1/64" {
+		t.Errorf("Address = %q, want both lines kept", got)
+	}
+	if got := FormatDNS(iface.DNS, nil); got != "10.0.0.53, 2001:db8::53" {
+		t.Errorf("DNS servers = %q", got)
+	}
+	if len(iface.DNSSearch) != 1 || iface.DNSSearch[0] != "corp.example.com" {
+		t.Errorf("DNSSearch = %q, want [corp.example.com]", iface.DNSSearch)
+	}
+	if got := FormatPrefixes(iface.Peers[0].AllowedIPs); got != "10.0.0.0/24, fd00::/64, 192.168.1.7/32" {
+		t.Errorf("AllowedIPs = %q", got)
+	}
+
+	// Unchanged: original lines are written back as they were.
+	if got := MarshalConfig(iface); got != input {
+		t.Errorf("MarshalConfig did not reproduce the input.\ngot:\n%s", got)
+	}
+
+	// Changed: the list is written canonically on a single line.
+	iface.Address = iface.Address[:1]
+	output := MarshalConfig(iface)
+	if strings.Count(output, "Address = ") != 1 || !strings.Contains(output, "Address = 10.0.0.1/24\n") {
+		t.Errorf("edited Address not written canonically:\n%s", output)
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "10.0.0.1/24", want: "10.0.0.1/24"},
+		{input: "0.0.0.0/0, ::/0", want: "0.0.0.0/0, ::/0"},
+		{input: "10.0.0.2", want: "10.0.0.2/32"},
+		{input: "fd00::2", want: "fd00::2/128"},
+		{input: " 10.0.0.2/32 ,, ", want: "10.0.0.2/32"},
+		{input: "", want: ""},
+		{input: "10.0.0.300/24", wantErr: true},
+		{input: "10.0.0.1/33", wantErr: true},
+		{input: "example.com", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParsePrefixes(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePrefixes(%q) expected error, got %v", tc.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePrefixes(%q) returned error: %v", tc.input, err)
+			}
+			if FormatPrefixes(got) != tc.want {
+				t.Errorf("ParsePrefixes(%q) = %q, want %q", tc.input, FormatPrefixes(got), tc.want)
+			}
+		})
+	}
+}
+
+func TestParseConfigInvalidAddress(t *testing.T) {
+	input := `[Interface]
+PrivateKey = abc123=
+Address = 10.0.0.1/24, nonsense
+`
+	_, err := ParseConfig(strings.NewReader(input))
+	if err == nil {
+		t.Fatal("expected error for invalid Address, got nil")
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("error %q should contain line 3", err.Error())
+	}
+}
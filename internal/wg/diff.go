@@ -0,0 +1,171 @@
+package wg
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// UnifiedDiff returns a unified diff that turns oldText into newText, with
+// the given file labels and three lines of context. It returns "" when the
+// texts are equal.
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	a, b := splitLines(oldText), splitLines(newText)
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// Extend the hunk while changes are within 2*context lines.
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+		from, to := max(start-diffContext, 0), min(end+diffContext, len(ops))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		writeHunk(&out, ops, from, to)
+		start = to
+	}
+	return out.String()
+}
+
+// DiffConfig compares the config on disk at dir/iface.Name.conf with what
+// SaveConfig would write for iface. It returns a unified diff with keys
+// redacted ("" when nothing would change) and whether the file exists; a
+// missing file diffs as empty.
+func (m *Manager) DiffConfig(dir string, iface *Interface) (diff string, exists bool, err error) {
+	path := filepath.Join(dir, iface.Name+".conf")
+	old, err := m.readFile(path)
+	switch {
+	case err == nil:
+		exists = true
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", false, fmt.Errorf("reading config %s: %w", path, err)
+	}
+	diff = UnifiedDiff(path, path+" (new)", RedactKeys(string(old)), RedactKeys(MarshalConfig(iface)))
+	return diff, exists, nil
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	kind       byte
+	text       string
+	aPos, bPos int // line index in old and new before this op
+}
+
+// diffLines computes a line edit script from a to b using a longest
+// common subsequence table. Configs are small, so O(len(a)*len(b)) is fine.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		}
+	}
+	return ops
+}
+
+// writeHunk writes ops[from:to] with its @@ header.
+func writeHunk(out *strings.Builder, ops []diffOp, from, to int) {
+	var aLen, bLen int
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			aLen++
+		}
+		if op.kind != '-' {
+			bLen++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(ops[from].aPos, aLen), hunkRange(ops[from].bPos, bLen))
+	for _, op := range ops[from:to] {
+		out.WriteByte(op.kind)
+		out.WriteString(op.text)
+		out.WriteByte('\n')
+	}
+}
+
+// hunkRange formats a hunk's start,length, 1-based as diff(1) does; an
+// empty range names the line before it.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// splitLines splits text into lines without their terminators.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// RedactKeys replaces the values of PrivateKey and PresharedKey lines in
+// config text with "(hidden #xxxxxxxx)", a short hash of the value, so
+// text can be shown on screen while a diff still reveals that a key
+// changed. Public keys are left alone.
+func RedactKeys(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		key, value, ok := strings.Cut(stripComment(line), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "privatekey", "presharedkey":
+		default:
+			continue
+		}
+		value = strings.TrimSpace(value)
+		sum := sha256.Sum256([]byte(value))
+		lines[i] = fmt.Sprintf("%s= (hidden #%x)", key, sum[:4])
+	}
+	return strings.Join(lines, "\n")
+}
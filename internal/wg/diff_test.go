@@ -0,0 +1,117 @@
+package wg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed line with context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want: "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "append to empty",
+			old:  "",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			name: "nearby changes share a hunk",
+			old:  "a\n1\n2\nb\n",
+			new:  "1\n2\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,2 @@\n-a\n 1\n 2\n-b\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := UnifiedDiff("old", "new", tc.old, tc.new); got != tc.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRedactKeys(t *testing.T) {
+	text := "[Interface]\nPrivateKey = " + testPrivKey + "\n\n[Peer]\nPublicKey = " + testPubKey1 + "\npresharedkey=" + testPubKey2 + " # psk\n"
+	got := RedactKeys(text)
+
+	if strings.Contains(got, testPrivKey) || strings.Contains(got, testPubKey2) {
+		t.Errorf("RedactKeys() leaked a secret:\n%s", got)
+	}
+	if !strings.Contains(got, "PublicKey = "+testPubKey1) {
+		t.Errorf("RedactKeys() hid a public key:\n%s", got)
+	}
+	if !strings.Contains(got, "PrivateKey = (hidden #") || !strings.Contains(got, "presharedkey= (hidden #") {
+		t.Errorf("RedactKeys() = \n%s\nwant hidden markers", got)
+	}
+
+	// A key change must still show up in a diff of redacted text.
+	other := strings.Replace(text, testPrivKey, testPubKey1, 1)
+	if RedactKeys(other) == got {
+		t.Error("different private keys redact to the same text")
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+	iface := &Interface{Name: "wg0", PrivateKey: testPrivKey, ListenPort: 51820}
+
+	diff, exists, err := m.DiffConfig(dir, iface)
+	if err != nil || exists {
+		t.Fatalf("DiffConfig() on missing file: exists %v, err %v", exists, err)
+	}
+	if !strings.Contains(diff, "+ListenPort = 51820") {
+		t.Errorf("diff against missing file = %q, want every line added", diff)
+	}
+
+	if err := m.SaveConfig(dir, iface); err != nil {
+		t.Fatal(err)
+	}
+	if diff, exists, _ := m.DiffConfig(dir, iface); diff != "" || !exists {
+		t.Errorf("DiffConfig() after save = %q, exists %v; want no diff", diff, exists)
+	}
+
+	iface.ListenPort = 51821
+	iface.PrivateKey = testPubKey1
+	diff, _, err = m.DiffConfig(dir, iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "wg0.conf")
+	for _, want := range []string{"--- " + path + "\n", "-ListenPort = 51820", "+ListenPort = 51821", "-PrivateKey = (hidden #", "+PrivateKey = (hidden #"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, testPrivKey) || strings.Contains(diff, testPubKey1) {
+		t.Errorf("diff leaked a private key:\n%s", diff)
+	}
+	// DiffConfig only reads.
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "51820") {
+		t.Errorf("DiffConfig changed the file: %q", data)
+	}
+}
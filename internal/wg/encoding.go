@@ -0,0 +1,263 @@
+package wg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces private and preshared keys in documents encoded
+// without secrets. Importing a document that still contains it fails.
+const Redacted = "(hidden)"
+
+// InterfaceDoc is the JSON/YAML form of an Interface, as printed by
+// `show --json` and accepted by `import`. Field names are stable; new
+// fields may be added. Keys are base64, addresses and allowed IPs CIDR
+// strings, and fields at their zero value are omitted.
+type InterfaceDoc struct {
+	Name       string    `json:"name,omitempty" yaml:"name,omitempty"`
+	PrivateKey string    `json:"private_key,omitempty" yaml:"private_key,omitempty"`
+	PublicKey  string    `json:"public_key,omitempty" yaml:"public_key,omitempty"` // derived; ignored on import
+	Address    []string  `json:"address,omitempty" yaml:"address,omitempty"`
+	ListenPort int       `json:"listen_port,omitempty" yaml:"listen_port,omitempty"`
+	DNS        []string  `json:"dns,omitempty" yaml:"dns,omitempty"`
+	DNSSearch  []string  `json:"dns_search,omitempty" yaml:"dns_search,omitempty"`
+	MTU        int       `json:"mtu,omitempty" yaml:"mtu,omitempty"`
+	Table      string    `json:"table,omitempty" yaml:"table,omitempty"`
+	FwMark     uint32    `json:"fwmark,omitempty" yaml:"fwmark,omitempty"`
+	PreUp      []string  `json:"pre_up,omitempty" yaml:"pre_up,omitempty"`
+	PostUp     []string  `json:"post_up,omitempty" yaml:"post_up,omitempty"`
+	PreDown    []string  `json:"pre_down,omitempty" yaml:"pre_down,omitempty"`
+	PostDown   []string  `json:"post_down,omitempty" yaml:"post_down,omitempty"`
+	SaveConfig bool      `json:"save_config,omitempty" yaml:"save_config,omitempty"`
+	Peers      []PeerDoc `json:"peers" yaml:"peers"`
+}
+
+// PeerDoc is the JSON/YAML form of a Peer. Created and Expires are
+// metadata times as written in the config (see ParseMetaTime).
+type PeerDoc struct {
+	PublicKey           string   `json:"public_key" yaml:"public_key"`
+	PresharedKey        string   `json:"preshared_key,omitempty" yaml:"preshared_key,omitempty"`
+	AllowedIPs          []string `json:"allowed_ips" yaml:"allowed_ips"`
+	Endpoint            string   `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	PersistentKeepalive int      `json:"persistent_keepalive,omitempty" yaml:"persistent_keepalive,omitempty"`
+	Name                string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Owner               string   `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Notes               string   `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Created             string   `json:"created,omitempty" yaml:"created,omitempty"`
+	Expires             string   `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// InterfaceStatusDoc is the JSON/YAML form of an InterfaceStatus. Status
+// never includes private or preshared keys.
+type InterfaceStatusDoc struct {
+	Name       string          `json:"name" yaml:"name"`
+	PublicKey  string          `json:"public_key" yaml:"public_key"`
+	ListenPort int             `json:"listen_port" yaml:"listen_port"`
+	FwMark     uint32          `json:"fwmark,omitempty" yaml:"fwmark,omitempty"`
+	Peers      []PeerStatusDoc `json:"peers" yaml:"peers"`
+}
+
+// PeerStatusDoc is the JSON/YAML form of a PeerStatus. LatestHandshake is
+// an RFC 3339 UTC time, or null if the peer never completed a handshake.
+type PeerStatusDoc struct {
+	PublicKey           string     `json:"public_key" yaml:"public_key"`
+	HasPresharedKey     bool       `json:"has_preshared_key" yaml:"has_preshared_key"`
+	Endpoint            string     `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	AllowedIPs          []string   `json:"allowed_ips" yaml:"allowed_ips"`
+	LatestHandshake     *time.Time `json:"latest_handshake" yaml:"latest_handshake"`
+	RxBytes             uint64     `json:"rx_bytes" yaml:"rx_bytes"`
+	TxBytes             uint64     `json:"tx_bytes" yaml:"tx_bytes"`
+	PersistentKeepalive int        `json:"persistent_keepalive,omitempty" yaml:"persistent_keepalive,omitempty"`
+}
+
+// NewInterfaceDoc converts iface for encoding. Unless secrets is set, the
+// private key and preshared keys are replaced with Redacted. Dir is not
+// part of the document.
+func NewInterfaceDoc(iface *Interface, secrets bool) InterfaceDoc {
+	d := InterfaceDoc{
+		Name:       iface.Name,
+		PrivateKey: redact(iface.PrivateKey, secrets),
+		Address:    prefixStrings(iface.Address),
+		ListenPort: iface.ListenPort,
+		DNSSearch:  iface.DNSSearch,
+		MTU:        iface.MTU,
+		Table:      iface.Table,
+		FwMark:     iface.FwMark,
+		PreUp:      iface.PreUp,
+		PostUp:     iface.PostUp,
+		PreDown:    iface.PreDown,
+		PostDown:   iface.PostDown,
+		SaveConfig: iface.SaveConfig,
+		Peers:      make([]PeerDoc, 0, len(iface.Peers)),
+	}
+	if pub, err := DerivePublicKey(iface.PrivateKey); err == nil {
+		d.PublicKey = pub
+	}
+	for _, a := range iface.DNS {
+		d.DNS = append(d.DNS, a.String())
+	}
+	for _, p := range iface.Peers {
+		d.Peers = append(d.Peers, PeerDoc{
+			PublicKey:           p.PublicKey,
+			PresharedKey:        redact(p.PresharedKey, secrets),
+			AllowedIPs:          prefixStrings(p.AllowedIPs),
+			Endpoint:            p.Endpoint,
+			PersistentKeepalive: p.PersistentKeepalive,
+			Name:                p.Name,
+			Owner:               p.Owner,
+			Notes:               p.Notes,
+			Created:             FormatMetaTime(p.Created),
+			Expires:             FormatMetaTime(p.Expires),
+		})
+	}
+	return d
+}
+
+// redact returns Redacted for a non-empty secret unless secrets is set.
+func redact(secret string, secrets bool) string {
+	if secret == "" || secrets {
+		return secret
+	}
+	return Redacted
+}
+
+func prefixStrings(prefixes []netip.Prefix) []string {
+	out := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		out = append(out, p.String())
+	}
+	return out
+}
+
+// Interface converts the document back to an Interface, checking that
+// every address and option parses. The result has no Dir and is not
+// validated; run Validate on it before saving.
+func (d InterfaceDoc) Interface() (*Interface, error) {
+	if d.PrivateKey == Redacted {
+		return nil, errors.New("private_key is redacted; export with secrets included")
+	}
+	iface := &Interface{
+		Name:       d.Name,
+		PrivateKey: d.PrivateKey,
+		ListenPort: d.ListenPort,
+		DNSSearch:  d.DNSSearch,
+		MTU:        d.MTU,
+		FwMark:     d.FwMark,
+		PreUp:      d.PreUp,
+		PostUp:     d.PostUp,
+		PreDown:    d.PreDown,
+		PostDown:   d.PostDown,
+		SaveConfig: d.SaveConfig,
+	}
+
+	var err error
+	if iface.Address, err = parsePrefixList(d.Address); err != nil {
+		return nil, fmt.Errorf("address: %w", err)
+	}
+	for _, s := range d.DNS {
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("dns: %w", err)
+		}
+		iface.DNS = append(iface.DNS, a)
+	}
+	if iface.Table, err = ParseTable(d.Table); err != nil {
+		return nil, fmt.Errorf("table: %w", err)
+	}
+
+	for i, pd := range d.Peers {
+		if pd.PresharedKey == Redacted {
+			return nil, fmt.Errorf("peer %d: preshared_key is redacted; export with secrets included", i+1)
+		}
+		p := Peer{
+			PublicKey:           pd.PublicKey,
+			PresharedKey:        pd.PresharedKey,
+			Endpoint:            pd.Endpoint,
+			PersistentKeepalive: pd.PersistentKeepalive,
+			Name:                pd.Name,
+			Owner:               pd.Owner,
+			Notes:               pd.Notes,
+		}
+		if p.AllowedIPs, err = parsePrefixList(pd.AllowedIPs); err != nil {
+			return nil, fmt.Errorf("peer %d: allowed_ips: %w", i+1, err)
+		}
+		if p.Created, err = ParseMetaTime(pd.Created); err != nil {
+			return nil, fmt.Errorf("peer %d: created: %w", i+1, err)
+		}
+		if p.Expires, err = ParseMetaTime(pd.Expires); err != nil {
+			return nil, fmt.Errorf("peer %d: expires: %w", i+1, err)
+		}
+		iface.Peers = append(iface.Peers, p)
+	}
+	return iface, nil
+}
+
+func parsePrefixList(list []string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, s := range list {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// NewStatusDoc converts s for encoding.
+func NewStatusDoc(s *InterfaceStatus) InterfaceStatusDoc {
+	d := InterfaceStatusDoc{
+		Name:       s.Name,
+		PublicKey:  s.PublicKey,
+		ListenPort: s.ListenPort,
+		FwMark:     s.FwMark,
+		Peers:      make([]PeerStatusDoc, 0, len(s.Peers)),
+	}
+	for _, p := range s.Peers {
+		pd := PeerStatusDoc{
+			PublicKey:           p.PublicKey,
+			HasPresharedKey:     p.HasPresharedKey,
+			Endpoint:            p.Endpoint,
+			AllowedIPs:          prefixStrings(p.AllowedIPs),
+			RxBytes:             p.TransferRx,
+			TxBytes:             p.TransferTx,
+			PersistentKeepalive: p.PersistentKeepalive,
+		}
+		if !p.LatestHandshake.IsZero() {
+			t := p.LatestHandshake.UTC()
+			pd.LatestHandshake = &t
+		}
+		d.Peers = append(d.Peers, pd)
+	}
+	return d
+}
+
+// ParseInterfaceJSON decodes an InterfaceDoc from JSON into an Interface.
+// Unknown fields are errors, so typos don't silently drop settings.
+func ParseInterfaceJSON(data []byte) (*Interface, error) {
+	var d InterfaceDoc
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("decoding JSON: %w", err)
+	}
+	return d.Interface()
+}
+
+// ParseInterfaceYAML decodes an InterfaceDoc from YAML into an Interface.
+// Unknown fields are errors.
+func ParseInterfaceYAML(data []byte) (*Interface, error) {
+	var d InterfaceDoc
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("decoding YAML: %w", err)
+	}
+	return d.Interface()
+}
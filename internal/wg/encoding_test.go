@@ -0,0 +1,139 @@
+package wg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestInterfaceDocRedactsByDefault(t *testing.T) {
+	iface, err := ParseConfigFromString(sampleConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iface.Name = "wg0"
+	iface.Dir = "/etc/wireguard"
+
+	data, err := json.Marshal(NewInterfaceDoc(iface, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if strings.Contains(out, testPrivKey) || strings.Contains(out, "AAAAAAAA") {
+		t.Errorf("redacted doc leaks a secret: %s", out)
+	}
+	for _, want := range []string{`"private_key":"(hidden)"`, `"preshared_key":"(hidden)"`, `"public_key":"` + testPubKey1 + `"`, `"address":["10.0.0.1/24"]`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("doc missing %s: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "/etc/wireguard") {
+		t.Errorf("doc includes Dir: %s", out)
+	}
+
+	if _, err := ParseInterfaceJSON(data); err == nil || !strings.Contains(err.Error(), "redacted") {
+		t.Errorf("importing a redacted doc: err = %v, want redacted error", err)
+	}
+}
+
+func TestInterfaceDocRoundTrip(t *testing.T) {
+	iface, err := ParseConfigFromString(sampleConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iface.Name = "wg0"
+	iface.Table = "off"
+	iface.FwMark = 0x1234
+	iface.PostUp = []string{"iptables -A FORWARD -i %i -j ACCEPT"}
+	iface.DNSSearch = []string{"corp.example"}
+	iface.Peers[1].Name = "laptop"
+	iface.Peers[1].Expires = time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	want := `[Interface]
+PrivateKey = ` + testPrivKey + `
+Address = 10.0.0.1/24
+ListenPort = 51820
+DNS = 1.1.1.1, 8.8.8.8, corp.example
+MTU = 1420
+Table = off
+FwMark = 0x1234
+PostUp = iptables -A FORWARD -i %i -j ACCEPT
+` + sampleConfig[strings.Index(sampleConfig, "\n[Peer]"):strings.LastIndex(sampleConfig, "[Peer]")] + `[Peer]
+# Name = laptop
+# Expires = 2026-12-31
+` + sampleConfig[strings.LastIndex(sampleConfig, "PublicKey"):]
+
+	encodings := []struct {
+		name   string
+		encode func(any) ([]byte, error)
+		parse  func([]byte) (*Interface, error)
+	}{
+		{"json", json.Marshal, ParseInterfaceJSON},
+		{"yaml", yaml.Marshal, ParseInterfaceYAML},
+	}
+	for _, enc := range encodings {
+		t.Run(enc.name, func(t *testing.T) {
+			data, err := enc.encode(NewInterfaceDoc(iface, true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := enc.parse(data)
+			if err != nil {
+				t.Fatalf("parse returned error: %v\n%s", err, data)
+			}
+			if got.Name != "wg0" {
+				t.Errorf("Name = %q, want wg0", got.Name)
+			}
+			if conf := MarshalConfig(got); conf != want {
+				t.Errorf("round trip =\n%s\nwant\n%s", conf, want)
+			}
+		})
+	}
+}
+
+func TestParseInterfaceJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"unknown field", `{"name":"wg0","listenport":51820}`, "unknown field"},
+		{"bad address", `{"address":["10.0.0.1"]}`, "address"},
+		{"bad allowed ip", `{"peers":[{"public_key":"x","allowed_ips":["nope"]}]}`, "peer 1: allowed_ips"},
+		{"bad table", `{"table":"main table"}`, "table"},
+		{"redacted psk", `{"peers":[{"public_key":"x","preshared_key":"(hidden)","allowed_ips":[]}]}`, "redacted"},
+		{"not json", `[Interface]`, "decoding JSON"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseInterfaceJSON([]byte(tc.doc))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ParseInterfaceJSON() error = %v, want containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestStatusDoc(t *testing.T) {
+	s := &InterfaceStatus{
+		Name:      "wg0",
+		PublicKey: testPubKey1,
+		Peers: []PeerStatus{
+			{PublicKey: testPubKey2, HasPresharedKey: true, TransferRx: 10},
+			{PublicKey: testPubKey1, LatestHandshake: time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("x", 3600))},
+		},
+	}
+	data, err := json.Marshal(NewStatusDoc(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, want := range []string{`"latest_handshake":null`, `"latest_handshake":"2026-01-02T02:04:05Z"`, `"has_preshared_key":true`, `"rx_bytes":10`, `"allowed_ips":[]`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("status doc missing %s: %s", want, out)
+		}
+	}
+}
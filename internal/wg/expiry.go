@@ -0,0 +1,94 @@
+package wg
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Expired reports whether the peer has an expiry date (see Peer.Expires)
+// that is not after now. A date without a time of day (midnight UTC, as
+// "# Expires = 2026-12-31" parses) lasts until the end of that day.
+func (p Peer) Expired(now time.Time) bool {
+	if p.Expires.IsZero() {
+		return false
+	}
+	end := p.Expires
+	if isMetaDate(end) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return !now.Before(end)
+}
+
+// ExpiredPeers returns the peers of iface that have expired at now.
+func ExpiredPeers(iface *Interface, now time.Time) []Peer {
+	var expired []Peer
+	for _, p := range iface.Peers {
+		if p.Expired(now) {
+			expired = append(expired, p)
+		}
+	}
+	return expired
+}
+
+// WithoutExpired returns a copy of iface without the peers that have
+// expired at now, and those peers. It never modifies its argument.
+func WithoutExpired(iface *Interface, now time.Time) (updated *Interface, removed []Peer) {
+	updated = new(Interface)
+	*updated = *iface
+	updated.Peers = slices.DeleteFunc(slices.Clone(iface.Peers), func(p Peer) bool {
+		if p.Expired(now) {
+			removed = append(removed, p)
+			return true
+		}
+		return false
+	})
+	return updated, removed
+}
+
+// RemovePeer removes a peer from the running interface name with
+// `wg set <name> peer <key> remove`, leaving the tunnel and its other
+// peers up.
+func (m *Manager) RemovePeer(name, publicKey string) error {
+	_, err := m.runWg("set", name, "peer", publicKey, "remove")
+	return err
+}
+
+// PruneExpired removes the peers of iface that have expired at now: from
+// its config, which is saved with the previous version kept in history,
+// and then, if the interface is up, from the running interface without
+// restarting it. Stored client configs of the removed peers (see
+// SaveClient) are deleted with them. It returns the removed peers and
+// whether the interface was up. Nothing is saved or run when no peer has
+// expired.
+func (m *Manager) PruneExpired(iface *Interface, now time.Time) (removed []Peer, live bool, err error) {
+	updated, removed := WithoutExpired(iface, now)
+	if len(removed) == 0 {
+		return nil, false, nil
+	}
+
+	name := interfaceName(iface.Target())
+	defer m.lock(name)()
+
+	if err := m.SaveConfig(iface.Dir, updated); err != nil {
+		return nil, false, err
+	}
+	var clientsErr error
+	if err := m.deleteClients(iface, removed); err != nil {
+		clientsErr = fmt.Errorf("removed from config, but not its stored client config: %w", err)
+	}
+	live, err = m.IsUp(iface.Target())
+	if err != nil {
+		return removed, false, errors.Join(fmt.Errorf("removed from config, but checking interface state: %w", err), clientsErr)
+	}
+	if !live {
+		return removed, false, clientsErr
+	}
+	for _, p := range removed {
+		if err := m.RemovePeer(name, p.PublicKey); err != nil {
+			return removed, true, errors.Join(fmt.Errorf("removed from config, but still on the running interface: %w", err), clientsErr)
+		}
+	}
+	return removed, true, clientsErr
+}
@@ -0,0 +1,165 @@
+package wg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const expiryConfig = `[Interface]
+PrivateKey = ` + testPrivKey + `
+Address = 10.0.0.1/24
+
+[Peer]
+# Name = contractor
+# Expires = 2026-06-01
+PublicKey = ` + testPubKey1 + `
+AllowedIPs = 10.0.0.2/32
+
+[Peer]
+# Expires = 2026-12-31
+PublicKey = ` + testPubKey2 + `
+AllowedIPs = 10.0.0.3/32
+`
+
+func TestWithoutExpired(t *testing.T) {
+	iface, err := ParseConfigFromString(expiryConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		now  time.Time
+		want int // peers removed
+	}{
+		{time.Date(2026, 6, 1, 23, 59, 0, 0, time.UTC), 0},
+		{time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), 2},
+	} {
+		updated, removed := WithoutExpired(iface, tc.now)
+		if len(removed) != tc.want || len(updated.Peers) != 2-tc.want {
+			t.Errorf("at %v: removed %d, kept %d; want %d removed", tc.now, len(removed), len(updated.Peers), tc.want)
+		}
+		if got := len(ExpiredPeers(iface, tc.now)); got != tc.want {
+			t.Errorf("ExpiredPeers() at %v = %d peers, want %d", tc.now, got, tc.want)
+		}
+	}
+	if len(iface.Peers) != 2 {
+		t.Error("WithoutExpired() modified its argument")
+	}
+
+	// The remaining peer keeps its layout, metadata included.
+	updated, removed := WithoutExpired(iface, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC))
+	if removed[0].Name != "contractor" {
+		t.Errorf("removed %+v, want the contractor", removed[0])
+	}
+	if got := MarshalConfig(updated); strings.Contains(got, "contractor") || !strings.Contains(got, "# Expires = 2026-12-31\nPublicKey = "+testPubKey2) {
+		t.Errorf("pruned config =\n%s", got)
+	}
+}
+
+func TestPeerExpired(t *testing.T) {
+	for _, tc := range []struct {
+		expires string
+		now     time.Time
+		want    bool
+	}{
+		{"", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		// A date lasts until the end of that day.
+		{"2026-06-01", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2026-06-01", time.Date(2026, 6, 1, 23, 59, 59, 0, time.UTC), false},
+		{"2026-06-01", time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC), true},
+		// A time expires when it is reached.
+		{"2026-06-01T12:00:00Z", time.Date(2026, 6, 1, 11, 59, 0, 0, time.UTC), false},
+		{"2026-06-01T12:00:00Z", time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC), true},
+		{"2026-06-01T12:00:00+02:00", time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC), true},
+	} {
+		expires, err := ParseMetaTime(tc.expires)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := (Peer{Expires: expires}).Expired(tc.now); got != tc.want {
+			t.Errorf("Expires %q: Expired(%v) = %v, want %v", tc.expires, tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestPruneExpired(t *testing.T) {
+	now := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		script   []fakeCall
+		wantErr  string
+		wantLive bool
+	}{
+		{
+			name:   "down interface",
+			script: []fakeCall{{cmd: "wg show wg0", exit: 1}},
+		},
+		{
+			name: "up interface loses the peer without a restart",
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg set wg0 peer " + testPubKey1 + " remove"},
+			},
+			wantLive: true,
+		},
+		{
+			name: "live removal fails",
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg set wg0 peer " + testPubKey1 + " remove", exit: 1, stderr: "boom\n"},
+			},
+			wantErr:  "removed from config, but still on the running interface: wg set wg0 peer " + testPubKey1 + " remove: exit status 1: boom",
+			wantLive: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			iface, err := ParseConfigFromString(expiryConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			iface.Name, iface.Dir = "wg0", dir
+
+			m := newTestManager(t, tc.script...)
+			removed, live, err := m.PruneExpired(iface, now)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Fatalf("PruneExpired() returned error: %v", err)
+			case tc.wantErr != "" && (err == nil || err.Error() != tc.wantErr):
+				t.Fatalf("PruneExpired() error = %v, want %q", err, tc.wantErr)
+			}
+			if len(removed) != 1 || live != tc.wantLive {
+				t.Errorf("PruneExpired() = %d removed, live %v; want 1, %v", len(removed), live, tc.wantLive)
+			}
+			saved, err := os.ReadFile(filepath.Join(dir, "wg0.conf"))
+			if err != nil || strings.Contains(string(saved), testPubKey1) {
+				t.Errorf("saved config = %s, %v; want the expired peer gone", saved, err)
+			}
+		})
+	}
+}
+
+func TestPruneExpiredDeletesStoredClient(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t, fakeCall{cmd: "wg show wg0", exit: 1})
+	server := newBundleServer(t, m, dir)
+	server.Peers[1].Expires = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	removed, _, err := m.PruneExpired(server, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(removed) != 1 || removed[0].Name != "laptop" {
+		t.Fatalf("PruneExpired() = %v, %v; want the laptop removed", removed, err)
+	}
+	cdir := filepath.Join(dir, clientsDir, "wg0")
+	if _, err := os.Stat(filepath.Join(cdir, "laptop.conf")); !os.IsNotExist(err) {
+		t.Errorf("laptop.conf still stored (%v); its private key must go with the peer", err)
+	}
+	if _, err := os.Stat(filepath.Join(cdir, "phone.conf")); err != nil {
+		t.Errorf("phone.conf: %v; want the unexpired client kept", err)
+	}
+}
@@ -0,0 +1,205 @@
+package wg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The file helpers below try plain file access first, so directories the
+// user owns (and root or CAP_DAC_OVERRIDE processes) need no helper, and
+// fall back to the privilege method when the kernel says no. The fallbacks
+// run fixed commands on absolute paths, never a shell, so that sudoers can
+// allow exactly them (see examples/wireguard-tui.sudoers).
+
+// listDir returns the names of the entries in dir, except hidden ones
+// when listed through the privilege helper.
+func (m *Manager) listDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err == nil {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+		return names, nil
+	}
+	if !errors.Is(err, fs.ErrPermission) {
+		return nil, err
+	}
+	return m.listDirPrivileged(dir)
+}
+
+// listDirPrivileged is listDir through the privilege helper.
+func (m *Manager) listDirPrivileged(dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	output, err := m.runPrivileged(nil, "ls", dir)
+	if err != nil {
+		return nil, m.notExist(dir, err)
+	}
+	var names []string
+	for _, name := range strings.Split(string(output), "\n") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// readFile returns the contents of path.
+func (m *Manager) readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil || !errors.Is(err, fs.ErrPermission) {
+		return data, err
+	}
+	return m.readFilePrivileged(path)
+}
+
+// readFilePrivileged is readFile through the privilege helper.
+func (m *Manager) readFilePrivileged(path string) ([]byte, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := m.runPrivileged(nil, "cat", path)
+	if err != nil {
+		return nil, m.notExist(path, err)
+	}
+	return data, nil
+}
+
+// notExist turns err, the failure of a privileged command on path, into
+// fs.ErrNotExist if path doesn't exist.
+func (m *Manager) notExist(path string, err error) error {
+	_, testErr := m.runPrivileged(nil, "test", "-e", path)
+	var exitErr *ExitError
+	if errors.As(testErr, &exitErr) && exitErr.Code == 1 && !errors.Is(testErr, ErrPasswordRequired) {
+		return fs.ErrNotExist
+	}
+	return err
+}
+
+// writeFile atomically replaces the contents of path, leaving it mode
+// 0600. The data goes to a temporary file in the same directory, created
+// 0600 before anything is written, which is fsynced and renamed over path,
+// so readers see either the old or the new config, never a partial one.
+func (m *Manager) writeFile(path string, data []byte) error {
+	err := writeFileAtomic(path, data)
+	if err == nil || !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	return m.writeFilePrivileged(path, data)
+}
+
+// writeFilePrivileged is writeFile through the privilege helper: mktemp
+// creates the temporary file 0600, dd fills and fsyncs it (unlike tee, it
+// doesn't echo the private keys to stdout, where they could end up in an
+// error message) and mv renames it into place.
+func (m *Manager) writeFilePrivileged(path string, data []byte) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	output, err := m.runPrivileged(nil, "mktemp", filepath.Join(dir, "."+filepath.Base(path)+".tmp-XXXXXX"))
+	if err != nil {
+		return err
+	}
+	tmp := strings.TrimSpace(string(output))
+	if filepath.Dir(tmp) != dir {
+		return fmt.Errorf("mktemp returned %q, not a file in %s", tmp, dir)
+	}
+
+	_, err = m.runPrivileged(data, "dd", "of="+tmp, "conv=fsync", "status=none")
+	if err == nil {
+		_, err = m.runPrivileged(nil, "mv", tmp, path)
+	}
+	if err != nil {
+		_, _ = m.runPrivileged(nil, "rm", tmp)
+	}
+	return err
+}
+
+// writeFileAtomic writes data to a temporary file next to path, syncs it
+// and renames it into place.
+func writeFileAtomic(path string, data []byte) (err error) {
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	// CreateTemp already uses 0600; make sure a umask can't widen it.
+	if err = f.Chmod(0600); err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir fsyncs a directory so a rename into it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return err
+	}
+	return nil
+}
+
+// mkdirAll creates dir and its parents with mode 0700.
+func (m *Manager) mkdirAll(dir string) error {
+	err := os.MkdirAll(dir, 0700)
+	if err == nil || !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return err
+	}
+	_, err = m.runPrivileged(nil, "mkdir", "-p", "-m", "0700", dir)
+	return err
+}
+
+// removeFile deletes path.
+func (m *Manager) removeFile(path string) error {
+	err := os.Remove(path)
+	if err == nil || !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return err
+	}
+	_, err = m.runPrivileged(nil, "rm", path)
+	return err
+}
+
+// runPrivileged runs a command with privileges, feeding it stdin, and
+// returns its stdout.
+func (m *Manager) runPrivileged(stdin []byte, name string, args ...string) ([]byte, error) {
+	return m.run(Command{Name: name, Args: args, Stdin: stdin, Privileged: true})
+}
@@ -0,0 +1,69 @@
+package wg
+
+import "time"
+
+// Health classifies a peer by the age of its latest handshake.
+// Values are ordered by severity, so the worst of several is the maximum.
+type Health int
+
+const (
+	HealthHealthy Health = iota // handshake within the stale threshold
+	HealthNever                 // no handshake since the interface came up
+	HealthStale                 // handshake older than the stale threshold
+	HealthDead                  // handshake older than DeadFactor thresholds
+)
+
+func (h Health) String() string {
+	switch h {
+	case HealthHealthy:
+		return "healthy"
+	case HealthNever:
+		return "never"
+	case HealthStale:
+		return "stale"
+	case HealthDead:
+		return "dead"
+	}
+	return "unknown"
+}
+
+// MinStaleAfter is the stale threshold of peers without keepalive, or
+// with a short one. WireGuard rekeys every two minutes while traffic
+// flows and rejects sessions older than three, so a working peer that
+// sends anything never gets past it.
+const MinStaleAfter = 180 * time.Second
+
+// DeadFactor is how many stale thresholds a handshake may be old before
+// the peer counts as dead rather than stale.
+const DeadFactor = 3
+
+// StaleAfter returns the handshake age after which p is stale: twice its
+// persistent keepalive, but at least MinStaleAfter.
+func StaleAfter(p PeerStatus) time.Duration {
+	return max(2*time.Duration(p.PersistentKeepalive)*time.Second, MinStaleAfter)
+}
+
+// PeerHealth classifies p at time now.
+func PeerHealth(p PeerStatus, now time.Time) Health {
+	if p.LatestHandshake.IsZero() {
+		return HealthNever
+	}
+	age, stale := now.Sub(p.LatestHandshake), StaleAfter(p)
+	switch {
+	case age > DeadFactor*stale:
+		return HealthDead
+	case age > stale:
+		return HealthStale
+	}
+	return HealthHealthy
+}
+
+// InterfaceHealth is the worst health of the peers of s; an interface
+// without peers is healthy.
+func InterfaceHealth(s *InterfaceStatus, now time.Time) Health {
+	worst := HealthHealthy
+	for _, p := range s.Peers {
+		worst = max(worst, PeerHealth(p, now))
+	}
+	return worst
+}
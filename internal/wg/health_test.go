@@ -0,0 +1,54 @@
+package wg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeerHealth(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name      string
+		age       time.Duration // 0: never
+		keepalive int
+		want      Health
+	}{
+		{"never", 0, 25, HealthNever},
+		{"fresh", 30 * time.Second, 0, HealthHealthy},
+		{"at threshold", 180 * time.Second, 0, HealthHealthy},
+		{"stale without keepalive", 181 * time.Second, 0, HealthStale},
+		{"short keepalive uses minimum", 200 * time.Second, 25, HealthStale},
+		{"long keepalive", 200 * time.Second, 120, HealthHealthy},
+		{"stale past 2x keepalive", 241 * time.Second, 120, HealthStale},
+		{"dead", 541 * time.Second, 0, HealthDead},
+		{"dead with keepalive", 721 * time.Second, 120, HealthDead},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := PeerStatus{PersistentKeepalive: tc.keepalive}
+			if tc.age != 0 {
+				p.LatestHandshake = now.Add(-tc.age)
+			}
+			if got := PeerHealth(p, now); got != tc.want {
+				t.Errorf("PeerHealth() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInterfaceHealth(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := &InterfaceStatus{Name: "wg0"}
+	if got := InterfaceHealth(s, now); got != HealthHealthy {
+		t.Errorf("no peers: %v, want healthy", got)
+	}
+	s.Peers = []PeerStatus{
+		{LatestHandshake: now.Add(-10 * time.Second)},
+		{},
+		{LatestHandshake: now.Add(-5 * time.Minute)},
+	}
+	if got := InterfaceHealth(s, now); got != HealthStale {
+		t.Errorf("InterfaceHealth() = %v, want stale (the worst peer)", got)
+	}
+}
@@ -0,0 +1,134 @@
+package wg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyDir is the backup area inside each profile root. Backups of
+// dir/name.conf live in dir/.history/name/<timestamp>.conf; wg-quick and
+// LoadConfigsFromDir only look at top-level .conf files, so they are
+// never mistaken for profiles.
+const historyDir = ".history"
+
+// maxHistory is how many backups are kept per profile; older ones are
+// removed when a new one is made.
+const maxHistory = 20
+
+// backupTimeFormat names backup files; it sorts lexically in time order.
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// Backup is a previous version of a profile's config.
+type Backup struct {
+	Time time.Time
+	Path string
+}
+
+// backupDirFor returns the backup directory of profile name in dir.
+func backupDirFor(dir, name string) string {
+	return filepath.Join(dir, historyDir, name)
+}
+
+// History lists the backups of a profile, newest first. A profile that
+// was never changed has none.
+func (m *Manager) History(iface *Interface) ([]Backup, error) {
+	bdir := backupDirFor(iface.Dir, iface.Name)
+	names, err := m.listDir(bdir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing history of %s: %w", iface.Name, err)
+	}
+
+	var backups []Backup
+	for _, n := range names {
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(n, ".conf"))
+		if err != nil || !strings.HasSuffix(n, ".conf") {
+			continue
+		}
+		backups = append(backups, Backup{Time: t, Path: filepath.Join(bdir, n)})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// ReadBackup returns the config text saved in b.
+func (m *Manager) ReadBackup(b Backup) (string, error) {
+	data, err := m.readFile(b.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading backup: %w", err)
+	}
+	return string(data), nil
+}
+
+// Restore replaces the profile's config with the version saved in b. The
+// config being replaced is backed up first, so a restore can be undone.
+func (m *Manager) Restore(iface *Interface, b Backup) (*Interface, error) {
+	text, err := m.ReadBackup(b)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := ParseConfigFromString(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing backup: %w", err)
+	}
+	restored.Name = iface.Name
+	restored.Dir = iface.Dir
+
+	if err := m.saveFile(iface.Dir, iface.Name, []byte(text)); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// saveFile atomically writes content to dir/name.conf, first copying the
+// current version, if it exists and differs, into the profile's history.
+func (m *Manager) saveFile(dir, name string, content []byte) error {
+	path := filepath.Join(dir, name+".conf")
+
+	old, err := m.readFile(path)
+	switch {
+	case err == nil && !bytes.Equal(old, content):
+		if err := m.backup(dir, name, old); err != nil {
+			return fmt.Errorf("backing up %s: %w", path, err)
+		}
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	if err := m.writeFile(path, content); err != nil {
+		return fmt.Errorf("writing config %s: %w", path, err)
+	}
+	return nil
+}
+
+// backup stores content as the newest backup of profile name in dir and
+// prunes backups beyond maxHistory.
+func (m *Manager) backup(dir, name string, content []byte) error {
+	bdir := backupDirFor(dir, name)
+	if err := m.mkdirAll(bdir); err != nil {
+		return err
+	}
+	path := filepath.Join(bdir, time.Now().UTC().Format(backupTimeFormat)+".conf")
+	if err := m.writeFile(path, content); err != nil {
+		return err
+	}
+
+	backups, err := m.History(&Interface{Name: name, Dir: dir})
+	if err != nil {
+		return err
+	}
+	for _, b := range backups[min(len(backups), maxHistory):] {
+		if err := m.removeFile(b.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package wg
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveConfigKeepsHistory(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+	iface := &Interface{Name: "wg0", Dir: dir, PrivateKey: testPrivKey, ListenPort: 51820}
+
+	if err := m.SaveConfig(dir, iface); err != nil {
+		t.Fatalf("first SaveConfig returned error: %v", err)
+	}
+	if h, _ := m.History(iface); len(h) != 0 {
+		t.Fatalf("History() after creating = %v, want none", h)
+	}
+
+	// Saving identical content makes no backup.
+	if err := m.SaveConfig(dir, iface); err != nil {
+		t.Fatalf("SaveConfig returned error: %v", err)
+	}
+	if h, _ := m.History(iface); len(h) != 0 {
+		t.Fatalf("History() after unchanged save = %v, want none", h)
+	}
+
+	iface.ListenPort = 51821
+	if err := m.SaveConfig(dir, iface); err != nil {
+		t.Fatalf("SaveConfig returned error: %v", err)
+	}
+	history, err := m.History(iface)
+	if err != nil {
+		t.Fatalf("History() returned error: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("len(History()) = %d, want 1", len(history))
+	}
+	old, err := m.ReadBackup(history[0])
+	if err != nil {
+		t.Fatalf("ReadBackup() returned error: %v", err)
+	}
+	if !strings.Contains(old, "ListenPort = 51820") {
+		t.Errorf("backup = %q, want the previous version", old)
+	}
+	if info, err := os.Stat(history[0].Path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("backup mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+
+	// LoadConfigsFromDir must not pick up the history directory.
+	configs, err := m.LoadConfigsFromDir(dir)
+	if err != nil || len(configs) != 1 {
+		t.Errorf("LoadConfigsFromDir() = %d configs, %v; want 1", len(configs), err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+	iface := &Interface{Name: "wg0", Dir: dir, PrivateKey: testPrivKey, ListenPort: 1}
+
+	for port := 1; port <= 3; port++ {
+		iface.ListenPort = port
+		if err := m.SaveConfig(dir, iface); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, _ := m.History(iface)
+	if len(history) != 2 {
+		t.Fatalf("len(History()) = %d, want 2", len(history))
+	}
+	// Newest first: port 2, then port 1.
+	restored, err := m.Restore(iface, history[1])
+	if err != nil {
+		t.Fatalf("Restore() returned error: %v", err)
+	}
+	if restored.ListenPort != 1 || restored.Name != "wg0" || restored.Dir != dir {
+		t.Errorf("Restore() = port %d name %q dir %q", restored.ListenPort, restored.Name, restored.Dir)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "wg0.conf"))
+	if !strings.Contains(string(data), "ListenPort = 1\n") {
+		t.Errorf("config after restore = %q", data)
+	}
+	// The replaced version (port 3) is now the newest backup.
+	history, _ = m.History(iface)
+	newest, _ := m.ReadBackup(history[0])
+	if len(history) != 3 || !strings.Contains(newest, "ListenPort = 3") {
+		t.Errorf("history after restore: %d backups, newest %q", len(history), newest)
+	}
+}
+
+func TestHistoryIsPruned(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+	iface := &Interface{Name: "wg0", Dir: dir, PrivateKey: testPrivKey}
+
+	for port := 1; port <= maxHistory+5; port++ {
+		iface.ListenPort = port
+		if err := m.SaveConfig(dir, iface); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, _ := m.History(iface)
+	if len(history) != maxHistory {
+		t.Errorf("len(History()) = %d, want %d", len(history), maxHistory)
+	}
+}
+
+func TestDeleteConfigKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	m := newTestManager(t)
+	iface := &Interface{Name: "wg0", Dir: dir, PrivateKey: testPrivKey}
+	if err := m.SaveConfig(dir, iface); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteConfig(dir, "wg0"); err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := m.History(iface); len(history) != 1 {
+		t.Errorf("len(History()) after delete = %d, want 1", len(history))
+	}
+}
+
+func TestWriteFileLeavesNoTemporaries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wg0.conf")
+	m := newTestManager(t)
+	for _, content := range []string{"one\n", "two\n"} {
+		if err := m.writeFile(path, []byte(content)); err != nil {
+			t.Fatalf("writeFile returned error: %v", err)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries after writes, want only wg0.conf", len(entries))
+	}
+	if data, _ := os.ReadFile(path); string(data) != "two\n" {
+		t.Errorf("content = %q, want %q", data, "two\n")
+	}
+}
+
+func TestWriteFilePrivileged(t *testing.T) {
+	// Run the helper script for real, without escalation, to check it
+	// replaces the file atomically with mode 0600.
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wg0.conf")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manager{runner: ExecRunner{Privileged: directPrivileged{name: PrivilegeRoot}}}
+
+	if err := m.writeFilePrivileged(path, []byte("new\n")); err != nil {
+		t.Fatalf("writeFilePrivileged returned error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	info, _ := os.Stat(path)
+	if string(data) != "new\n" || info.Mode().Perm() != 0600 {
+		t.Errorf("after write: content %q mode %v, want %q 0600", data, info.Mode().Perm(), "new\n")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only wg0.conf", len(entries))
+	}
+}
+
+func TestPrivilegedFileCommands(t *testing.T) {
+	// The fallbacks must run commands sudoers can list: no shell, absolute
+	// paths (see examples/wireguard-tui.sudoers).
+	const tmp = "/etc/wireguard/.wg0.conf.tmp-a1b2c3"
+	m := newTestManager(t,
+		fakeCall{cmd: "ls /etc/wireguard", stdout: "wg0.conf\nwork vpn.conf\n"},
+		fakeCall{cmd: "cat /etc/wireguard/gone.conf", exit: 1, stderr: "cat: /etc/wireguard/gone.conf: No such file or directory"},
+		fakeCall{cmd: "test -e /etc/wireguard/gone.conf", exit: 1},
+		fakeCall{cmd: "mktemp /etc/wireguard/.wg0.conf.tmp-XXXXXX", stdout: tmp + "\n"},
+		fakeCall{cmd: "dd of=" + tmp + " conv=fsync status=none"},
+		fakeCall{cmd: "mv " + tmp + " /etc/wireguard/wg0.conf"},
+		fakeCall{cmd: "mktemp /etc/wireguard/.wg0.conf.tmp-XXXXXX", stdout: tmp + "\n"},
+		fakeCall{cmd: "dd of=" + tmp + " conv=fsync status=none", exit: 1, stderr: "dd: No space left on device"},
+		fakeCall{cmd: "rm " + tmp},
+	)
+
+	names, err := m.listDirPrivileged("/etc/wireguard")
+	if err != nil || strings.Join(names, "|") != "wg0.conf|work vpn.conf" {
+		t.Errorf("listDirPrivileged() = %q, %v", names, err)
+	}
+	if _, err := m.readFilePrivileged("/etc/wireguard/gone.conf"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("readFilePrivileged(missing) error = %v, want fs.ErrNotExist", err)
+	}
+	if err := m.writeFilePrivileged("/etc/wireguard/wg0.conf", []byte("[Interface]\n")); err != nil {
+		t.Errorf("writeFilePrivileged() returned error: %v", err)
+	}
+	if err := m.writeFilePrivileged("/etc/wireguard/wg0.conf", []byte("[Interface]\n")); err == nil {
+		t.Error("writeFilePrivileged() with a failing dd succeeded")
+	}
+}
@@ -1,96 +1,109 @@
 package wg
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
-// Up brings up the named WireGuard interface by running `wg-quick up <name>`.
-// On failure the combined stdout/stderr output is included in the error.
-func Up(name string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
+// cmdTimeout is the maximum duration for any subprocess invocation.
+const cmdTimeout = 5 * time.Second
+
+// promptTimeout replaces cmdTimeout for privileged commands whose helper
+// may be waiting for the user to authenticate (see Prompter).
+const promptTimeout = 2 * time.Minute
 
-	cmd := exec.CommandContext(ctx, "sudo", "wg-quick", "up", name)
-	output, err := cmd.CombinedOutput()
+// runWg executes `wg <args>` with privileges and returns trimmed stdout.
+// Used for commands that require NET_ADMIN (show, etc.).
+func (m *Manager) runWg(args ...string) (string, error) {
+	out, err := m.run(Command{Name: "wg", Args: args, Privileged: true})
 	if err != nil {
-		return fmt.Errorf("wg-quick up %s: %w: %s", name, err, strings.TrimSpace(string(output)))
+		return "", err
 	}
-	return nil
+	return strings.TrimSpace(string(out)), nil
 }
 
-// Down brings down the named WireGuard interface by running `wg-quick down <name>`.
+// interfaceName returns the interface a wg-quick target refers to: the
+// target itself, or the config file name without .conf for a path.
+func interfaceName(target string) string {
+	return strings.TrimSuffix(filepath.Base(target), ".conf")
+}
+
+// Up brings up a WireGuard interface by running `wg-quick up <target>`.
+// The target is an interface name or a config path, see Interface.Target.
 // On failure the combined stdout/stderr output is included in the error.
-func Down(name string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
+func (m *Manager) Up(target string) error {
+	_, err := m.run(Command{Name: "wg-quick", Args: []string{"up", target}, Privileged: true})
+	return err
+}
 
-	cmd := exec.CommandContext(ctx, "sudo", "wg-quick", "down", name)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("wg-quick down %s: %w: %s", name, err, strings.TrimSpace(string(output)))
-	}
-	return nil
+// Down brings down a WireGuard interface by running `wg-quick down <target>`.
+// On failure the combined stdout/stderr output is included in the error.
+func (m *Manager) Down(target string) error {
+	_, err := m.run(Command{Name: "wg-quick", Args: []string{"down", target}, Privileged: true})
+	return err
 }
 
-// IsUp reports whether the named WireGuard interface is currently active.
+// IsUp reports whether the WireGuard interface for target (a name or
+// config path) is currently active.
 // It runs `wg show <name>` and returns true if the command exits 0,
 // false if the command exits with a non-zero status (interface not found/down),
-// and a non-nil error only for unexpected failures (e.g. wg binary not found).
-func IsUp(name string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "sudo", "wg", "show", name)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		// Distinguish between "interface not found" (exit code 1) and
-		// unexpected errors (binary missing, timeout, etc.).
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			// Non-zero exit from wg means the interface is not up.
-			_ = exitErr
-			_ = output
-			return false, nil
-		}
-		return false, fmt.Errorf("wg show %s: %w: %s", name, err, strings.TrimSpace(string(output)))
+// and a non-nil error only for unexpected failures (e.g. wg binary not found,
+// or the privilege helper wanting a password).
+func (m *Manager) IsUp(target string) (bool, error) {
+	_, err := m.run(Command{Name: "wg", Args: []string{"show", interfaceName(target)}, Privileged: true})
+	if err == nil {
+		return true, nil
+	}
+	// A refused escalation also exits non-zero; it must not read as "down".
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) && !errors.Is(err, ErrPasswordRequired) {
+		// Non-zero exit from wg means the interface is not up.
+		return false, nil
 	}
-	return true, nil
+	return false, err
 }
 
-// Toggle flips the state of the named WireGuard interface: if it is currently
-// up it is brought down, and vice versa. It returns the new state (true = up).
-func Toggle(name string) (nowUp bool, err error) {
-	up, err := IsUp(name)
+// Toggle flips the state of a WireGuard interface given by name or config
+// path: if it is currently up it is brought down, and vice versa. It returns the new state (true = up).
+//
+// Toggles of the same interface through one Manager are serialized. If
+// wg-quick fails because something else changed the state between the check
+// and the change (e.g. "already exists"), the state is checked again and the
+// toggle succeeds when the interface ended up where it was headed.
+func (m *Manager) Toggle(target string) (nowUp bool, err error) {
+	defer m.lock(interfaceName(target))()
+
+	up, err := m.IsUp(target)
 	if err != nil {
 		return false, fmt.Errorf("checking interface state: %w", err)
 	}
 
+	change := m.Up
 	if up {
-		if err := Down(name); err != nil {
-			return false, err
-		}
-		return false, nil
+		change = m.Down
 	}
-
-	if err := Up(name); err != nil {
-		return false, err
+	if err := change(target); err != nil {
+		if now, checkErr := m.IsUp(target); checkErr == nil && now != up {
+			return now, nil
+		}
+		return up, err
 	}
-	return true, nil
+	return !up, nil
 }
 
-// ListInterfaces returns the names of all active WireGuard interfaces by
-// running `wg show interfaces` and splitting the output on whitespace.
-// An empty slice is returned when no interfaces are active.
-func ListInterfaces() ([]string, error) {
-	out, err := runSudoWgCmd("show", "interfaces")
+// ListInterfaces returns the names of all active WireGuard interfaces from
+// the Manager's backend: netlink when available, `wg show interfaces`
+// otherwise. An empty slice is returned when no interfaces are active.
+func (m *Manager) ListInterfaces() ([]string, error) {
+	names, err := m.backend.Interfaces()
 	if err != nil {
-		return nil, fmt.Errorf("listing interfaces: %w", err)
+		return nil, err
 	}
-
-	if out == "" {
-		return []string{}, nil
+	if names == nil {
+		names = []string{}
 	}
-	return strings.Fields(out), nil
+	return names, nil
 }
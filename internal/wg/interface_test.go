@@ -1,30 +1,337 @@
 package wg
 
 import (
-	"os/exec"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
-func TestListInterfacesDoesNotError(t *testing.T) {
-	if err := exec.Command("sudo", "-n", "true").Run(); err != nil {
-		t.Skip("skipping: passwordless sudo not available")
-	}
-	// ListInterfaces should not error even if no interfaces are up.
-	_, err := ListInterfaces()
+const alreadyExists = "wg-quick: `wg0' already exists\n"
+
+func TestListInterfacesEmpty(t *testing.T) {
+	m := newTestManager(t, fakeCall{cmd: "wg show interfaces"})
+
+	names, err := m.ListInterfaces()
 	if err != nil {
 		t.Fatalf("ListInterfaces() returned error: %v", err)
 	}
+	if names == nil || len(names) != 0 {
+		t.Errorf("ListInterfaces() = %#v, want empty non-nil slice", names)
+	}
+}
+
+func TestIsUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		call    fakeCall
+		want    bool
+		wantErr error
+	}{
+		{"up", fakeCall{cmd: "wg show wg0", stdout: "interface: wg0\n"}, true, nil},
+		{"nonexistent", fakeCall{cmd: "wg show wg0", exit: 1, stderr: "Unable to access interface: No such device\n"}, false, nil},
+		{"password", fakeCall{cmd: "wg show wg0", exit: 1, stderr: "sudo: a password is required\n"}, false, ErrPasswordRequired},
+		{"not run", fakeCall{cmd: "wg show wg0", err: os.ErrNotExist}, false, os.ErrNotExist},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestManager(t, tc.call)
+			up, err := m.IsUp("wg0")
+			if !errors.Is(err, tc.wantErr) || (err != nil) != (tc.wantErr != nil) {
+				t.Fatalf("IsUp() error = %v, want %v", err, tc.wantErr)
+			}
+			if up != tc.want {
+				t.Errorf("IsUp() = %v, want %v", up, tc.want)
+			}
+		})
+	}
+}
+
+func TestToggle(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  []fakeCall
+		want    bool
+		wantErr string
+	}{
+		{
+			name: "down to up",
+			script: []fakeCall{
+				{cmd: "wg show wg0", exit: 1},
+				{cmd: "wg-quick up wg0"},
+			},
+			want: true,
+		},
+		{
+			name: "up to down",
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg-quick down wg0"},
+			},
+			want: false,
+		},
+		{
+			name: "raced up by someone else",
+			script: []fakeCall{
+				{cmd: "wg show wg0", exit: 1},
+				{cmd: "wg-quick up wg0", exit: 1, stderr: alreadyExists},
+				{cmd: "wg show wg0"},
+			},
+			want: true,
+		},
+		{
+			name: "raced down by someone else",
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg-quick down wg0", exit: 1, stderr: "wg-quick: `wg0' is not a WireGuard interface\n"},
+				{cmd: "wg show wg0", exit: 1},
+			},
+			want: false,
+		},
+		{
+			name: "up fails",
+			script: []fakeCall{
+				{cmd: "wg show wg0", exit: 1},
+				{cmd: "wg-quick up wg0", exit: 1, stderr: "RTNETLINK answers: Operation not supported\n"},
+				{cmd: "wg show wg0", exit: 1},
+			},
+			wantErr: "wg-quick up wg0: exit status 1: RTNETLINK answers: Operation not supported",
+		},
+		{
+			name: "down fails and recheck fails",
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg-quick down wg0", exit: 1, stderr: "boom\n"},
+				{cmd: "wg show wg0", exit: 1, stderr: "sudo: a password is required\n"},
+			},
+			want:    true,
+			wantErr: "wg-quick down wg0: exit status 1: boom",
+		},
+		{
+			name: "state check fails",
+			script: []fakeCall{
+				{cmd: "wg show wg0", exit: 1, stderr: "sudo: a password is required\n"},
+			},
+			wantErr: "checking interface state: wg show wg0: password required",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestManager(t, tc.script...)
+			nowUp, err := m.Toggle("wg0")
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Fatalf("Toggle() returned error: %v", err)
+			case tc.wantErr != "" && (err == nil || err.Error() != tc.wantErr):
+				t.Fatalf("Toggle() error = %v, want %q", err, tc.wantErr)
+			}
+			if nowUp != tc.want {
+				t.Errorf("Toggle() = %v, want %v", nowUp, tc.want)
+			}
+		})
+	}
+}
+
+func TestToggleSerializesSameInterface(t *testing.T) {
+	// Interleaved toggles would run both `wg show` checks first and break
+	// the script; serialized ones bring wg0 up and then down again.
+	m := newTestManager(t,
+		fakeCall{cmd: "wg show wg0", exit: 1},
+		fakeCall{cmd: "wg-quick up wg0"},
+		fakeCall{cmd: "wg show wg0"},
+		fakeCall{cmd: "wg-quick down wg0"},
+	)
+
+	var wg sync.WaitGroup
+	results := make(chan bool, 2)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nowUp, err := m.Toggle("wg0")
+			if err != nil {
+				t.Errorf("Toggle() returned error: %v", err)
+			}
+			results <- nowUp
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var ups int
+	for up := range results {
+		if up {
+			ups++
+		}
+	}
+	if ups != 1 {
+		t.Errorf("got %d toggles ending up, want exactly 1", ups)
+	}
+}
+
+func TestDeleteProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   []fakeCall
+		wantErr  string
+		wantFile bool
+	}{
+		{
+			name:   "down interface",
+			script: []fakeCall{{cmd: "wg show wg0", exit: 1}},
+		},
+		{
+			name: "up interface is brought down first",
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg-quick down $CONF"},
+			},
+		},
+		{
+			name: "config kept when down fails",
+			script: []fakeCall{
+				{cmd: "wg show wg0"},
+				{cmd: "wg-quick down $CONF", exit: 1, stderr: "boom\n"},
+			},
+			wantErr:  "wg-quick down $CONF: exit status 1: boom",
+			wantFile: true,
+		},
+		{
+			name:     "config kept when state is unknown",
+			script:   []fakeCall{{cmd: "wg show wg0", exit: 1, stderr: "sudo: a password is required\n"}},
+			wantErr:  "checking interface state: wg show wg0: password required",
+			wantFile: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "wg0.conf")
+			if err := os.WriteFile(path, []byte("[Interface]\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			// Profiles outside /etc/wireguard are addressed by path.
+			script := make([]fakeCall, len(tc.script))
+			for i, call := range tc.script {
+				call.cmd = strings.ReplaceAll(call.cmd, "$CONF", path)
+				script[i] = call
+			}
+			wantErr := strings.ReplaceAll(tc.wantErr, "$CONF", path)
+
+			m := newTestManager(t, script...)
+			err := m.DeleteProfile(&Interface{Name: "wg0", Dir: dir})
+			switch {
+			case wantErr == "" && err != nil:
+				t.Fatalf("DeleteProfile() returned error: %v", err)
+			case wantErr != "" && (err == nil || err.Error() != wantErr):
+				t.Fatalf("DeleteProfile() error = %v, want %q", err, wantErr)
+			}
+			if _, err := os.Stat(path); (err == nil) != tc.wantFile {
+				t.Errorf("config exists = %v, want %v", err == nil, tc.wantFile)
+			}
+		})
+	}
+}
+
+func TestManagerGetStatus(t *testing.T) {
+	m := newTestManager(t, fakeCall{
+		cmd:    "wg show wg0 dump",
+		stdout: "(hidden)\t" + testPubKey1 + "\t51820\toff\n" + testPubKey2 + "\t(none)\t(none)\t10.0.0.2/32\t0\t0\t0\toff\n",
+	})
+
+	st, err := m.GetStatus("wg0")
+	if err != nil {
+		t.Fatalf("GetStatus() returned error: %v", err)
+	}
+	if st.ListenPort != 51820 || len(st.Peers) != 1 || st.Peers[0].PublicKey != testPubKey2 {
+		t.Errorf("GetStatus() = %+v", st)
+	}
+}
+
+func TestInterfaceTarget(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"", "wg0"},
+		{"/etc/wireguard", "wg0"},
+		{"/etc/wireguard/", "wg0"},
+		{"/home/me/.config/wireguard", "/home/me/.config/wireguard/wg0.conf"},
+	}
+
+	for _, tc := range tests {
+		iface := &Interface{Name: "wg0", Dir: tc.dir}
+		if got := iface.Target(); got != tc.want {
+			t.Errorf("Target() with Dir %q = %q, want %q", tc.dir, got, tc.want)
+		}
+		if got := interfaceName(iface.Target()); got != "wg0" {
+			t.Errorf("interfaceName(%q) = %q, want wg0", iface.Target(), got)
+		}
+	}
 }
 
-func TestIsUpReturnsFalseForNonexistent(t *testing.T) {
-	if err := exec.Command("sudo", "-n", "true").Run(); err != nil {
-		t.Skip("skipping: passwordless sudo not available")
+func TestToggleByPath(t *testing.T) {
+	m := newTestManager(t,
+		fakeCall{cmd: "wg show wg1", exit: 1},
+		fakeCall{cmd: "wg-quick up /tmp/profiles/wg1.conf"},
+	)
+	nowUp, err := m.Toggle("/tmp/profiles/wg1.conf")
+	if err != nil || !nowUp {
+		t.Errorf("Toggle() = %v, %v, want true, nil", nowUp, err)
 	}
-	up, err := IsUp("wg_nonexistent_test_12345")
+}
+
+func TestLoadProfilesMergesRoots(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeConf := func(dir, name string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name+".conf"), []byte("[Interface]\nPrivateKey = "+testPrivKey+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConf(first, "wg1")
+	writeConf(second, "wg0")
+	writeConf(second, "wg2")
+
+	m := newTestManager(t)
+	m.SetConfigDirs([]string{first, filepath.Join(first, "missing"), second})
+	profiles, err := m.LoadProfiles()
 	if err != nil {
-		t.Fatalf("IsUp() returned unexpected error: %v", err)
+		t.Fatalf("LoadProfiles() returned error: %v", err)
+	}
+
+	var got []string
+	for _, p := range profiles {
+		got = append(got, p.Dir+":"+p.Name)
+	}
+	want := []string{first + ":wg1", second + ":wg0", second + ":wg2"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("LoadProfiles() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadProfilesKeepsGoodRoots(t *testing.T) {
+	good, bad := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(good, "wg0.conf"), []byte("[Interface]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bad, "broken.conf"), []byte("not a config\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestManager(t)
+	m.SetConfigDirs([]string{bad, good})
+	profiles, err := m.LoadProfiles()
+	if err == nil || !strings.Contains(err.Error(), "parsing broken.conf") {
+		t.Errorf("LoadProfiles() error = %v, want parse error for broken.conf", err)
 	}
-	if up {
-		t.Error("IsUp() returned true for nonexistent interface, want false")
+	if len(profiles) != 1 || profiles[0].Name != "wg0" {
+		t.Errorf("LoadProfiles() = %v, want wg0 from the readable root", profiles)
 	}
 }
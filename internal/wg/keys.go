@@ -1,116 +1,138 @@
 package wg
 
 import (
-	"bytes"
-	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
-	"os/exec"
-	"strings"
-	"time"
-)
 
-// cmdTimeout is the maximum duration for any wg subprocess invocation.
-const cmdTimeout = 5 * time.Second
+	"golang.org/x/crypto/curve25519"
+)
 
-// runWgCmd executes the wg binary with the given arguments and returns its
-// trimmed stdout. A context timeout of cmdTimeout is applied.
-func runWgCmd(args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
+// KeyLen is the length in bytes of WireGuard private, public and preshared
+// keys.
+const KeyLen = 32
 
-	cmd := exec.CommandContext(ctx, "wg", args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// Key is a WireGuard key: a Curve25519 private or public key, or a
+// preshared key. Its String and Format methods never reveal the key
+// material, so a Key passed to a logger or fmt verb can't leak a private
+// key; use Base64 to get the config-file encoding.
+type Key [KeyLen]byte
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("wg %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+// ParseKey decodes a base64-encoded key as found in a config file.
+func ParseKey(s string) (Key, error) {
+	var k Key
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return k, fmt.Errorf("invalid key: not base64")
 	}
+	if len(b) != KeyLen {
+		return k, fmt.Errorf("invalid key: %d bytes, want %d", len(b), KeyLen)
+	}
+	copy(k[:], b)
+	return k, nil
+}
 
-	return strings.TrimSpace(stdout.String()), nil
+// NewPrivateKey returns a random Curve25519 private key, clamped as
+// described in RFC 7748 section 5 (the same as `wg genkey`).
+func NewPrivateKey() (Key, error) {
+	k, err := NewPresharedKey()
+	if err != nil {
+		return k, err
+	}
+	k[0] &= 248
+	k[31] = (k[31] & 127) | 64
+	return k, nil
 }
 
-// runWgCmdWithInput executes the wg binary with the given arguments, piping
-// input to its stdin, and returns trimmed stdout. A context timeout of
-// cmdTimeout is applied.
-func runWgCmdWithInput(input string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "wg", args...)
-	cmd.Stdin = strings.NewReader(input)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("wg %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+// NewPresharedKey returns 32 random bytes for use as a preshared key.
+func NewPresharedKey() (Key, error) {
+	var k Key
+	if _, err := rand.Read(k[:]); err != nil {
+		return k, fmt.Errorf("reading random bytes: %w", err)
 	}
+	return k, nil
+}
 
-	return strings.TrimSpace(stdout.String()), nil
+// PublicKey derives the public key for private key k. Like `wg pubkey`, it
+// accepts unclamped private keys; X25519 clamps the scalar itself.
+func (k Key) PublicKey() Key {
+	var pub Key
+	// X25519 only fails when the result is the all-zero point, which a
+	// multiple of the base point never is.
+	out, _ := curve25519.X25519(k[:], curve25519.Basepoint)
+	copy(pub[:], out)
+	return pub
 }
 
-// runSudoWgCmd executes `sudo wg <args>` and returns trimmed stdout.
-// Used for commands that require NET_ADMIN (show, etc.).
-func runSudoWgCmd(args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
-	defer cancel()
+// Base64 returns the key in the base64 encoding used by config files and
+// the wg tool.
+func (k Key) Base64() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
 
-	fullArgs := append([]string{"wg"}, args...)
-	cmd := exec.CommandContext(ctx, "sudo", fullArgs...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// IsZero reports whether the key is all zeros (unset).
+func (k Key) IsZero() bool {
+	return k == Key{}
+}
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("sudo wg %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+// String returns a placeholder instead of the key material.
+func (k Key) String() string {
+	if k.IsZero() {
+		return "(none)"
 	}
+	return "(hidden)"
+}
 
-	return strings.TrimSpace(stdout.String()), nil
+// GoString keeps %#v from printing the key bytes.
+func (k Key) GoString() string {
+	return "wg.Key" + k.String()
+}
+
+// Format makes every fmt verb, including %x and %v, print the placeholder.
+func (k Key) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		fmt.Fprint(f, k.GoString())
+		return
+	}
+	fmt.Fprint(f, k.String())
 }
 
-// GeneratePrivateKey generates a new WireGuard private key by calling `wg genkey`.
-// The returned key is a base64-encoded 32-byte Curve25519 private key.
+// GeneratePrivateKey generates a new WireGuard private key. The returned
+// key is a base64-encoded 32-byte Curve25519 private key.
 func GeneratePrivateKey() (string, error) {
-	key, err := runWgCmd("genkey")
+	k, err := NewPrivateKey()
 	if err != nil {
 		return "", fmt.Errorf("generating private key: %w", err)
 	}
-	return key, nil
+	return k.Base64(), nil
 }
 
-// DerivePublicKey derives the public key from a WireGuard private key by
-// piping it to `wg pubkey`. The derivation is deterministic.
+// DerivePublicKey derives the public key from a base64-encoded WireGuard
+// private key. The derivation is deterministic.
 func DerivePublicKey(privateKey string) (string, error) {
-	key, err := runWgCmdWithInput(privateKey, "pubkey")
+	k, err := ParseKey(privateKey)
 	if err != nil {
 		return "", fmt.Errorf("deriving public key: %w", err)
 	}
-	return key, nil
+	return k.PublicKey().Base64(), nil
 }
 
-// GeneratePresharedKey generates a new WireGuard preshared key by calling
-// `wg genpsk`. The returned key is a base64-encoded 32-byte random value.
+// GeneratePresharedKey generates a new WireGuard preshared key. The
+// returned key is a base64-encoded 32-byte random value.
 func GeneratePresharedKey() (string, error) {
-	key, err := runWgCmd("genpsk")
+	k, err := NewPresharedKey()
 	if err != nil {
 		return "", fmt.Errorf("generating preshared key: %w", err)
 	}
-	return key, nil
+	return k.Base64(), nil
 }
 
 // GenerateKeyPair generates a new WireGuard private key and derives its
 // corresponding public key. Both are returned as base64-encoded strings.
 func GenerateKeyPair() (privateKey, publicKey string, err error) {
-	privateKey, err = GeneratePrivateKey()
+	k, err := NewPrivateKey()
 	if err != nil {
-		return "", "", err
+		return "", "", fmt.Errorf("generating private key: %w", err)
 	}
-
-	publicKey, err = DerivePublicKey(privateKey)
-	if err != nil {
-		return "", "", err
-	}
-
-	return privateKey, publicKey, nil
+	return k.Base64(), k.PublicKey().Base64(), nil
 }
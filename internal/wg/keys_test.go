@@ -2,6 +2,9 @@ package wg
 
 import (
 	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -143,3 +146,76 @@ func TestDerivePublicKeyInvalidInput(t *testing.T) {
 		t.Error("DerivePublicKey() with invalid input should return error, got nil")
 	}
 }
+
+func TestKeyPublicKeyRFC7748(t *testing.T) {
+	// Alice's key pair from RFC 7748 section 6.1.
+	privBytes, _ := hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	wantPub, _ := hex.DecodeString("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+
+	priv, err := ParseKey(base64.StdEncoding.EncodeToString(privBytes))
+	if err != nil {
+		t.Fatalf("ParseKey() returned error: %v", err)
+	}
+	pub := priv.PublicKey()
+	if got := hex.EncodeToString(pub[:]); got != hex.EncodeToString(wantPub) {
+		t.Errorf("PublicKey() = %s, want %x", got, wantPub)
+	}
+
+	derived, err := DerivePublicKey(priv.Base64())
+	if err != nil {
+		t.Fatalf("DerivePublicKey() returned error: %v", err)
+	}
+	if derived != base64.StdEncoding.EncodeToString(wantPub) {
+		t.Errorf("DerivePublicKey() = %s, want %s", derived, base64.StdEncoding.EncodeToString(wantPub))
+	}
+}
+
+func TestNewPrivateKeyIsClamped(t *testing.T) {
+	for i := 0; i < 32; i++ {
+		k, err := NewPrivateKey()
+		if err != nil {
+			t.Fatalf("NewPrivateKey() returned error: %v", err)
+		}
+		if k[0]&7 != 0 || k[31]&128 != 0 || k[31]&64 == 0 {
+			t.Fatalf("NewPrivateKey() = %x is not clamped", k[:])
+		}
+	}
+}
+
+func TestParseKeyInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"not base64", "not-a-valid-key"},
+		{"too short", base64.StdEncoding.EncodeToString(make([]byte, 16))},
+		{"too long", base64.StdEncoding.EncodeToString(make([]byte, 33))},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseKey(tc.input); err == nil {
+				t.Errorf("ParseKey(%q) should return error", tc.input)
+			}
+		})
+	}
+}
+
+func TestKeyDoesNotPrint(t *testing.T) {
+	k, err := NewPrivateKey()
+	if err != nil {
+		t.Fatalf("NewPrivateKey() returned error: %v", err)
+	}
+	b64 := k.Base64()
+	hexKey := hex.EncodeToString(k[:])
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%x", "%X", "%q", "%d"} {
+		out := fmt.Sprintf(verb, k)
+		if strings.Contains(out, b64) || strings.Contains(strings.ToLower(out), hexKey) || strings.Contains(out, fmt.Sprint(k[0], " ", k[1])) {
+			t.Errorf("Sprintf(%q, key) = %q leaks key material", verb, out)
+		}
+	}
+	if out := fmt.Sprintf("%v", struct{ K Key }{k}); strings.Contains(out, b64) {
+		t.Errorf("key inside struct printed as %q", out)
+	}
+}
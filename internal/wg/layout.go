@@ -0,0 +1,200 @@
+package wg
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// rawLine is a single config line as it appeared on disk.
+type rawLine struct {
+	key     string // canonical key name; empty for headers, comments and blank lines
+	text    string // the line exactly as read, without the trailing newline
+	eol     string // "\n" or "\r\n"; empty for a last line without one
+	comment string // a key line's trailing comment with the space before it, e.g. "  # office"
+	header  bool   // true for [Interface] / [Peer] lines
+}
+
+// writeLine writes text ending in eol, or in nl when it has none (the last
+// line of a file, when more is written after it).
+func writeLine(b *strings.Builder, text, eol, nl string) {
+	if eol == "" {
+		eol = nl
+	}
+	b.WriteString(text + eol)
+}
+
+// layout remembers how a section was written on disk so that MarshalConfig
+// can reproduce it: every line in order, plus the serialized values of the
+// modelled keys at parse time. A key whose current values still equal orig
+// is written back from its original lines byte-for-byte.
+type layout struct {
+	lines []rawLine
+	orig  map[string][]string
+}
+
+// field is the serialized form of one modelled key. Each value becomes one
+// "Key = value" line; a field with no values is omitted.
+type field struct {
+	key    string
+	values []string
+}
+
+// takeTrailingTrivia removes and returns the comment and blank lines at the
+// end of the layout, including peer metadata comments.
+func (l *layout) takeTrailingTrivia() []rawLine {
+	n := len(l.lines)
+	for n > 0 && (l.lines[n-1].key == "" || isMetaKey(l.lines[n-1].key)) && !l.lines[n-1].header {
+		n--
+	}
+	trivia := slices.Clone(l.lines[n:])
+	l.lines = l.lines[:n]
+	return trivia
+}
+
+// canonicalKey returns the canonical spelling of key if it matches one of
+// known case-insensitively (as wg-quick does), or key unchanged otherwise.
+func canonicalKey(known []string, key string) string {
+	for _, k := range known {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return key
+}
+
+// fieldValues indexes fields by key.
+func fieldValues(fields []field) map[string][]string {
+	m := make(map[string][]string, len(fields))
+	for _, f := range fields {
+		m[f.key] = f.values
+	}
+	return m
+}
+
+// optString returns a single value, or none when s is empty.
+func optString(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// optInt returns a single value, or none when n is zero.
+func optInt(n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return []string{strconv.Itoa(n)}
+}
+
+// optFwMark returns a firewall mark in hex, or none when it is off.
+func optFwMark(mark uint32) []string {
+	if mark == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("0x%x", mark)}
+}
+
+// optBool returns "true", or none when b is false.
+func optBool(b bool) []string {
+	if !b {
+		return nil
+	}
+	return []string{"true"}
+}
+
+// writeFields writes every value of every field not listed in skip, each
+// line ending in nl.
+func writeFields(b *strings.Builder, fields []field, skip map[string]bool, nl string) {
+	for _, f := range fields {
+		if skip[f.key] {
+			continue
+		}
+		for _, v := range f.values {
+			writeLine(b, f.key+" = "+v, nl, nl)
+		}
+	}
+}
+
+// writeSection writes one section. Without a recorded layout the section is
+// written canonically: header, then fields in order. With a layout, the
+// original lines are replayed; modelled keys whose values changed are
+// rewritten at the position of their first line, and keys that were not in
+// the file are appended after the last key line of the section, except
+// metadata comments, which go right after the header so they are not
+// mistaken for the next peer's. A rewritten line keeps the line ending and
+// trailing comment of the original line it replaces; new lines end in nl.
+func writeSection(b *strings.Builder, header string, fields []field, l layout, nl string) {
+	if len(l.lines) == 0 {
+		writeLine(b, header, nl, nl)
+		writeFields(b, fields, nil, nl)
+		return
+	}
+
+	current := fieldValues(fields)
+	present := make(map[string]bool)
+	keyLines := make(map[string][]rawLine)
+	insertAt, headerAt := -1, -1
+	for i, ln := range l.lines {
+		if ln.key != "" {
+			present[ln.key] = true
+			keyLines[ln.key] = append(keyLines[ln.key], ln)
+		}
+		if ln.key != "" || ln.header {
+			insertAt = i
+		}
+		if ln.header && headerAt < 0 {
+			headerAt = i
+		}
+	}
+	// Missing keys written after the header: metadata, and the rest too
+	// when the section has nothing after its header.
+	headerSkip := maps.Clone(present)
+	insertSkip := maps.Clone(present)
+	for _, f := range fields {
+		if isMetaKey(f.key) {
+			insertSkip[f.key] = true
+		} else if insertAt != headerAt {
+			headerSkip[f.key] = true
+		}
+	}
+
+	if insertAt < 0 {
+		// Nothing but comments: the header was never seen.
+		for _, ln := range l.lines {
+			writeLine(b, ln.text, ln.eol, nl)
+		}
+		writeLine(b, header, nl, nl)
+		writeFields(b, fields, nil, nl)
+		return
+	}
+
+	rewritten := make(map[string]bool)
+	for i, ln := range l.lines {
+		values, known := current[ln.key]
+		switch {
+		case ln.key == "" || !known || slices.Equal(values, l.orig[ln.key]):
+			writeLine(b, ln.text, ln.eol, nl)
+		case !rewritten[ln.key]:
+			rewritten[ln.key] = true
+			for j, v := range values {
+				// The j-th value takes over the j-th original line's ending
+				// and comment; values beyond those get plain new lines.
+				orig := rawLine{eol: nl}
+				if j < len(keyLines[ln.key]) {
+					orig = keyLines[ln.key][j]
+				}
+				writeLine(b, ln.key+" = "+v+orig.comment, orig.eol, nl)
+			}
+		}
+		switch i {
+		case headerAt:
+			writeFields(b, fields, headerSkip, nl)
+		case insertAt:
+			writeFields(b, fields, insertSkip, nl)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package wg
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Manager controls WireGuard interfaces and their config files. All
+// external commands go through its Runner, so tests can script them.
+type Manager struct {
+	runner  Runner
+	backend Backend
+	dirs    []string // profile roots; see SetConfigDirs
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // per-interface, serializes Toggle
+}
+
+// NewManager returns a Manager that runs commands with r, reading device
+// state over netlink with the wg CLI as fallback. A nil r runs commands as
+// subprocesses through the detected privilege method.
+func NewManager(r Runner) *Manager {
+	if r == nil {
+		r = ExecRunner{Privileged: DetectPrivileged()}
+	}
+	m := &Manager{runner: r}
+	m.backend = &fallbackBackend{
+		primary:  newNetlinkBackend(),
+		fallback: cliBackend{m: m},
+	}
+	return m
+}
+
+// SetBackend replaces how the Manager reads device state, e.g. with a fake
+// in tests of code built on the Manager.
+func (m *Manager) SetBackend(b Backend) {
+	m.backend = b
+}
+
+// SetConfigDirs sets the profile roots LoadProfiles reads. New profiles
+// belong in the first one. With no roots, DefaultConfigDir is used.
+func (m *Manager) SetConfigDirs(dirs []string) {
+	m.dirs = append([]string(nil), dirs...)
+}
+
+// ConfigDirs returns the profile roots, primary first.
+func (m *Manager) ConfigDirs() []string {
+	if len(m.dirs) == 0 {
+		return []string{DefaultConfigDir}
+	}
+	return append([]string(nil), m.dirs...)
+}
+
+// lock acquires the lock for the named interface and returns its release.
+func (m *Manager) lock(name string) func() {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*sync.Mutex)
+	}
+	l, ok := m.locks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[name] = l
+	}
+	m.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// run executes cmd through the runner with its timeout (see timeout). Failures
+// are returned as "<cmd>: <err>: <output>", wrapping ErrPasswordRequired
+// when the privilege helper refused to run without a password.
+func (m *Manager) run(cmd Command) (stdout []byte, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout(cmd))
+	defer cancel()
+
+	stdout, stderr, err := m.runner.Run(ctx, cmd)
+	if err != nil {
+		return stdout, privilegedError(cmd.String(), err, commandOutput(stdout, stderr))
+	}
+	return stdout, nil
+}
+
+// timeout returns how long cmd may run: cmdTimeout, or promptTimeout for
+// a privileged command whose helper may be showing a password dialog.
+func (m *Manager) timeout(cmd Command) time.Duration {
+	if p, ok := m.privilegeMethod().(Prompter); ok && cmd.Privileged && p.Prompts() {
+		return promptTimeout
+	}
+	return cmdTimeout
+}
+
+// privilegeMethod returns the privilege method behind the runner, or the
+// runner itself when it is not an ExecRunner.
+func (m *Manager) privilegeMethod() any {
+	if er, ok := m.runner.(ExecRunner); ok {
+		return er.Privileged
+	}
+	return m.runner
+}
+
+// authenticator returns the password handler behind the runner, if any.
+func (m *Manager) authenticator() (Authenticator, bool) {
+	auth, ok := m.privilegeMethod().(Authenticator)
+	return auth, ok
+}
+
+// AcceptsPassword reports whether the privilege method can take a password
+// through Authenticate.
+func (m *Manager) AcceptsPassword() bool {
+	_, ok := m.authenticator()
+	return ok
+}
+
+// NeedsPassword reports whether the privilege method is waiting for a
+// password. Methods that can't take one from the UI report false.
+func (m *Manager) NeedsPassword() (bool, error) {
+	auth, ok := m.authenticator()
+	if !ok {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
+	defer cancel()
+	return auth.NeedsPassword(ctx)
+}
+
+// Authenticate passes password to the privilege method.
+func (m *Manager) Authenticate(password string) error {
+	auth, ok := m.authenticator()
+	if !ok {
+		return fmt.Errorf("privilege method does not accept a password")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
+	defer cancel()
+	return auth.Authenticate(ctx, password)
+}
@@ -0,0 +1,530 @@
+package wg
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Netlink constants from linux/netlink.h, linux/genetlink.h and
+// linux/wireguard.h. They are spelled out here rather than taken from
+// x/sys/unix so the protocol code builds, and is tested, on every OS.
+const (
+	nlmsgHdrLen = 16
+	genlHdrLen  = 4
+	nlaHdrLen   = 4
+
+	nlmsgError = 0x2
+	nlmsgDone  = 0x3
+
+	nlmFRequest = 0x1
+	nlmFMulti   = 0x2
+	nlmFAck     = 0x4
+	nlmFDump    = 0x300
+
+	nlaTypeMask = 0x3fff // strips NLA_F_NESTED and NLA_F_NET_BYTEORDER
+
+	genlIDCtrl         = 0x10
+	ctrlCmdGetFamily   = 3
+	ctrlVersion        = 1
+	ctrlAttrFamilyID   = 1
+	ctrlAttrFamilyName = 2
+
+	wgGenlName     = "wireguard"
+	wgGenlVersion  = 1
+	wgCmdGetDevice = 0
+
+	wgDeviceAIfname     = 2
+	wgDeviceAPublicKey  = 4
+	wgDeviceAListenPort = 6
+	wgDeviceAFwmark     = 7
+	wgDeviceAPeers      = 8
+
+	wgPeerAPublicKey         = 1
+	wgPeerAPresharedKey      = 2
+	wgPeerAEndpoint          = 4
+	wgPeerAKeepaliveInterval = 5
+	wgPeerALastHandshakeTime = 6
+	wgPeerARxBytes           = 7
+	wgPeerATxBytes           = 8
+	wgPeerAAllowedIPs        = 9
+
+	wgAllowedIPAFamily   = 1
+	wgAllowedIPAIPAddr   = 2
+	wgAllowedIPACidrMask = 3
+
+	afInet  = 2
+	afInet6 = 10
+)
+
+// nlTransport is a netlink socket: requests go out as single datagrams and
+// each Receive returns one datagram, which may hold several messages.
+type nlTransport interface {
+	Send(b []byte) error
+	Receive() ([]byte, error)
+	Close() error
+}
+
+// nlMessage is one netlink message with its header fields decoded.
+type nlMessage struct {
+	typ   uint16
+	flags uint16
+	seq   uint32
+	data  []byte
+}
+
+// nlAttr is one netlink attribute. typ has the flag bits masked off.
+type nlAttr struct {
+	typ  uint16
+	data []byte
+}
+
+// nlConn sends requests over a transport and collects their replies.
+type nlConn struct {
+	t   nlTransport
+	seq uint32
+}
+
+// execute sends one request and returns its reply messages. Multipart
+// replies are read up to NLMSG_DONE; an NLMSG_ERROR reply is returned as a
+// syscall.Errno, or ends the exchange successfully if it is an ACK.
+func (c *nlConn) execute(typ, flags uint16, data []byte) ([]nlMessage, error) {
+	c.seq++
+	req := make([]byte, nlmsgHdrLen, nlmsgHdrLen+len(data))
+	binary.NativeEndian.PutUint32(req[0:4], uint32(nlmsgHdrLen+len(data)))
+	binary.NativeEndian.PutUint16(req[4:6], typ)
+	binary.NativeEndian.PutUint16(req[6:8], flags|nlmFRequest)
+	binary.NativeEndian.PutUint32(req[8:12], c.seq)
+	req = append(req, data...)
+
+	if err := c.t.Send(req); err != nil {
+		return nil, fmt.Errorf("netlink send: %w", err)
+	}
+
+	var replies []nlMessage
+	for {
+		buf, err := c.t.Receive()
+		if err != nil {
+			return nil, fmt.Errorf("netlink receive: %w", err)
+		}
+		msgs, err := parseNlMessages(buf)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			if m.seq != c.seq {
+				continue // left over from an earlier request
+			}
+			switch m.typ {
+			case nlmsgDone:
+				return replies, nil
+			case nlmsgError:
+				if len(m.data) < 4 {
+					return nil, fmt.Errorf("netlink: short error message")
+				}
+				if code := int32(binary.NativeEndian.Uint32(m.data[:4])); code != 0 {
+					return nil, syscall.Errno(-code)
+				}
+				return replies, nil
+			}
+			replies = append(replies, m)
+			if m.flags&nlmFMulti == 0 && flags&nlmFAck == 0 {
+				return replies, nil
+			}
+		}
+	}
+}
+
+// parseNlMessages splits a datagram into messages.
+func parseNlMessages(b []byte) ([]nlMessage, error) {
+	var msgs []nlMessage
+	for len(b) >= nlmsgHdrLen {
+		n := int(binary.NativeEndian.Uint32(b[0:4]))
+		if n < nlmsgHdrLen || n > len(b) {
+			return nil, fmt.Errorf("netlink: bad message length %d", n)
+		}
+		msgs = append(msgs, nlMessage{
+			typ:   binary.NativeEndian.Uint16(b[4:6]),
+			flags: binary.NativeEndian.Uint16(b[6:8]),
+			seq:   binary.NativeEndian.Uint32(b[8:12]),
+			data:  b[nlmsgHdrLen:n],
+		})
+		b = b[min(nlAlign(n), len(b)):]
+	}
+	return msgs, nil
+}
+
+// parseNlAttrs decodes a run of attributes.
+func parseNlAttrs(b []byte) ([]nlAttr, error) {
+	var attrs []nlAttr
+	for len(b) >= nlaHdrLen {
+		n := int(binary.NativeEndian.Uint16(b[0:2]))
+		if n < nlaHdrLen || n > len(b) {
+			return nil, fmt.Errorf("netlink: bad attribute length %d", n)
+		}
+		attrs = append(attrs, nlAttr{
+			typ:  binary.NativeEndian.Uint16(b[2:4]) & nlaTypeMask,
+			data: b[nlaHdrLen:n],
+		})
+		b = b[min(nlAlign(n), len(b)):]
+	}
+	return attrs, nil
+}
+
+// appendNlAttr encodes an attribute onto b.
+func appendNlAttr(b []byte, typ uint16, data []byte) []byte {
+	var hdr [nlaHdrLen]byte
+	binary.NativeEndian.PutUint16(hdr[0:2], uint16(nlaHdrLen+len(data)))
+	binary.NativeEndian.PutUint16(hdr[2:4], typ)
+	b = append(b, hdr[:]...)
+	b = append(b, data...)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// nlString encodes a NUL-terminated string attribute value.
+func nlString(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+// nlAlign rounds n up to the 4-byte netlink alignment.
+func nlAlign(n int) int {
+	return (n + 3) &^ 3
+}
+
+// genlRequest returns a generic netlink header followed by attrs.
+func genlRequest(cmd, version uint8, attrs []byte) []byte {
+	return append([]byte{cmd, version, 0, 0}, attrs...)
+}
+
+// netlinkBackend reads device state from the kernel's "wireguard" generic
+// netlink family, the same interface `wg show` uses. Counters, handshake
+// times and allowed IPs are exact.
+type netlinkBackend struct {
+	dial  func() (nlTransport, error)
+	links func() ([]string, error) // names of all network interfaces
+
+	mu     sync.Mutex
+	conn   *nlConn
+	family uint16
+}
+
+// newNetlinkBackend returns a backend that opens its socket on first use.
+func newNetlinkBackend() *netlinkBackend {
+	return &netlinkBackend{dial: dialNetlink, links: linkNames}
+}
+
+// linkNames lists the system's network interfaces.
+func linkNames() ([]string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ifaces))
+	for i, ifc := range ifaces {
+		names[i] = ifc.Name
+	}
+	return names, nil
+}
+
+// unavailable wraps err as errBackendUnavailable.
+func unavailable(err error) error {
+	return fmt.Errorf("%w: %w", errBackendUnavailable, err)
+}
+
+// connect opens the socket and resolves the wireguard family ID. Callers
+// must hold b.mu.
+func (b *netlinkBackend) connect() error {
+	if b.conn != nil {
+		return nil
+	}
+
+	t, err := b.dial()
+	if err != nil {
+		return unavailable(fmt.Errorf("opening netlink socket: %w", err))
+	}
+	conn := &nlConn{t: t}
+
+	req := genlRequest(ctrlCmdGetFamily, ctrlVersion, appendNlAttr(nil, ctrlAttrFamilyName, nlString(wgGenlName)))
+	msgs, err := conn.execute(genlIDCtrl, nlmFAck, req)
+	if err != nil {
+		_ = t.Close()
+		if errors.Is(err, syscall.ENOENT) {
+			return unavailable(fmt.Errorf("wireguard kernel module not loaded"))
+		}
+		return unavailable(fmt.Errorf("resolving wireguard netlink family: %w", err))
+	}
+
+	for _, m := range msgs {
+		if len(m.data) < genlHdrLen {
+			continue
+		}
+		attrs, err := parseNlAttrs(m.data[genlHdrLen:])
+		if err != nil {
+			_ = t.Close()
+			return unavailable(err)
+		}
+		for _, a := range attrs {
+			if a.typ == ctrlAttrFamilyID && len(a.data) >= 2 {
+				b.family = binary.NativeEndian.Uint16(a.data)
+			}
+		}
+	}
+	if b.family == 0 {
+		_ = t.Close()
+		return unavailable(fmt.Errorf("wireguard netlink family has no ID"))
+	}
+
+	b.conn = conn
+	return nil
+}
+
+// Status dumps the named device with WG_CMD_GET_DEVICE.
+func (b *netlinkBackend) Status(name string) (*InterfaceStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.connect(); err != nil {
+		return nil, err
+	}
+
+	req := genlRequest(wgCmdGetDevice, wgGenlVersion, appendNlAttr(nil, wgDeviceAIfname, nlString(name)))
+	msgs, err := b.conn.execute(b.family, nlmFAck|nlmFDump, req)
+	if err != nil {
+		if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+			err = unavailable(err)
+		}
+		return nil, fmt.Errorf("getting status for %s: %w", name, err)
+	}
+	st, err := parseDevice(msgs)
+	if err != nil {
+		return nil, fmt.Errorf("getting status for %s: %w", name, err)
+	}
+	st.Name = name
+	return st, nil
+}
+
+// Interfaces asks the kernel about every network interface and keeps the
+// ones the wireguard family recognizes; others are rejected with ENODEV or
+// EOPNOTSUPP.
+func (b *netlinkBackend) Interfaces() ([]string, error) {
+	links, err := b.links()
+	if err != nil {
+		return nil, fmt.Errorf("listing interfaces: %w", err)
+	}
+
+	all, err := b.statusOf(links)
+	if err != nil {
+		return nil, fmt.Errorf("listing interfaces: %w", err)
+	}
+	names := make([]string, len(all))
+	for i, st := range all {
+		names[i] = st.Name
+	}
+	return names, nil
+}
+
+// AllStatus dumps every WireGuard device.
+func (b *netlinkBackend) AllStatus() ([]*InterfaceStatus, error) {
+	links, err := b.links()
+	if err != nil {
+		return nil, fmt.Errorf("getting status: %w", err)
+	}
+	all, err := b.statusOf(links)
+	if err != nil {
+		return nil, fmt.Errorf("getting status: %w", err)
+	}
+	return all, nil
+}
+
+// statusOf dumps each of links that is a WireGuard device, sorted by name.
+func (b *netlinkBackend) statusOf(links []string) ([]*InterfaceStatus, error) {
+	all := []*InterfaceStatus{}
+	for _, link := range links {
+		st, err := b.Status(link)
+		switch {
+		case err == nil:
+			all = append(all, st)
+		case errors.Is(err, syscall.ENODEV), errors.Is(err, syscall.EOPNOTSUPP):
+		default:
+			return nil, err
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all, nil
+}
+
+// parseDevice decodes the messages of a WG_CMD_GET_DEVICE dump. The kernel
+// splits large devices across messages; a peer whose allowed IPs didn't fit
+// is repeated at the start of the next message with the remainder.
+func parseDevice(msgs []nlMessage) (*InterfaceStatus, error) {
+	status := &InterfaceStatus{}
+	for _, m := range msgs {
+		if len(m.data) < genlHdrLen {
+			return nil, fmt.Errorf("netlink: short generic netlink message")
+		}
+		attrs, err := parseNlAttrs(m.data[genlHdrLen:])
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range attrs {
+			switch a.typ {
+			case wgDeviceAPublicKey:
+				status.PublicKey = nlKey(a.data)
+			case wgDeviceAListenPort:
+				if len(a.data) >= 2 {
+					status.ListenPort = int(binary.NativeEndian.Uint16(a.data))
+				}
+			case wgDeviceAFwmark:
+				if len(a.data) >= 4 {
+					status.FwMark = binary.NativeEndian.Uint32(a.data)
+				}
+			case wgDeviceAPeers:
+				if err := parsePeers(a.data, status); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return status, nil
+}
+
+// parsePeers decodes a nested WGDEVICE_A_PEERS attribute into status.
+func parsePeers(b []byte, status *InterfaceStatus) error {
+	entries, err := parseNlAttrs(b)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		peer, err := parsePeer(e.data)
+		if err != nil {
+			return err
+		}
+		if n := len(status.Peers); n > 0 && status.Peers[n-1].PublicKey == peer.PublicKey {
+			status.Peers[n-1].AllowedIPs = append(status.Peers[n-1].AllowedIPs, peer.AllowedIPs...)
+			continue
+		}
+		status.Peers = append(status.Peers, peer)
+	}
+	return nil
+}
+
+// parsePeer decodes the attributes of one peer.
+func parsePeer(b []byte) (PeerStatus, error) {
+	var peer PeerStatus
+	attrs, err := parseNlAttrs(b)
+	if err != nil {
+		return peer, err
+	}
+	for _, a := range attrs {
+		switch a.typ {
+		case wgPeerAPublicKey:
+			peer.PublicKey = nlKey(a.data)
+		case wgPeerAPresharedKey:
+			peer.HasPresharedKey = nlKey(a.data) != ""
+		case wgPeerAEndpoint:
+			if ep, ok := parseSockaddr(a.data); ok {
+				peer.Endpoint = ep.String()
+			}
+		case wgPeerAKeepaliveInterval:
+			if len(a.data) >= 2 {
+				peer.PersistentKeepalive = int(binary.NativeEndian.Uint16(a.data))
+			}
+		case wgPeerALastHandshakeTime:
+			// struct __kernel_timespec { __s64 tv_sec; __s64 tv_nsec; }
+			if len(a.data) >= 16 {
+				sec := int64(binary.NativeEndian.Uint64(a.data[0:8]))
+				nsec := int64(binary.NativeEndian.Uint64(a.data[8:16]))
+				if sec != 0 || nsec != 0 {
+					peer.LatestHandshake = time.Unix(sec, nsec)
+				}
+			}
+		case wgPeerARxBytes:
+			if len(a.data) >= 8 {
+				peer.TransferRx = binary.NativeEndian.Uint64(a.data)
+			}
+		case wgPeerATxBytes:
+			if len(a.data) >= 8 {
+				peer.TransferTx = binary.NativeEndian.Uint64(a.data)
+			}
+		case wgPeerAAllowedIPs:
+			ips, err := parseAllowedIPs(a.data)
+			if err != nil {
+				return peer, err
+			}
+			peer.AllowedIPs = ips
+		}
+	}
+	return peer, nil
+}
+
+// parseAllowedIPs decodes a nested WGPEER_A_ALLOWEDIPS attribute.
+func parseAllowedIPs(b []byte) ([]netip.Prefix, error) {
+	entries, err := parseNlAttrs(b)
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []netip.Prefix
+	for _, e := range entries {
+		attrs, err := parseNlAttrs(e.data)
+		if err != nil {
+			return nil, err
+		}
+		var addr netip.Addr
+		bits := -1
+		for _, a := range attrs {
+			switch a.typ {
+			case wgAllowedIPAIPAddr:
+				addr, _ = netip.AddrFromSlice(a.data)
+			case wgAllowedIPACidrMask:
+				if len(a.data) >= 1 {
+					bits = int(a.data[0])
+				}
+			}
+		}
+		if p := netip.PrefixFrom(addr, bits); p.IsValid() {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes, nil
+}
+
+// parseSockaddr decodes a struct sockaddr_in or sockaddr_in6. The family is
+// in host byte order, the port in network byte order.
+func parseSockaddr(b []byte) (netip.AddrPort, bool) {
+	if len(b) < 2 {
+		return netip.AddrPort{}, false
+	}
+	switch binary.NativeEndian.Uint16(b[0:2]) {
+	case afInet:
+		if len(b) < 8 {
+			return netip.AddrPort{}, false
+		}
+		addr := netip.AddrFrom4([4]byte(b[4:8]))
+		return netip.AddrPortFrom(addr, binary.BigEndian.Uint16(b[2:4])), true
+	case afInet6:
+		if len(b) < 24 {
+			return netip.AddrPort{}, false
+		}
+		addr := netip.AddrFrom16([16]byte(b[8:24]))
+		return netip.AddrPortFrom(addr, binary.BigEndian.Uint16(b[2:4])), true
+	}
+	return netip.AddrPort{}, false
+}
+
+// nlKey encodes a key attribute as base64, or "" for an all-zero key.
+func nlKey(b []byte) string {
+	if len(b) != KeyLen || Key(b) == (Key{}) {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package wg
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// nlSocket is a NETLINK_GENERIC socket.
+type nlSocket struct {
+	fd  int
+	buf []byte
+}
+
+// dialNetlink opens a generic netlink socket. Receives time out after
+// cmdTimeout so a wedged kernel reply can't hang the UI.
+func dialNetlink() (nlTransport, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_GENERIC)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+	tv := unix.NsecToTimeval(cmdTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+	// Dump replies are at most 32 KiB per datagram.
+	return &nlSocket{fd: fd, buf: make([]byte, 64*1024)}, nil
+}
+
+func (s *nlSocket) Send(b []byte) error {
+	return unix.Sendto(s.fd, b, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK})
+}
+
+func (s *nlSocket) Receive() ([]byte, error) {
+	n, _, err := unix.Recvfrom(s.fd, s.buf, 0)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, n)
+	copy(out, s.buf[:n])
+	return out, nil
+}
+
+func (s *nlSocket) Close() error {
+	return unix.Close(s.fd)
+}
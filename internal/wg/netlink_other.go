@@ -0,0 +1,10 @@
+//go:build !linux
+
+package wg
+
+import "errors"
+
+// dialNetlink fails outside Linux; the CLI backend is used instead.
+func dialNetlink() (nlTransport, error) {
+	return nil, errors.New("netlink is only available on Linux")
+}
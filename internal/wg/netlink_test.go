@@ -0,0 +1,274 @@
+package wg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// recordedTransport replays a netlink exchange from testdata/netlink. Each
+// file holds datagrams in order: "> hex" is a request the backend must
+// send, "< hex" a reply the kernel sent back; "#" lines are comments.
+type recordedTransport struct {
+	t     *testing.T
+	lines []string
+}
+
+func newRecordedTransport(t *testing.T, name string) *recordedTransport {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "netlink", name))
+	if err != nil {
+		t.Fatalf("opening recording: %v", err)
+	}
+	defer f.Close()
+
+	rt := &recordedTransport{t: t}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rt.lines = append(rt.lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("reading recording: %v", err)
+	}
+	return rt
+}
+
+// next pops the next datagram, which must have the given direction.
+func (rt *recordedTransport) next(dir string) []byte {
+	rt.t.Helper()
+	if len(rt.lines) == 0 {
+		rt.t.Fatalf("recording exhausted, wanted %q datagram", dir)
+	}
+	line := rt.lines[0]
+	rt.lines = rt.lines[1:]
+	if !strings.HasPrefix(line, dir+" ") {
+		rt.t.Fatalf("recording has %q next, wanted %q datagram", line[:1], dir)
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(line, dir+" "))
+	if err != nil {
+		rt.t.Fatalf("bad hex in recording: %v", err)
+	}
+	return b
+}
+
+func (rt *recordedTransport) Send(b []byte) error {
+	if want := rt.next(">"); !bytes.Equal(b, want) {
+		rt.t.Errorf("sent  %x\nwant %x", b, want)
+	}
+	return nil
+}
+
+func (rt *recordedTransport) Receive() ([]byte, error) {
+	return rt.next("<"), nil
+}
+
+func (rt *recordedTransport) Close() error { return nil }
+
+// done fails the test if the backend didn't play the whole recording.
+func (rt *recordedTransport) done() {
+	rt.t.Helper()
+	if len(rt.lines) != 0 {
+		rt.t.Errorf("%d datagrams of the recording were not exchanged", len(rt.lines))
+	}
+}
+
+// recordedBackend returns a netlink backend that replays the named recording.
+func recordedBackend(t *testing.T, name string) (*netlinkBackend, *recordedTransport) {
+	rt := newRecordedTransport(t, name)
+	b := &netlinkBackend{
+		dial:  func() (nlTransport, error) { return rt, nil },
+		links: func() ([]string, error) { return nil, nil },
+	}
+	return b, rt
+}
+
+func TestNetlinkStatus(t *testing.T) {
+	b, rt := recordedBackend(t, "wg0.txt")
+
+	st, err := b.Status("wg0")
+	if err != nil {
+		t.Fatalf("Status() returned error: %v", err)
+	}
+	rt.done()
+
+	if st.Name != "wg0" || st.PublicKey != "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=" {
+		t.Errorf("Name, PublicKey = %q, %q", st.Name, st.PublicKey)
+	}
+	if st.ListenPort != 51820 {
+		t.Errorf("ListenPort = %d, want 51820", st.ListenPort)
+	}
+	if len(st.Peers) != 2 {
+		t.Fatalf("len(Peers) = %d, want 2 (continued peer must be merged)", len(st.Peers))
+	}
+
+	p0 := st.Peers[0]
+	if p0.PublicKey != "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=" {
+		t.Errorf("Peer[0].PublicKey = %q", p0.PublicKey)
+	}
+	if p0.Endpoint != "203.0.113.1:51820" {
+		t.Errorf("Peer[0].Endpoint = %q, want 203.0.113.1:51820", p0.Endpoint)
+	}
+	if got := FormatPrefixes(p0.AllowedIPs); got != "10.0.0.2/32, fd00::2/128, 192.168.50.0/24" {
+		t.Errorf("Peer[0].AllowedIPs = %q", got)
+	}
+	if want := time.Unix(1748779110, 250000000); !p0.LatestHandshake.Equal(want) {
+		t.Errorf("Peer[0].LatestHandshake = %v, want %v", p0.LatestHandshake, want)
+	}
+	if p0.TransferRx != 1572864123 || p0.TransferTx != 3397386 {
+		t.Errorf("Peer[0].Transfer = %d/%d, want exact counters 1572864123/3397386", p0.TransferRx, p0.TransferTx)
+	}
+	if p0.PersistentKeepalive != 25 {
+		t.Errorf("Peer[0].PersistentKeepalive = %d, want 25", p0.PersistentKeepalive)
+	}
+	if p0.HasPresharedKey {
+		t.Error("Peer[0].HasPresharedKey = true for an all-zero key")
+	}
+
+	p1 := st.Peers[1]
+	if p1.Endpoint != "[2001:db8::1]:51821" {
+		t.Errorf("Peer[1].Endpoint = %q, want [2001:db8::1]:51821", p1.Endpoint)
+	}
+	if !p1.LatestHandshake.IsZero() {
+		t.Errorf("Peer[1].LatestHandshake = %v, want zero (never)", p1.LatestHandshake)
+	}
+	if got := FormatPrefixes(p1.AllowedIPs); got != "10.0.0.3/32" {
+		t.Errorf("Peer[1].AllowedIPs = %q", got)
+	}
+}
+
+func TestNetlinkStatusNoDevice(t *testing.T) {
+	b, rt := recordedBackend(t, "nodev.txt")
+
+	_, err := b.Status("wg9")
+	if !errors.Is(err, syscall.ENODEV) {
+		t.Fatalf("Status() error = %v, want ENODEV", err)
+	}
+	if errors.Is(err, errBackendUnavailable) {
+		t.Error("a missing device must not mark the backend unavailable")
+	}
+	rt.done()
+}
+
+func TestNetlinkUnavailable(t *testing.T) {
+	tests := []struct {
+		recording string
+	}{
+		{"nofamily.txt"},
+		{"eperm.txt"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.recording, func(t *testing.T) {
+			b, rt := recordedBackend(t, tc.recording)
+			_, err := b.Status("wg0")
+			if !errors.Is(err, errBackendUnavailable) {
+				t.Fatalf("Status() error = %v, want errBackendUnavailable", err)
+			}
+			rt.done()
+		})
+	}
+}
+
+func TestNetlinkAllStatus(t *testing.T) {
+	b, rt := recordedBackend(t, "interfaces.txt")
+	b.links = func() ([]string, error) { return []string{"eth0", "wg0", "lo"}, nil }
+
+	all, err := b.AllStatus()
+	if err != nil {
+		t.Fatalf("AllStatus() returned error: %v", err)
+	}
+	rt.done()
+	if len(all) != 1 || all[0].Name != "wg0" || len(all[0].Peers) != 2 {
+		t.Errorf("AllStatus() = %+v, want wg0 with 2 peers", all)
+	}
+}
+
+func TestNetlinkInterfaces(t *testing.T) {
+	b, rt := recordedBackend(t, "interfaces.txt")
+	b.links = func() ([]string, error) { return []string{"eth0", "wg0", "lo"}, nil }
+
+	names, err := b.Interfaces()
+	if err != nil {
+		t.Fatalf("Interfaces() returned error: %v", err)
+	}
+	rt.done()
+	if len(names) != 1 || names[0] != "wg0" {
+		t.Errorf("Interfaces() = %v, want [wg0]", names)
+	}
+}
+
+// stubBackend returns canned results and counts calls.
+type stubBackend struct {
+	status *InterfaceStatus
+	calls  int
+}
+
+func (s *stubBackend) Status(string) (*InterfaceStatus, error) {
+	s.calls++
+	return s.status, nil
+}
+
+func (s *stubBackend) Interfaces() ([]string, error) {
+	s.calls++
+	return []string{"wg0"}, nil
+}
+
+func (s *stubBackend) AllStatus() ([]*InterfaceStatus, error) {
+	s.calls++
+	return []*InterfaceStatus{s.status}, nil
+}
+
+func TestFallbackBackendSwitchesWhenUnavailable(t *testing.T) {
+	primary, rt := recordedBackend(t, "eperm.txt")
+	cli := &stubBackend{status: &InterfaceStatus{ListenPort: 51820}}
+	f := &fallbackBackend{primary: primary, fallback: cli}
+
+	st, err := f.Status("wg0")
+	if err != nil {
+		t.Fatalf("Status() returned error: %v", err)
+	}
+	if st.ListenPort != 51820 || cli.calls != 1 {
+		t.Errorf("Status() = %+v after %d fallback calls, want fallback result", st, cli.calls)
+	}
+	rt.done()
+
+	// The recording is exhausted: a second netlink attempt would fail the
+	// test, so this also checks the switch is sticky.
+	if _, err := f.Interfaces(); err != nil {
+		t.Fatalf("Interfaces() returned error: %v", err)
+	}
+	if cli.calls != 2 {
+		t.Errorf("fallback calls = %d, want 2", cli.calls)
+	}
+}
+
+func TestFallbackBackendKeepsDeviceErrors(t *testing.T) {
+	primary, _ := recordedBackend(t, "nodev.txt")
+	cli := &stubBackend{}
+	f := &fallbackBackend{primary: primary, fallback: cli}
+
+	if _, err := f.Status("wg9"); !errors.Is(err, syscall.ENODEV) {
+		t.Errorf("Status() error = %v, want ENODEV from netlink", err)
+	}
+	if cli.calls != 0 {
+		t.Errorf("fallback called %d times for a per-device error", cli.calls)
+	}
+}
+
+func TestParseNlAttrsRejectsBadLength(t *testing.T) {
+	if _, err := parseNlAttrs([]byte{0xff, 0x00, 0x01, 0x00}); err == nil {
+		t.Error("parseNlAttrs() with overlong attribute should return error")
+	}
+}
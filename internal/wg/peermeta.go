@@ -0,0 +1,151 @@
+package wg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Peer metadata is stored in the [Peer] section as structured comments,
+// which wg and wg-quick ignore:
+//
+//	[Peer]
+//	# Name = laptop
+//	# Owner = alice@example.com
+//	# Notes = company ThinkPad
+//	# Created = 2026-03-01T09:30:00Z
+//	# Expires = 2026-12-31
+//	PublicKey = ...
+//
+// Keys are matched case-insensitively like config keys. A metadata line
+// whose value doesn't parse stays a plain comment.
+var peerMetaKeys = []string{"Name", "Owner", "Notes", "Created", "Expires"}
+
+// metaKeyPrefix turns a metadata key into its layout key, so that
+// writeFields produces "# Name = value" lines.
+const metaKeyPrefix = "# "
+
+// metaDateFormat is the short form of metadata times: a UTC date, stored
+// as midnight at its start. An Expires date lasts the whole day.
+const metaDateFormat = "2006-01-02"
+
+// isMetaKey reports whether a layout key is a metadata comment.
+func isMetaKey(key string) bool {
+	return strings.HasPrefix(key, metaKeyPrefix)
+}
+
+// parseMetaComment recognizes a metadata comment line, returning its
+// layout key (e.g. "# Name") and value.
+func parseMetaComment(raw string) (key, value string, ok bool) {
+	line := strings.TrimSpace(raw)
+	if !strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	k, v, ok := strings.Cut(strings.TrimPrefix(line, "#"), "=")
+	if !ok {
+		return "", "", false
+	}
+	k = strings.TrimSpace(k)
+	for _, mk := range peerMetaKeys {
+		if strings.EqualFold(mk, k) {
+			return metaKeyPrefix + mk, strings.TrimSpace(v), true
+		}
+	}
+	return "", "", false
+}
+
+// setPeerMeta sets a metadata field from its layout key, reporting false
+// if the value is not valid for it.
+func setPeerMeta(peer *Peer, key, value string) bool {
+	switch strings.TrimPrefix(key, metaKeyPrefix) {
+	case "Name":
+		peer.Name = value
+	case "Owner":
+		peer.Owner = value
+	case "Notes":
+		peer.Notes = value
+	case "Created":
+		t, err := ParseMetaTime(value)
+		if err != nil {
+			return false
+		}
+		peer.Created = t
+	case "Expires":
+		t, err := ParseMetaTime(value)
+		if err != nil {
+			return false
+		}
+		peer.Expires = t
+	default:
+		return false
+	}
+	return true
+}
+
+// peerMetaFields returns the serialized metadata of peer, in the order
+// MarshalConfig writes it.
+func peerMetaFields(peer *Peer) []field {
+	return []field{
+		{metaKeyPrefix + "Name", optString(oneLine(peer.Name))},
+		{metaKeyPrefix + "Owner", optString(oneLine(peer.Owner))},
+		{metaKeyPrefix + "Notes", optString(oneLine(peer.Notes))},
+		{metaKeyPrefix + "Created", optString(FormatMetaTime(peer.Created))},
+		{metaKeyPrefix + "Expires", optString(FormatMetaTime(peer.Expires))},
+	}
+}
+
+// oneLine keeps a metadata value on its comment line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ParseMetaTime parses a metadata time: a date (2006-01-02, taken as the
+// start of that day in UTC) or an RFC 3339 timestamp. An empty string is
+// the zero time.
+func ParseMetaTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(metaDateFormat, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+// FormatMetaTime formats a metadata time in UTC, as a date if it is
+// midnight and as RFC 3339 otherwise. The zero time is "".
+func FormatMetaTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	t = t.UTC()
+	if isMetaDate(t) {
+		return t.Format(metaDateFormat)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// isMetaDate reports whether t is midnight UTC, which metadata writes as
+// a date.
+func isMetaDate(t time.Time) bool {
+	t = t.UTC()
+	return t.Equal(t.Truncate(24 * time.Hour))
+}
+
+// Matches reports whether the peer's name, owner, notes, public key,
+// endpoint or allowed IPs contain query, ignoring case. An empty query
+// matches every peer.
+func (p Peer) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	for _, s := range []string{p.Name, p.Owner, p.Notes, p.PublicKey, p.Endpoint, FormatPrefixes(p.AllowedIPs)} {
+		if strings.Contains(strings.ToLower(s), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package wg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPeerMetadata(t *testing.T) {
+	conf := `[Interface]
+PrivateKey = ` + testPrivKey + `
+Address = 10.0.0.1/24
+
+[Peer]
+# name = laptop
+# Owner = alice@example.com
+#Notes=company ThinkPad # asset 42
+# Created = 2026-03-01T09:30:00Z
+PublicKey = ` + testPubKey1 + `
+AllowedIPs = 10.0.0.2/32
+
+# Name = phone
+# Expires = 2026-12-31
+[Peer]
+PublicKey = ` + testPubKey2 + `
+AllowedIPs = 10.0.0.3/32
+# Expires = soon
+`
+	iface, err := ParseConfigFromString(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := MarshalConfig(iface); got != conf {
+		t.Errorf("round trip changed the config:\n%s", got)
+	}
+
+	p1, p2 := iface.Peers[0], iface.Peers[1]
+	if p1.Name != "laptop" || p1.Owner != "alice@example.com" || p1.Notes != "company ThinkPad # asset 42" {
+		t.Errorf("peer 1 metadata = %q %q %q", p1.Name, p1.Owner, p1.Notes)
+	}
+	if want := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC); !p1.Created.Equal(want) || !p1.Expires.IsZero() {
+		t.Errorf("peer 1 Created = %v, Expires = %v", p1.Created, p1.Expires)
+	}
+	// Comments directly above a [Peer] header describe that peer; an
+	// unparsable date stays a plain comment.
+	if want := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC); p2.Name != "phone" || !p2.Expires.Equal(want) {
+		t.Errorf("peer 2 = %q, Expires %v; want phone, %v", p2.Name, p2.Expires, want)
+	}
+
+	// Edits rewrite metadata in place; new metadata goes after the header.
+	iface.Peers[0].Owner = "bob@example.com"
+	iface.Peers[1].Owner = "carol"
+	iface.Peers[1].Expires = time.Time{}
+	got := MarshalConfig(iface)
+	for _, want := range []string{
+		"# name = laptop\n# Owner = bob@example.com\n#Notes=",
+		"# Name = phone\n[Peer]\n# Owner = carol\nPublicKey = " + testPubKey2,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("edited config missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Expires = 2026") {
+		t.Errorf("cleared Expires still written:\n%s", got)
+	}
+	reparsed, err := ParseConfigFromString(got)
+	if err != nil || reparsed.Peers[1].Owner != "carol" || reparsed.Peers[1].Name != "phone" {
+		t.Errorf("reparsed peer 2 = %+v, %v", reparsed.Peers[1], err)
+	}
+
+	// A new peer is written canonically, metadata first.
+	fresh := MarshalConfig(&Interface{Peers: []Peer{{Name: "tablet", Notes: "two\nlines", PublicKey: testPubKey1}}})
+	if want := "[Peer]\n# Name = tablet\n# Notes = two lines\nPublicKey = "; !strings.Contains(fresh, want) {
+		t.Errorf("new peer =\n%s\nwant %q", fresh, want)
+	}
+}
+
+func TestMetaTime(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      bool
+	}{
+		{"", "", false},
+		{"2026-12-31", "2026-12-31", false},
+		{"2026-12-31T00:00:00Z", "2026-12-31", false},
+		{"2026-12-31T10:00:00+02:00", "2026-12-31T08:00:00Z", false},
+		{"31.12.2026", "", true},
+	}
+	for _, tc := range tests {
+		got, err := ParseMetaTime(tc.in)
+		if (err != nil) != tc.err {
+			t.Errorf("ParseMetaTime(%q) error = %v", tc.in, err)
+			continue
+		}
+		if s := FormatMetaTime(got); !tc.err && s != tc.want {
+			t.Errorf("FormatMetaTime(ParseMetaTime(%q)) = %q, want %q", tc.in, s, tc.want)
+		}
+	}
+}
+
+func TestPeerMatches(t *testing.T) {
+	p := Peer{Name: "Laptop", Owner: "alice@example.com", PublicKey: testPubKey1, AllowedIPs: mustPrefixes("10.0.0.2/32")}
+	for query, want := range map[string]bool{
+		"":              true,
+		"laptop":        true,
+		"ALICE":         true,
+		"10.0.0.2/":     true,
+		testPubKey1[:8]: true,
+		"bob":           false,
+	} {
+		if got := p.Matches(query); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package wg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Privilege method names, as accepted by NewPrivileged.
+const (
+	PrivilegeAuto        = "auto"
+	PrivilegeSudo        = "sudo"
+	PrivilegeDoas        = "doas"
+	PrivilegePkexec      = "pkexec"
+	PrivilegeRoot        = "root"
+	PrivilegeCapNetAdmin = "cap"
+)
+
+// ErrPasswordRequired is returned (wrapped) when a privileged command was
+// refused because the escalation helper wants a password. Helpers always run
+// non-interactively, so they never prompt on the terminal and corrupt the
+// TUI; callers should ask for the password and call Authenticate.
+var ErrPasswordRequired = errors.New("password required")
+
+// Privileged runs commands that need more rights than the current user has:
+// wg-quick, wg, and file access under /etc/wireguard.
+type Privileged interface {
+	// Name returns the method name, e.g. "sudo".
+	Name() string
+	// Command returns a command that runs name with args elevated. It must
+	// not read from the terminal.
+	Command(ctx context.Context, name string, args ...string) *exec.Cmd
+}
+
+// Authenticator is implemented by privilege methods that can take a
+// password from the UI instead of the terminal.
+type Authenticator interface {
+	// NeedsPassword reports whether commands would currently be refused
+	// for lack of a password.
+	NeedsPassword(ctx context.Context) (bool, error)
+	// Authenticate validates password and caches the credential so later
+	// commands run without one.
+	Authenticate(ctx context.Context, password string) error
+}
+
+// Prompter is implemented by privilege methods whose helper may wait for
+// the user to authenticate in a dialog of its own, as pkexec does through
+// the polkit agent. Their commands get promptTimeout instead of
+// cmdTimeout, so the dialog isn't killed while it is being answered.
+type Prompter interface {
+	Prompts() bool
+}
+
+// helperPrivileged prefixes commands with a setuid helper such as sudo.
+type helperPrivileged struct {
+	name    string
+	args    []string // flags placed before the command
+	prompts bool     // the helper can ask for a password itself; see Prompter
+}
+
+func (h helperPrivileged) Name() string { return h.name }
+
+func (h helperPrivileged) Prompts() bool { return h.prompts }
+
+func (h helperPrivileged) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	full := append(append(append([]string{}, h.args...), name), args...)
+	return exec.CommandContext(ctx, h.name, full...)
+}
+
+// sudoPrivileged runs commands with `sudo -n` and supports password entry
+// through `sudo -S -v`, which caches sudo's timestamp for later commands.
+type sudoPrivileged struct {
+	helperPrivileged
+}
+
+func (s sudoPrivileged) NeedsPassword(ctx context.Context) (bool, error) {
+	output, err := exec.CommandContext(ctx, "sudo", "-n", "true").CombinedOutput()
+	if err == nil {
+		return false, nil
+	}
+	if passwordRequired(output) {
+		return true, nil
+	}
+	return false, fmt.Errorf("sudo: %w: %s", err, strings.TrimSpace(string(output)))
+}
+
+func (s sudoPrivileged) Authenticate(ctx context.Context, password string) error {
+	// -S reads the password from stdin, -p "" suppresses the prompt and -v
+	// only refreshes the cached credential.
+	cmd := exec.CommandContext(ctx, "sudo", "-S", "-v", "-p", "")
+	cmd.Stdin = strings.NewReader(password + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" || strings.Contains(msg, "incorrect password") || strings.Contains(msg, "try again") {
+			return fmt.Errorf("sudo: incorrect password")
+		}
+		return fmt.Errorf("sudo: %s", msg)
+	}
+	return nil
+}
+
+// directPrivileged runs commands as-is, for processes that already have the
+// rights they need.
+type directPrivileged struct {
+	name string
+}
+
+func (d directPrivileged) Name() string { return d.name }
+
+func (d directPrivileged) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, args...)
+}
+
+// NewPrivileged returns the named privilege method. "" and "auto" detect
+// one: direct execution when running as root or with CAP_NET_ADMIN, else
+// the first of sudo, doas and pkexec found in PATH.
+//
+// CAP_NET_ADMIN mode ("cap") is meant for a wg binary or this program
+// granted the capability with setcap; note that wg-quick insists on root
+// and will itself try sudo, and config files must be accessible to the
+// user.
+func NewPrivileged(method string) (Privileged, error) {
+	switch method {
+	case "", PrivilegeAuto:
+		return DetectPrivileged(), nil
+	case PrivilegeRoot:
+		if os.Geteuid() != 0 {
+			return nil, fmt.Errorf("privilege %q: not running as root", method)
+		}
+		return directPrivileged{name: PrivilegeRoot}, nil
+	case PrivilegeCapNetAdmin:
+		return directPrivileged{name: PrivilegeCapNetAdmin}, nil
+	case PrivilegeSudo, PrivilegeDoas, PrivilegePkexec:
+		if _, err := exec.LookPath(method); err != nil {
+			return nil, fmt.Errorf("privilege %q: %w", method, err)
+		}
+		return helperFor(method), nil
+	}
+	return nil, fmt.Errorf("unknown privilege method %q (want auto, sudo, doas, pkexec, root or cap)", method)
+}
+
+// DetectPrivileged picks a privilege method for the current process.
+func DetectPrivileged() Privileged {
+	if os.Geteuid() == 0 {
+		return directPrivileged{name: PrivilegeRoot}
+	}
+	if hasCapNetAdmin() {
+		return directPrivileged{name: PrivilegeCapNetAdmin}
+	}
+	for _, helper := range []string{PrivilegeSudo, PrivilegeDoas, PrivilegePkexec} {
+		if _, err := exec.LookPath(helper); err == nil {
+			return helperFor(helper)
+		}
+	}
+	// Nothing found: sudo gives the most recognizable error message.
+	return helperFor(PrivilegeSudo)
+}
+
+// helperFor returns the helper-based method for sudo, doas or pkexec.
+func helperFor(method string) Privileged {
+	switch method {
+	case PrivilegeDoas:
+		return helperPrivileged{name: "doas", args: []string{"-n"}}
+	case PrivilegePkexec:
+		// pkexec asks through the desktop's polkit agent, not the terminal,
+		// once for every command.
+		return helperPrivileged{name: "pkexec", prompts: true}
+	}
+	return sudoPrivileged{helperPrivileged{name: "sudo", args: []string{"-n", "--"}}}
+}
+
+// capNetAdmin is the bit for CAP_NET_ADMIN in the capability sets.
+const capNetAdmin = 12
+
+// hasCapNetAdmin reports whether CAP_NET_ADMIN is in the process's
+// effective capability set, per /proc/self/status.
+func hasCapNetAdmin() bool {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return capEffHas(f, capNetAdmin)
+}
+
+// capEffHas reports whether bit is set in the CapEff line of a
+// /proc/<pid>/status file.
+func capEffHas(status io.Reader, bit uint) bool {
+	sc := bufio.NewScanner(status)
+	for sc.Scan() {
+		hex, ok := strings.CutPrefix(sc.Text(), "CapEff:")
+		if !ok {
+			continue
+		}
+		caps, err := strconv.ParseUint(strings.TrimSpace(hex), 16, 64)
+		return err == nil && caps&(1<<bit) != 0
+	}
+	return false
+}
+
+// passwordRequired reports whether a helper's output says it refused to run
+// without a password (sudo -n, doas -n).
+func passwordRequired(output []byte) bool {
+	s := string(output)
+	return strings.Contains(s, "password is required") ||
+		strings.Contains(s, "terminal is required") ||
+		strings.Contains(s, "Authentication required") ||
+		strings.Contains(s, "Authorization required")
+}
+
+// privilegedError formats a failed command as "<what>: <err>: <output>",
+// wrapping ErrPasswordRequired when the privilege helper asked for a
+// password.
+func privilegedError(what string, err error, output []byte) error {
+	if passwordRequired(output) {
+		return fmt.Errorf("%s: %w", what, ErrPasswordRequired)
+	}
+	if out := strings.TrimSpace(string(output)); out != "" {
+		return fmt.Errorf("%s: %w: %s", what, err, out)
+	}
+	return fmt.Errorf("%s: %w", what, err)
+}
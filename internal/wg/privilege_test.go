@@ -0,0 +1,158 @@
+package wg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrivilegedCommandArgs(t *testing.T) {
+	tests := []struct {
+		method Privileged
+		want   []string
+	}{
+		{helperFor(PrivilegeSudo), []string{"sudo", "-n", "--", "wg-quick", "up", "wg0"}},
+		{helperFor(PrivilegeDoas), []string{"doas", "-n", "wg-quick", "up", "wg0"}},
+		{helperFor(PrivilegePkexec), []string{"pkexec", "wg-quick", "up", "wg0"}},
+		{directPrivileged{name: PrivilegeRoot}, []string{"wg-quick", "up", "wg0"}},
+		{directPrivileged{name: PrivilegeCapNetAdmin}, []string{"wg-quick", "up", "wg0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.method.Name(), func(t *testing.T) {
+			cmd := tc.method.Command(context.Background(), "wg-quick", "up", "wg0")
+			// Path is resolved by exec; compare the base name instead.
+			got := append([]string{filepath.Base(cmd.Args[0])}, cmd.Args[1:]...)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Command() args = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSudoIsAuthenticator(t *testing.T) {
+	if _, ok := helperFor(PrivilegeSudo).(Authenticator); !ok {
+		t.Error("sudo method does not implement Authenticator")
+	}
+	if _, ok := helperFor(PrivilegeDoas).(Authenticator); ok {
+		t.Error("doas method must not claim to take a password")
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	tests := []struct {
+		method     Privileged
+		privileged bool
+		want       time.Duration
+	}{
+		{helperFor(PrivilegePkexec), true, promptTimeout},
+		{helperFor(PrivilegePkexec), false, cmdTimeout},
+		{helperFor(PrivilegeSudo), true, cmdTimeout},
+		{directPrivileged{name: PrivilegeRoot}, true, cmdTimeout},
+	}
+
+	for _, tc := range tests {
+		m := NewManager(ExecRunner{Privileged: tc.method})
+		if got := m.timeout(Command{Name: "wg", Privileged: tc.privileged}); got != tc.want {
+			t.Errorf("%s, privileged %v: timeout = %v, want %v", tc.method.Name(), tc.privileged, got, tc.want)
+		}
+	}
+}
+
+func TestNewPrivilegedRejectsUnknown(t *testing.T) {
+	_, err := NewPrivileged("su")
+	if err == nil || !strings.Contains(err.Error(), "unknown privilege method") {
+		t.Errorf("NewPrivileged(\"su\") error = %v, want unknown method", err)
+	}
+}
+
+func TestNewPrivilegedCap(t *testing.T) {
+	p, err := NewPrivileged(PrivilegeCapNetAdmin)
+	if err != nil {
+		t.Fatalf("NewPrivileged(cap) returned error: %v", err)
+	}
+	if p.Name() != PrivilegeCapNetAdmin {
+		t.Errorf("Name() = %q, want %q", p.Name(), PrivilegeCapNetAdmin)
+	}
+}
+
+func TestPasswordRequired(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"sudo: a password is required\n", true},
+		{"sudo: a terminal is required to read the password; either use the -S option to read from standard input or configure an askpass helper\n", true},
+		{"doas: Authorization required\n", true},
+		{"Unable to access the interface: Operation not permitted\n", false},
+		{"", false},
+	}
+
+	for _, tc := range tests {
+		if got := passwordRequired([]byte(tc.output)); got != tc.want {
+			t.Errorf("passwordRequired(%q) = %v, want %v", tc.output, got, tc.want)
+		}
+	}
+}
+
+func TestPrivilegedError(t *testing.T) {
+	cause := errors.New("exit status 1")
+
+	err := privilegedError("wg-quick up wg0", cause, []byte("sudo: a password is required\n"))
+	if !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("error = %v, want ErrPasswordRequired", err)
+	}
+
+	err = privilegedError("wg-quick up wg0", cause, []byte("wg-quick: `wg0' already exists\n"))
+	if errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("error = %v wraps ErrPasswordRequired for an ordinary failure", err)
+	}
+	if want := "wg-quick up wg0: exit status 1: wg-quick: `wg0' already exists"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
+
+func TestCapEffHas(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		want   bool
+	}{
+		{"full set", "Name:\twg-tui\nCapEff:\t000001ffffffffff\n", true},
+		{"net admin only", "CapEff:\t0000000000001000\n", true},
+		{"no caps", "CapInh:\t0000000000000000\nCapEff:\t0000000000000000\n", false},
+		{"missing line", "Name:\twg-tui\n", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := capEffHas(strings.NewReader(tc.status), capNetAdmin); got != tc.want {
+				t.Errorf("capEffHas() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSaveConfigTightensPermissions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wg0.conf")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := newTestManager(t).SaveConfig(dir, &Interface{Name: "wg0", PrivateKey: testPrivKey}); err != nil {
+		t.Fatalf("SaveConfig returned error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("permissions = %o, want 600", perm)
+	}
+}
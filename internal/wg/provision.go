@@ -0,0 +1,212 @@
+package wg
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+// ErrNoFreeAddress is returned when every host address of a subnet is
+// taken.
+var ErrNoFreeAddress = errors.New("no free address")
+
+// NextFreeAddress returns the lowest host address of subnet not covered by
+// any of used. The network address is never returned, nor the broadcast
+// address of an IPv4 subnet.
+func NextFreeAddress(subnet netip.Prefix, used []netip.Prefix) (netip.Addr, error) {
+	subnet = subnet.Masked()
+	last := lastAddr(subnet)
+	a := subnet.Addr().Next()
+
+scan:
+	for a.IsValid() && subnet.Contains(a) {
+		if a.Is4() && a == last && subnet.Bits() < 31 {
+			break
+		}
+		for _, u := range used {
+			if u.Masked().Contains(a) {
+				// Skip the whole used prefix; large ones (a routed /64
+				// inside a /48) would take forever address by address.
+				a = lastAddr(u.Masked()).Next()
+				continue scan
+			}
+		}
+		return a, nil
+	}
+	return netip.Addr{}, fmt.Errorf("%s: %w", subnet, ErrNoFreeAddress)
+}
+
+// lastAddr returns the highest address in p, which must be masked.
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Addr().As16()
+	bits := p.Bits()
+	if p.Addr().Is4() {
+		bits += 96
+	}
+	for i := bits; i < 128; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	a := netip.AddrFrom16(b)
+	if p.Addr().Is4() {
+		a = a.Unmap()
+	}
+	return a
+}
+
+// usedPrefixes returns what a new peer of iface must not be given: the
+// interface's own addresses and every peer's AllowedIPs.
+func usedPrefixes(iface *Interface) []netip.Prefix {
+	var used []netip.Prefix
+	for _, a := range iface.Address {
+		used = append(used, netip.PrefixFrom(a.Addr(), a.Addr().BitLen()))
+	}
+	for _, p := range iface.Peers {
+		used = append(used, p.AllowedIPs...)
+	}
+	return used
+}
+
+// AllocateAddresses picks an address for a new peer of iface in each of
+// its Address subnets (so a dual-stack server gives one IPv4 and one IPv6
+// address), returned as single-host prefixes.
+func AllocateAddresses(iface *Interface) ([]netip.Prefix, error) {
+	if len(iface.Address) == 0 {
+		return nil, errors.New("the interface has no Address to allocate from")
+	}
+	used := usedPrefixes(iface)
+	var out []netip.Prefix
+	for _, subnet := range iface.Address {
+		a, err := NextFreeAddress(subnet, used)
+		if err != nil {
+			return nil, err
+		}
+		host := netip.PrefixFrom(a, a.BitLen())
+		out = append(out, host)
+		used = append(used, host)
+	}
+	return out, nil
+}
+
+// ClientOptions describes a client to add to a server profile.
+type ClientOptions struct {
+	Name     string // the client's profile name
+	Endpoint string // host:port the client connects to; required
+
+	// AllowedIPs the client routes through the tunnel. Empty means the
+	// server's subnets.
+	AllowedIPs          []netip.Prefix
+	DNS                 []netip.Addr
+	PersistentKeepalive int
+}
+
+// AddClient provisions a client of server: it allocates the client's
+// addresses, generates its key pair and a preshared key, and returns a
+// copy of server with the client's [Peer] appended together with the
+// client's own config. The new peer is named after the client and stamped
+// with its creation time. server itself is not modified.
+func AddClient(server *Interface, opts ClientOptions) (updated, client *Interface, err error) {
+	if opts.Endpoint == "" {
+		return nil, nil, errors.New("endpoint is required: the address clients use to reach the server")
+	}
+	serverPub, err := DerivePublicKey(server.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("server key: %w", err)
+	}
+	addrs, err := AllocateAddresses(server)
+	if err != nil {
+		return nil, nil, err
+	}
+	priv, pub, err := GenerateKeyPair()
+	if err != nil {
+		return nil, nil, err
+	}
+	psk, err := GeneratePresharedKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowed := opts.AllowedIPs
+	if len(allowed) == 0 {
+		allowed = serverSubnets(server)
+	}
+	client = &Interface{
+		Name:       opts.Name,
+		Address:    addrs,
+		PrivateKey: priv,
+		DNS:        opts.DNS,
+		Peers: []Peer{{
+			PublicKey:           serverPub,
+			PresharedKey:        psk,
+			AllowedIPs:          allowed,
+			Endpoint:            opts.Endpoint,
+			PersistentKeepalive: opts.PersistentKeepalive,
+		}},
+	}
+
+	updated = new(Interface)
+	*updated = *server
+	updated.Peers = append(slices.Clone(server.Peers), Peer{
+		PublicKey:    pub,
+		PresharedKey: psk,
+		AllowedIPs:   addrs,
+		Name:         opts.Name,
+		Created:      time.Now().UTC().Truncate(time.Second),
+	})
+	return updated, client, nil
+}
+
+// ErrNoClientAddress reports a peer whose AllowedIPs hold no host address
+// in the server's subnets, such as a peer routing 0.0.0.0/0: there is no
+// address its client config could use.
+var ErrNoClientAddress = errors.New("the peer's AllowedIPs have no host address in the server's subnets")
+
+// ClientConfig reconstructs the config of the client behind peer of
+// server from the client's private key: its addresses are the host
+// prefixes (/32, /128) of the peer's AllowedIPs inside the server's
+// subnets, and it routes those subnets. The server's endpoint is not
+// known here and is left empty. It fails with ErrNoClientAddress if the
+// peer has no such address.
+func ClientConfig(server *Interface, peer Peer, name, privateKey string) (*Interface, error) {
+	serverPub, err := DerivePublicKey(server.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("server key: %w", err)
+	}
+	addrs := clientAddresses(server, peer.AllowedIPs)
+	if len(addrs) == 0 {
+		return nil, ErrNoClientAddress
+	}
+	return &Interface{
+		Name:       name,
+		Address:    addrs,
+		PrivateKey: privateKey,
+		Peers: []Peer{{
+			PublicKey:           serverPub,
+			PresharedKey:        peer.PresharedKey,
+			AllowedIPs:          serverSubnets(server),
+			PersistentKeepalive: peer.PersistentKeepalive,
+		}},
+	}, nil
+}
+
+// clientAddresses returns the host prefixes among prefixes that lie in
+// one of server's subnets, the addresses a client of server can have.
+func clientAddresses(server *Interface, prefixes []netip.Prefix) []netip.Prefix {
+	var addrs []netip.Prefix
+	for _, p := range prefixes {
+		if p.Bits() == p.Addr().BitLen() && onLink(p, server.Address) {
+			addrs = append(addrs, p)
+		}
+	}
+	return addrs
+}
+
+// serverSubnets returns the networks of server's addresses.
+func serverSubnets(server *Interface) []netip.Prefix {
+	var subnets []netip.Prefix
+	for _, a := range server.Address {
+		subnets = append(subnets, a.Masked())
+	}
+	return subnets
+}
@@ -0,0 +1,187 @@
+package wg
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestNextFreeAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		subnet string
+		used   []string
+		want   string // empty: ErrNoFreeAddress
+	}{
+		{"first host", "10.0.0.1/24", []string{"10.0.0.1/32"}, "10.0.0.2"},
+		{"skips peers", "10.0.0.1/24", []string{"10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"}, "10.0.0.4"},
+		{"fills gaps", "10.0.0.1/24", []string{"10.0.0.1/32", "10.0.0.3/32"}, "10.0.0.2"},
+		{"skips routed subnet", "10.0.0.1/24", []string{"10.0.0.1/32", "10.0.0.0/25"}, "10.0.0.128"},
+		{"unmasked used prefix", "10.0.0.1/24", []string{"10.0.0.1/32", "10.0.0.2/31"}, "10.0.0.4"},
+		{"other family ignored", "10.0.0.1/24", []string{"::/0", "10.0.0.1/32"}, "10.0.0.2"},
+		{"exhausted", "10.0.0.1/30", []string{"10.0.0.1/32", "10.0.0.2/32"}, ""},
+		{"broadcast not used", "192.168.1.1/24", []string{"192.168.1.0/25", "192.168.1.128/26", "192.168.1.192/27", "192.168.1.224/28", "192.168.1.240/29", "192.168.1.248/30", "192.168.1.252/31"}, "192.168.1.254"},
+		{"default route covers all", "10.0.0.1/24", []string{"0.0.0.0/0"}, ""},
+		{"top of address space", "255.255.255.252/30", []string{"255.255.255.253/32", "255.255.255.254/32"}, ""},
+		{"ipv6", "fd00::1/64", []string{"fd00::1/128", "fd00::2/128"}, "fd00::3"},
+		{"ipv6 skips large prefix", "fd00::1/48", []string{"fd00::/64", "fd00:0:0:1::/64"}, "fd00:0:0:2::"},
+		{"ipv6 exhausted", "fd00::/127", []string{"fd00::1/128"}, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var used []netip.Prefix
+			for _, u := range tc.used {
+				used = append(used, netip.MustParsePrefix(u))
+			}
+			got, err := NextFreeAddress(netip.MustParsePrefix(tc.subnet), used)
+			if tc.want == "" {
+				if !errors.Is(err, ErrNoFreeAddress) {
+					t.Errorf("NextFreeAddress() = %v, %v, want ErrNoFreeAddress", got, err)
+				}
+				return
+			}
+			if err != nil || got != netip.MustParseAddr(tc.want) {
+				t.Errorf("NextFreeAddress() = %v, %v, want %s", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddClient(t *testing.T) {
+	server, err := ParseConfigFromString(`[Interface]
+PrivateKey = ` + testPrivKey + `
+Address = 10.0.0.1/24, fd00::1/64
+ListenPort = 51820
+
+[Peer]
+PublicKey = ` + testPubKey2 + `
+AllowedIPs = 10.0.0.2/32, fd00::2/128
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, client, err := AddClient(server, ClientOptions{
+		Name:                "laptop",
+		Endpoint:            "vpn.example.com:51820",
+		DNS:                 []netip.Addr{netip.MustParseAddr("10.0.0.1")},
+		PersistentKeepalive: 25,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Peers) != 1 {
+		t.Errorf("server was modified: %d peers", len(server.Peers))
+	}
+	if len(updated.Peers) != 2 {
+		t.Fatalf("updated has %d peers, want 2", len(updated.Peers))
+	}
+
+	if got := FormatPrefixes(client.Address); got != "10.0.0.3/32, fd00::3/128" {
+		t.Errorf("client Address = %s", got)
+	}
+	serverPub, err := DerivePublicKey(testPrivKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := client.Peers[0]
+	if sp.PublicKey != serverPub || sp.Endpoint != "vpn.example.com:51820" || sp.PersistentKeepalive != 25 {
+		t.Errorf("client's server peer = %+v", sp)
+	}
+	if got := FormatPrefixes(sp.AllowedIPs); got != "10.0.0.0/24, fd00::/64" {
+		t.Errorf("client AllowedIPs = %s, want the server subnets", got)
+	}
+
+	cp := updated.Peers[1]
+	clientPub, err := DerivePublicKey(client.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.PublicKey != clientPub || cp.PresharedKey == "" || cp.PresharedKey != sp.PresharedKey {
+		t.Errorf("server's client peer = %+v, want client key %s and shared PSK", cp, clientPub)
+	}
+	if got := FormatPrefixes(cp.AllowedIPs); got != "10.0.0.3/32, fd00::3/128" {
+		t.Errorf("server peer AllowedIPs = %s", got)
+	}
+	if cp.Name != "laptop" || cp.Created.IsZero() {
+		t.Errorf("server peer metadata = %q, created %v; want the client name and a creation time", cp.Name, cp.Created)
+	}
+	if diags := Validate(updated); HasErrors(diags) {
+		t.Errorf("updated server invalid: %v", diags)
+	}
+	if diags := Validate(client); HasErrors(diags) {
+		t.Errorf("client invalid: %v", diags)
+	}
+
+	// The next client gets the next address.
+	_, next, err := AddClient(updated, ClientOptions{Endpoint: "vpn.example.com:51820"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatPrefixes(next.Address); got != "10.0.0.4/32, fd00::4/128" {
+		t.Errorf("second client Address = %s", got)
+	}
+}
+
+func TestAddClientErrors(t *testing.T) {
+	full := &Interface{
+		PrivateKey: testPrivKey,
+		Address:    []netip.Prefix{netip.MustParsePrefix("10.0.0.1/30")},
+		Peers:      []Peer{{PublicKey: testPubKey2, AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")}}},
+	}
+	tests := []struct {
+		name   string
+		server *Interface
+		opts   ClientOptions
+		want   error
+	}{
+		{"exhausted", full, ClientOptions{Endpoint: "h:1"}, ErrNoFreeAddress},
+		{"no endpoint", full, ClientOptions{}, nil},
+		{"no address", &Interface{PrivateKey: testPrivKey}, ClientOptions{Endpoint: "h:1"}, nil},
+		{"no key", &Interface{Address: full.Address}, ClientOptions{Endpoint: "h:1"}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := AddClient(tc.server, tc.opts)
+			if err == nil || (tc.want != nil && !errors.Is(err, tc.want)) {
+				t.Errorf("AddClient() error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	server := &Interface{
+		PrivateKey: testPrivKey,
+		Address:    []netip.Prefix{netip.MustParsePrefix("10.0.0.1/24")},
+	}
+	priv, pub, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer := Peer{PublicKey: pub, PresharedKey: testPubKey1, AllowedIPs: []netip.Prefix{netip.MustParsePrefix("10.0.0.7/32")}, PersistentKeepalive: 25}
+
+	client, err := ClientConfig(server, peer, "peer1", priv)
+	if err != nil {
+		t.Fatalf("ClientConfig() returned error: %v", err)
+	}
+	serverPub, _ := DerivePublicKey(testPrivKey)
+	sp := client.Peers[0]
+	if FormatPrefixes(client.Address) != "10.0.0.7/32" || sp.PublicKey != serverPub || sp.PresharedKey != testPubKey1 ||
+		FormatPrefixes(sp.AllowedIPs) != "10.0.0.0/24" || sp.Endpoint != "" || sp.PersistentKeepalive != 25 {
+		t.Errorf("ClientConfig() = %+v, peer %+v", client, sp)
+	}
+
+	// Only host addresses inside the server's subnet become the client's.
+	peer.AllowedIPs = mustPrefixes("10.0.0.7/32, 192.168.1.0/24, 10.9.0.1/32")
+	if client, err := ClientConfig(server, peer, "peer1", priv); err != nil || FormatPrefixes(client.Address) != "10.0.0.7/32" {
+		t.Errorf("ClientConfig() with routed subnets = %v, %v; want Address 10.0.0.7/32", client, err)
+	}
+
+	// The wizard's default AllowedIPs make the peer a server, not a client.
+	peer.AllowedIPs = mustPrefixes("0.0.0.0/0, ::/0")
+	if client, err := ClientConfig(server, peer, "peer1", priv); !errors.Is(err, ErrNoClientAddress) {
+		t.Errorf("ClientConfig() with 0.0.0.0/0 = %v, %v; want ErrNoClientAddress", client, err)
+	}
+}
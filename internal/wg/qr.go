@@ -11,3 +11,8 @@ func GenerateQRString(iface *Interface) (string, error) {
 	}
 	return qr.ToSmallString(false), nil
 }
+
+// GenerateQRPNG renders an interface config as a size×size PNG QR code.
+func GenerateQRPNG(iface *Interface, size int) ([]byte, error) {
+	return qrcode.Encode(MarshalConfig(iface), qrcode.Medium, size)
+}
@@ -5,12 +5,12 @@ import "testing"
 func TestGenerateQRString(t *testing.T) {
 	iface := &Interface{
 		PrivateKey: "testkey123",
-		Address:    "10.0.0.1/24",
-		DNS:        "1.1.1.1",
+		Address:    mustPrefixes("10.0.0.1/24"),
+		DNS:        mustAddrs("1.1.1.1"),
 		Peers: []Peer{
 			{
 				PublicKey:  "peerpubkey",
-				AllowedIPs: "0.0.0.0/0",
+				AllowedIPs: mustPrefixes("0.0.0.0/0"),
 				Endpoint:   "1.2.3.4:51820",
 			},
 		},
@@ -31,7 +31,7 @@ func TestGenerateQRString(t *testing.T) {
 func TestGenerateQRStringNoPeers(t *testing.T) {
 	iface := &Interface{
 		PrivateKey: "testkey123",
-		Address:    "10.0.0.1/24",
+		Address:    mustPrefixes("10.0.0.1/24"),
 	}
 
 	qr, err := GenerateQRString(iface)
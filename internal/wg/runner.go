@@ -0,0 +1,80 @@
+package wg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Command is an external command the Manager wants run.
+type Command struct {
+	Name       string
+	Args       []string
+	Stdin      []byte
+	Privileged bool // needs root: run through the privilege method
+}
+
+// String returns the command line, e.g. "wg-quick up wg0".
+func (c Command) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Runner executes external commands for a Manager. Implementations return
+// an *ExitError when the command ran but exited non-zero, with whatever it
+// wrote to stdout and stderr.
+type Runner interface {
+	Run(ctx context.Context, cmd Command) (stdout, stderr []byte, err error)
+}
+
+// ExitError reports a command that ran but exited with a non-zero status.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExecRunner runs commands as subprocesses, elevating privileged ones
+// through Privileged.
+type ExecRunner struct {
+	Privileged Privileged
+}
+
+// Run implements Runner.
+func (r ExecRunner) Run(ctx context.Context, c Command) ([]byte, []byte, error) {
+	var cmd *exec.Cmd
+	if c.Privileged {
+		cmd = r.Privileged.Command(ctx, c.Name, c.Args...)
+	} else {
+		cmd = exec.CommandContext(ctx, c.Name, c.Args...)
+	}
+	if c.Stdin != nil {
+		cmd.Stdin = bytes.NewReader(c.Stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = &ExitError{Code: exitErr.ExitCode()}
+	}
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// commandOutput joins what a command wrote to stderr and stdout, for error
+// messages. wg-quick logs to stderr, so that comes first.
+func commandOutput(stdout, stderr []byte) []byte {
+	var parts [][]byte
+	for _, b := range [][]byte{stderr, stdout} {
+		if b = bytes.TrimSpace(b); len(b) > 0 {
+			parts = append(parts, b)
+		}
+	}
+	return bytes.Join(parts, []byte("\n"))
+}
@@ -0,0 +1,119 @@
+package wg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeCall is one scripted command: the command line the Manager must run
+// and what the command does.
+type fakeCall struct {
+	cmd    string // expected command line, e.g. "wg-quick up wg0"
+	stdout string
+	stderr string
+	exit   int   // non-zero fails with *ExitError
+	err    error // fails without running, e.g. exec.ErrNotFound
+}
+
+// fakeRunner plays a script of commands in order, like recordedTransport
+// does for netlink. Unexpected commands fail the test.
+type fakeRunner struct {
+	t      *testing.T
+	mu     sync.Mutex
+	script []fakeCall
+}
+
+func (f *fakeRunner) Run(_ context.Context, cmd Command) ([]byte, []byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.script) == 0 {
+		f.t.Errorf("unexpected command %q: script exhausted", cmd)
+		return nil, nil, errors.New("unexpected command")
+	}
+	call := f.script[0]
+	f.script = f.script[1:]
+	if got := cmd.String(); got != call.cmd {
+		f.t.Errorf("ran %q, want %q", got, call.cmd)
+	}
+	if !cmd.Privileged {
+		f.t.Errorf("%q was not run with privileges", cmd)
+	}
+
+	switch {
+	case call.err != nil:
+		return nil, nil, call.err
+	case call.exit != 0:
+		return []byte(call.stdout), []byte(call.stderr), &ExitError{Code: call.exit}
+	}
+	return []byte(call.stdout), []byte(call.stderr), nil
+}
+
+// done fails the test if part of the script was not run.
+func (f *fakeRunner) done() {
+	f.t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.script) != 0 {
+		f.t.Errorf("%d scripted commands were not run, next %q", len(f.script), f.script[0].cmd)
+	}
+}
+
+// newTestManager returns a Manager whose commands, including status reads,
+// are played from script. The script must be used up by the end of the test.
+func newTestManager(t *testing.T, script ...fakeCall) *Manager {
+	f := &fakeRunner{t: t, script: script}
+	m := &Manager{runner: f}
+	m.backend = cliBackend{m: m}
+	t.Cleanup(f.done)
+	return m
+}
+
+func TestManagerErrorFormatting(t *testing.T) {
+	tests := []struct {
+		name string
+		call fakeCall
+		want string
+	}{
+		{
+			"stderr",
+			fakeCall{cmd: "wg-quick up wg0", exit: 1, stderr: "[#] ip link add wg0 type wireguard\nRTNETLINK answers: Operation not supported\n"},
+			"wg-quick up wg0: exit status 1: [#] ip link add wg0 type wireguard\nRTNETLINK answers: Operation not supported",
+		},
+		{
+			"stderr and stdout",
+			fakeCall{cmd: "wg-quick up wg0", exit: 2, stdout: "partial\n", stderr: "failed\n"},
+			"wg-quick up wg0: exit status 2: failed\npartial",
+		},
+		{
+			"no output",
+			fakeCall{cmd: "wg-quick up wg0", exit: 1},
+			"wg-quick up wg0: exit status 1",
+		},
+		{
+			"password",
+			fakeCall{cmd: "wg-quick up wg0", exit: 1, stderr: "sudo: a password is required\n"},
+			"wg-quick up wg0: password required",
+		},
+		{
+			"not run",
+			fakeCall{cmd: "wg-quick up wg0", err: errors.New(`exec: "sudo": executable file not found in $PATH`)},
+			`wg-quick up wg0: exec: "sudo": executable file not found in $PATH`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestManager(t, tc.call)
+			err := m.Up("wg0")
+			if err == nil {
+				t.Fatal("Up() returned nil error")
+			}
+			if err.Error() != tc.want {
+				t.Errorf("Up() error = %q, want %q", err, tc.want)
+			}
+		})
+	}
+}
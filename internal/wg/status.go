@@ -2,168 +2,176 @@ package wg
 
 import (
 	"fmt"
-	"regexp"
+	"net/netip"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// InterfaceStatus represents the live runtime status of a WireGuard interface
-// as reported by `wg show`.
+// InterfaceStatus represents the live runtime status of a WireGuard interface.
 type InterfaceStatus struct {
+	Name       string
 	PublicKey  string
 	ListenPort int
+	FwMark     uint32 // 0 if off
 	Peers      []PeerStatus
 }
 
-// PeerStatus represents the live runtime status of a single peer
-// as reported by `wg show`.
+// PeerStatus represents the live runtime status of a single peer.
 type PeerStatus struct {
 	PublicKey           string
+	HasPresharedKey     bool
 	Endpoint            string
-	AllowedIPs          string
-	LatestHandshake     time.Duration
-	TransferRx          string
-	TransferTx          string
+	AllowedIPs          []netip.Prefix
+	LatestHandshake     time.Time // zero if the peer never completed a handshake
+	TransferRx          uint64    // bytes received from the peer
+	TransferTx          uint64    // bytes sent to the peer
 	PersistentKeepalive int
 }
 
-// durationPartRe matches a single component like "1 minute" or "30 seconds".
-var durationPartRe = regexp.MustCompile(`(\d+)\s+(hour|minute|second)s?`)
-
-// GetStatus runs `wg show <name>` and parses the output into an InterfaceStatus.
-func GetStatus(name string) (*InterfaceStatus, error) {
-	out, err := runSudoWgCmd("show", name)
-	if err != nil {
-		return nil, fmt.Errorf("getting status for %s: %w", name, err)
-	}
-	return parseWgShow(out)
+// GetStatus returns the live status of the named interface from the
+// Manager's backend: netlink when available, `wg show` otherwise.
+func (m *Manager) GetStatus(name string) (*InterfaceStatus, error) {
+	return m.backend.Status(name)
 }
 
-// parseWgShow parses the raw text output of `wg show <name>` into an
-// InterfaceStatus. The output format uses indented key-value pairs grouped
-// under `interface:` and `peer:` headers.
-func parseWgShow(output string) (*InterfaceStatus, error) {
-	status := &InterfaceStatus{}
-	var currentPeer *PeerStatus
-
-	for _, line := range strings.Split(output, "\n") {
-		// Skip empty lines
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
+// GetAllStatus returns the live status of every active WireGuard interface,
+// sorted by name, in a single backend call.
+func (m *Manager) GetAllStatus() ([]*InterfaceStatus, error) {
+	return m.backend.AllStatus()
+}
 
-		// Check for section headers (not indented)
-		if strings.HasPrefix(line, "interface:") {
-			// Interface section — nothing to extract from header itself
+// parseWgDump parses the output of `wg show <name> dump`: one tab-separated
+// line for the interface (private key, public key, listen port, fwmark),
+// then one per peer (public key, preshared key, endpoint, allowed ips,
+// latest handshake, rx bytes, tx bytes, persistent keepalive).
+func parseWgDump(name, output string) (*InterfaceStatus, error) {
+	var status *InterfaceStatus
+	for i, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if i == 0 {
+			st, err := parseDumpInterface(name, fields)
+			if err != nil {
+				return nil, err
+			}
+			status = st
 			continue
 		}
-		if strings.HasPrefix(line, "peer:") {
-			pubKey := strings.TrimSpace(strings.TrimPrefix(line, "peer:"))
-			status.Peers = append(status.Peers, PeerStatus{PublicKey: pubKey})
-			currentPeer = &status.Peers[len(status.Peers)-1]
-			continue
+		peer, err := parseDumpPeer(fields)
+		if err != nil {
+			return nil, err
 		}
+		status.Peers = append(status.Peers, peer)
+	}
+	if status == nil {
+		return nil, fmt.Errorf("empty dump for %s", name)
+	}
+	return status, nil
+}
 
-		// All key-value lines are indented with 2 spaces
-		trimmed := strings.TrimSpace(line)
-		sepIdx := strings.Index(trimmed, ": ")
-		if sepIdx < 0 {
+// parseWgDumpAll parses the output of `wg show all dump`, where every line
+// of parseWgDump's format is prefixed with the interface name. Interfaces
+// are returned in the order wg lists them.
+func parseWgDumpAll(output string) ([]*InterfaceStatus, error) {
+	all := []*InterfaceStatus{}
+	byName := make(map[string]*InterfaceStatus)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
 			continue
 		}
-		key := trimmed[:sepIdx]
-		value := trimmed[sepIdx+2:]
-
-		// Determine whether this belongs to the interface or current peer
-		if currentPeer == nil {
-			// Interface section
-			switch key {
-			case "public key":
-				status.PublicKey = value
-			case "listening port":
-				port, err := strconv.Atoi(value)
-				if err != nil {
-					return nil, fmt.Errorf("parsing listening port %q: %w", value, err)
-				}
-				status.ListenPort = port
-			}
-		} else {
-			// Peer section
-			switch key {
-			case "endpoint":
-				currentPeer.Endpoint = value
-			case "allowed ips":
-				currentPeer.AllowedIPs = value
-			case "latest handshake":
-				d, err := parseHandshakeTime(value)
-				if err != nil {
-					return nil, fmt.Errorf("parsing handshake time %q: %w", value, err)
-				}
-				currentPeer.LatestHandshake = d
-			case "transfer":
-				rx, tx := parseTransfer(value)
-				currentPeer.TransferRx = rx
-				currentPeer.TransferTx = tx
-			case "persistent keepalive":
-				k, err := parseKeepalive(value)
-				if err != nil {
-					return nil, fmt.Errorf("parsing keepalive %q: %w", value, err)
-				}
-				currentPeer.PersistentKeepalive = k
+		fields := strings.Split(line, "\t")
+		name := fields[0]
+		st, ok := byName[name]
+		if !ok {
+			st, err := parseDumpInterface(name, fields[1:])
+			if err != nil {
+				return nil, err
 			}
+			byName[name] = st
+			all = append(all, st)
+			continue
+		}
+		peer, err := parseDumpPeer(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
 		}
+		st.Peers = append(st.Peers, peer)
 	}
-
-	return status, nil
+	return all, nil
 }
 
-// parseHandshakeTime parses strings like "1 minute, 30 seconds ago" into a
-// time.Duration. It extracts all hour/minute/second components using regex.
-func parseHandshakeTime(s string) (time.Duration, error) {
-	matches := durationPartRe.FindAllStringSubmatch(s, -1)
-	if len(matches) == 0 {
-		return 0, fmt.Errorf("no duration components found in %q", s)
+// parseDumpInterface parses the interface line of a dump. The private key
+// in the first field is deliberately not kept.
+func parseDumpInterface(name string, fields []string) (*InterfaceStatus, error) {
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("interface line for %s: got %d fields, want 4", name, len(fields))
 	}
+	st := &InterfaceStatus{Name: name, PublicKey: dumpValue(fields[1])}
 
-	var total time.Duration
-	for _, match := range matches {
-		n, err := strconv.Atoi(match[1])
+	port, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("parsing listening port %q: %w", fields[2], err)
+	}
+	st.ListenPort = port
+
+	if fields[3] != "off" {
+		mark, err := strconv.ParseUint(fields[3], 0, 32)
 		if err != nil {
-			return 0, fmt.Errorf("parsing number %q: %w", match[1], err)
+			return nil, fmt.Errorf("parsing fwmark %q: %w", fields[3], err)
 		}
-		switch match[2] {
-		case "hour":
-			total += time.Duration(n) * time.Hour
-		case "minute":
-			total += time.Duration(n) * time.Minute
-		case "second":
-			total += time.Duration(n) * time.Second
+		st.FwMark = uint32(mark)
+	}
+	return st, nil
+}
+
+// parseDumpPeer parses a peer line of a dump.
+func parseDumpPeer(fields []string) (PeerStatus, error) {
+	var peer PeerStatus
+	if len(fields) != 8 {
+		return peer, fmt.Errorf("peer line: got %d fields, want 8", len(fields))
+	}
+
+	peer.PublicKey = fields[0]
+	peer.HasPresharedKey = dumpValue(fields[1]) != ""
+	peer.Endpoint = dumpValue(fields[2])
+
+	if ips := dumpValue(fields[3]); ips != "" {
+		prefixes, err := ParsePrefixes(ips)
+		if err != nil {
+			return peer, fmt.Errorf("parsing allowed ips %q: %w", ips, err)
 		}
+		peer.AllowedIPs = prefixes
 	}
 
-	return total, nil
-}
+	handshake, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return peer, fmt.Errorf("parsing latest handshake %q: %w", fields[4], err)
+	}
+	if handshake != 0 {
+		peer.LatestHandshake = time.Unix(handshake, 0)
+	}
+
+	if peer.TransferRx, err = strconv.ParseUint(fields[5], 10, 64); err != nil {
+		return peer, fmt.Errorf("parsing transfer rx %q: %w", fields[5], err)
+	}
+	if peer.TransferTx, err = strconv.ParseUint(fields[6], 10, 64); err != nil {
+		return peer, fmt.Errorf("parsing transfer tx %q: %w", fields[6], err)
+	}
 
-// parseTransfer splits a transfer line like "1.50 MiB received, 3.24 MiB sent"
-// into separate rx and tx strings including units (e.g. "1.50 MiB", "3.24 MiB").
-func parseTransfer(s string) (rx, tx string) {
-	// Format: "X.XX UiB received, Y.YY UiB sent"
-	parts := strings.SplitN(s, ", ", 2)
-	if len(parts) == 2 {
-		rx = strings.TrimSuffix(parts[0], " received")
-		tx = strings.TrimSuffix(parts[1], " sent")
+	if fields[7] != "off" {
+		keepalive, err := strconv.Atoi(fields[7])
+		if err != nil {
+			return peer, fmt.Errorf("parsing persistent keepalive %q: %w", fields[7], err)
+		}
+		peer.PersistentKeepalive = keepalive
 	}
-	return rx, tx
+	return peer, nil
 }
 
-// parseKeepalive extracts the number of seconds from a string like
-// "every 25 seconds".
-func parseKeepalive(s string) (int, error) {
-	s = strings.TrimPrefix(s, "every ")
-	s = strings.TrimSuffix(s, " seconds")
-	n, err := strconv.Atoi(s)
-	if err != nil {
-		return 0, fmt.Errorf("parsing keepalive interval %q: %w", s, err)
+// dumpValue maps the dump's "(none)" placeholder to "".
+func dumpValue(s string) string {
+	if s == "(none)" {
+		return ""
 	}
-	return n, nil
+	return s
 }
@@ -1,41 +1,34 @@
 package wg
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
 
-const sampleWgShow = `interface: wg0
-  public key: xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
-  private key: (hidden)
-  listening port: 51820
+const sampleWgDump = "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=\txTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=\t51820\t0xca6c\n" +
+	"TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=\tFpCyhws9cxwWoV4xELtfJvjJN+zQVRPISllRWgeopVE=\t203.0.113.1:51820\t10.0.0.2/32,fd00::2/128\t1748779110\t1572864123\t3397386\t25\n" +
+	"gN65BkIKy1eCE9pP1wdc8ROUtkHLF2PfAqYdyYBz6EA=\t(none)\t(none)\t(none)\t0\t0\t0\toff\n"
 
-peer: TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=
-  endpoint: 203.0.113.1:51820
-  allowed ips: 10.0.0.2/32
-  latest handshake: 1 minute, 30 seconds ago
-  transfer: 1.50 MiB received, 3.24 MiB sent
-  persistent keepalive: every 25 seconds
-
-peer: abc123publickey=
-  endpoint: 198.51.100.1:51820
-  allowed ips: 10.0.0.3/32
-  latest handshake: 45 seconds ago
-  transfer: 500.00 KiB received, 120.00 KiB sent`
-
-func TestParseWgShow(t *testing.T) {
-	status, err := parseWgShow(sampleWgShow)
+func TestParseWgDump(t *testing.T) {
+	status, err := parseWgDump("wg0", sampleWgDump)
 	if err != nil {
-		t.Fatalf("parseWgShow() returned error: %v", err)
+		t.Fatalf("parseWgDump() returned error: %v", err)
 	}
 
 	// Verify interface fields
+	if status.Name != "wg0" {
+		t.Errorf("Name = %q, want %q", status.Name, "wg0")
+	}
 	if status.PublicKey != "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=" {
 		t.Errorf("PublicKey = %q, want %q", status.PublicKey, "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=")
 	}
 	if status.ListenPort != 51820 {
 		t.Errorf("ListenPort = %d, want %d", status.ListenPort, 51820)
 	}
+	if status.FwMark != 0xca6c {
+		t.Errorf("FwMark = %#x, want 0xca6c", status.FwMark)
+	}
 
 	// Verify peer count
 	if len(status.Peers) != 2 {
@@ -47,118 +40,108 @@ func TestParseWgShow(t *testing.T) {
 	if p0.PublicKey != "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=" {
 		t.Errorf("Peer[0].PublicKey = %q, want %q", p0.PublicKey, "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=")
 	}
+	if !p0.HasPresharedKey {
+		t.Error("Peer[0].HasPresharedKey = false, want true")
+	}
 	if p0.Endpoint != "203.0.113.1:51820" {
 		t.Errorf("Peer[0].Endpoint = %q, want %q", p0.Endpoint, "203.0.113.1:51820")
 	}
-	if p0.AllowedIPs != "10.0.0.2/32" {
-		t.Errorf("Peer[0].AllowedIPs = %q, want %q", p0.AllowedIPs, "10.0.0.2/32")
+	if got := FormatPrefixes(p0.AllowedIPs); got != "10.0.0.2/32, fd00::2/128" {
+		t.Errorf("Peer[0].AllowedIPs = %q, want %q", got, "10.0.0.2/32, fd00::2/128")
 	}
-	if p0.LatestHandshake != 90*time.Second {
-		t.Errorf("Peer[0].LatestHandshake = %v, want %v", p0.LatestHandshake, 90*time.Second)
+	if want := time.Unix(1748779110, 0); !p0.LatestHandshake.Equal(want) {
+		t.Errorf("Peer[0].LatestHandshake = %v, want %v", p0.LatestHandshake, want)
 	}
-	if p0.TransferRx != "1.50 MiB" {
-		t.Errorf("Peer[0].TransferRx = %q, want %q", p0.TransferRx, "1.50 MiB")
+	if p0.TransferRx != 1572864123 {
+		t.Errorf("Peer[0].TransferRx = %d, want %d", p0.TransferRx, 1572864123)
 	}
-	if p0.TransferTx != "3.24 MiB" {
-		t.Errorf("Peer[0].TransferTx = %q, want %q", p0.TransferTx, "3.24 MiB")
+	if p0.TransferTx != 3397386 {
+		t.Errorf("Peer[0].TransferTx = %d, want %d", p0.TransferTx, 3397386)
 	}
 	if p0.PersistentKeepalive != 25 {
 		t.Errorf("Peer[0].PersistentKeepalive = %d, want %d", p0.PersistentKeepalive, 25)
 	}
 
-	// Verify second peer
+	// Second peer has "(none)" placeholders, no handshake and keepalive off
 	p1 := status.Peers[1]
-	if p1.PublicKey != "abc123publickey=" {
-		t.Errorf("Peer[1].PublicKey = %q, want %q", p1.PublicKey, "abc123publickey=")
-	}
-	if p1.Endpoint != "198.51.100.1:51820" {
-		t.Errorf("Peer[1].Endpoint = %q, want %q", p1.Endpoint, "198.51.100.1:51820")
-	}
-	if p1.AllowedIPs != "10.0.0.3/32" {
-		t.Errorf("Peer[1].AllowedIPs = %q, want %q", p1.AllowedIPs, "10.0.0.3/32")
-	}
-	if p1.LatestHandshake != 45*time.Second {
-		t.Errorf("Peer[1].LatestHandshake = %v, want %v", p1.LatestHandshake, 45*time.Second)
-	}
-	if p1.TransferRx != "500.00 KiB" {
-		t.Errorf("Peer[1].TransferRx = %q, want %q", p1.TransferRx, "500.00 KiB")
+	if p1.HasPresharedKey || p1.Endpoint != "" || len(p1.AllowedIPs) != 0 {
+		t.Errorf("Peer[1] = %+v, want empty PSK, endpoint and allowed IPs", p1)
 	}
-	if p1.TransferTx != "120.00 KiB" {
-		t.Errorf("Peer[1].TransferTx = %q, want %q", p1.TransferTx, "120.00 KiB")
+	if !p1.LatestHandshake.IsZero() {
+		t.Errorf("Peer[1].LatestHandshake = %v, want zero (never)", p1.LatestHandshake)
 	}
-	// Second peer has no persistent keepalive — should be zero value
 	if p1.PersistentKeepalive != 0 {
 		t.Errorf("Peer[1].PersistentKeepalive = %d, want 0", p1.PersistentKeepalive)
 	}
 }
 
-func TestParseHandshakeTime(t *testing.T) {
-	tests := []struct {
-		name    string
-		input   string
-		want    time.Duration
-		wantErr bool
-	}{
-		{
-			name:  "minute and seconds",
-			input: "1 minute, 30 seconds ago",
-			want:  90 * time.Second,
-		},
-		{
-			name:  "seconds only",
-			input: "45 seconds ago",
-			want:  45 * time.Second,
-		},
-		{
-			name:  "hours minutes seconds",
-			input: "2 hours, 5 minutes, 10 seconds ago",
-			want:  2*time.Hour + 5*time.Minute + 10*time.Second,
-		},
+func TestParseWgDumpNoPeersFwMarkOff(t *testing.T) {
+	status, err := parseWgDump("wg1", "(none)\t(none)\t0\toff\n")
+	if err != nil {
+		t.Fatalf("parseWgDump() returned error: %v", err)
 	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			got, err := parseHandshakeTime(tc.input)
-			if tc.wantErr {
-				if err == nil {
-					t.Fatalf("parseHandshakeTime(%q) expected error, got nil", tc.input)
-				}
-				return
-			}
-			if err != nil {
-				t.Fatalf("parseHandshakeTime(%q) returned error: %v", tc.input, err)
-			}
-			if got != tc.want {
-				t.Errorf("parseHandshakeTime(%q) = %v, want %v", tc.input, got, tc.want)
-			}
-		})
+	if status.PublicKey != "" || status.ListenPort != 0 || status.FwMark != 0 || len(status.Peers) != 0 {
+		t.Errorf("parseWgDump() = %+v, want empty status", status)
 	}
 }
 
-func TestParseTransfer(t *testing.T) {
-	rx, tx := parseTransfer("1.50 MiB received, 3.24 MiB sent")
-	if rx != "1.50 MiB" {
-		t.Errorf("parseTransfer rx = %q, want %q", rx, "1.50 MiB")
-	}
-	if tx != "3.24 MiB" {
-		t.Errorf("parseTransfer tx = %q, want %q", tx, "3.24 MiB")
+func TestParseWgDumpAll(t *testing.T) {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(sampleWgDump), "\n") {
+		b.WriteString("wg0\t" + line + "\n")
 	}
+	b.WriteString("wg1\t(none)\tgN65BkIKy1eCE9pP1wdc8ROUtkHLF2PfAqYdyYBz6EA=\t51821\toff\n")
+	b.WriteString("wg1\tTrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc=\t(none)\t198.51.100.1:51820\t0.0.0.0/0\t1748779000\t10\t20\toff\n")
 
-	rx2, tx2 := parseTransfer("500.00 KiB received, 120.00 KiB sent")
-	if rx2 != "500.00 KiB" {
-		t.Errorf("parseTransfer rx = %q, want %q", rx2, "500.00 KiB")
+	all, err := parseWgDumpAll(b.String())
+	if err != nil {
+		t.Fatalf("parseWgDumpAll() returned error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("len(all) = %d, want 2", len(all))
+	}
+	if all[0].Name != "wg0" || len(all[0].Peers) != 2 {
+		t.Errorf("all[0] = %s with %d peers, want wg0 with 2", all[0].Name, len(all[0].Peers))
 	}
-	if tx2 != "120.00 KiB" {
-		t.Errorf("parseTransfer tx = %q, want %q", tx2, "120.00 KiB")
+	if all[1].Name != "wg1" || all[1].ListenPort != 51821 || len(all[1].Peers) != 1 {
+		t.Errorf("all[1] = %+v, want wg1 on 51821 with 1 peer", all[1])
+	}
+	if all[1].Peers[0].TransferTx != 20 {
+		t.Errorf("all[1].Peers[0].TransferTx = %d, want 20", all[1].Peers[0].TransferTx)
 	}
 }
 
-func TestParseKeepalive(t *testing.T) {
-	got, err := parseKeepalive("every 25 seconds")
+func TestParseWgDumpAllEmpty(t *testing.T) {
+	all, err := parseWgDumpAll("")
 	if err != nil {
-		t.Fatalf("parseKeepalive() returned error: %v", err)
+		t.Fatalf("parseWgDumpAll() returned error: %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("parseWgDumpAll(\"\") = %v, want empty", all)
 	}
-	if got != 25 {
-		t.Errorf("parseKeepalive() = %d, want 25", got)
+}
+
+func TestParseWgDumpErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"short interface line", "priv\tpub\t51820\n"},
+		{"bad port", "priv\tpub\tport\toff\n"},
+		{"bad fwmark", "priv\tpub\t51820\tmark\n"},
+		{"short peer line", "priv\tpub\t51820\toff\npeer\t(none)\n"},
+		{"bad handshake", "priv\tpub\t51820\toff\npeer\t(none)\t(none)\t(none)\tyesterday\t0\t0\toff\n"},
+		{"bad transfer", "priv\tpub\t51820\toff\npeer\t(none)\t(none)\t(none)\t0\t-1\t0\toff\n"},
+		{"bad allowed ips", "priv\tpub\t51820\toff\npeer\t(none)\t(none)\t10.0.0.300/32\t0\t0\t0\toff\n"},
+		{"bad keepalive", "priv\tpub\t51820\toff\npeer\t(none)\t(none)\t(none)\t0\t0\t0\tsometimes\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseWgDump("wg0", tc.input); err == nil {
+				t.Errorf("parseWgDump(%q) should return error", tc.input)
+			}
+		})
 	}
 }
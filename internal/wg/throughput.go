@@ -0,0 +1,126 @@
+package wg
+
+import "time"
+
+// Rate is the transfer rate of a peer over one interval between status
+// reads, in bytes per second.
+type Rate struct {
+	At     time.Time // end of the interval
+	Rx, Tx float64
+}
+
+// Throughput turns successive status reads of one interface into a rolling
+// history of per-peer rates.
+type Throughput struct {
+	keep  time.Duration
+	last  map[string]counters // previous read per peer public key
+	rates map[string][]Rate   // oldest first
+}
+
+type counters struct {
+	at     time.Time
+	rx, tx uint64
+}
+
+// NewThroughput returns a Throughput that keeps rates for keep.
+func NewThroughput(keep time.Duration) *Throughput {
+	return &Throughput{
+		keep:  keep,
+		last:  make(map[string]counters),
+		rates: make(map[string][]Rate),
+	}
+}
+
+// Add records a status read taken at now. A peer's first read only sets
+// its baseline. A counter that went backwards was reset (the interface
+// was recreated or the peer reconnected); the bytes since the reset are
+// counted from zero rather than yielding a negative rate. Peers missing
+// from s are forgotten.
+func (t *Throughput) Add(now time.Time, s *InterfaceStatus) {
+	seen := make(map[string]bool, len(s.Peers))
+	for _, p := range s.Peers {
+		seen[p.PublicKey] = true
+		cur := counters{at: now, rx: p.TransferRx, tx: p.TransferTx}
+		prev, ok := t.last[p.PublicKey]
+		t.last[p.PublicKey] = cur
+		secs := now.Sub(prev.at).Seconds()
+		if !ok || secs <= 0 {
+			continue
+		}
+
+		rates := append(t.rates[p.PublicKey], Rate{
+			At: now,
+			Rx: float64(counterDelta(prev.rx, cur.rx)) / secs,
+			Tx: float64(counterDelta(prev.tx, cur.tx)) / secs,
+		})
+		cut := 0
+		for cut < len(rates) && now.Sub(rates[cut].At) > t.keep {
+			cut++
+		}
+		t.rates[p.PublicKey] = rates[cut:]
+	}
+	for key := range t.last {
+		if !seen[key] {
+			delete(t.last, key)
+			delete(t.rates, key)
+		}
+	}
+}
+
+func counterDelta(prev, cur uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// Rates returns the rates of peer recorded within window before now,
+// oldest first.
+func (t *Throughput) Rates(peer string, now time.Time, window time.Duration) []Rate {
+	rates := t.rates[peer]
+	for i, r := range rates {
+		if now.Sub(r.At) <= window {
+			return rates[i:]
+		}
+	}
+	return nil
+}
+
+// Total returns the sum of every peer's latest rate, the current rate of
+// the whole interface. ok is false until a rate is known.
+func (t *Throughput) Total() (total Rate, ok bool) {
+	for _, rates := range t.rates {
+		last := rates[len(rates)-1]
+		total.Rx += last.Rx
+		total.Tx += last.Tx
+		if last.At.After(total.At) {
+			total.At = last.At
+		}
+	}
+	return total, len(t.rates) > 0
+}
+
+// RateStats summarizes rates: the latest, peak and average of each
+// direction. Averages weigh every interval equally.
+type RateStats struct {
+	Current, Peak, Average Rate
+}
+
+// Stats summarizes rates, which may be empty.
+func Stats(rates []Rate) RateStats {
+	var st RateStats
+	if len(rates) == 0 {
+		return st
+	}
+	for _, r := range rates {
+		st.Peak.Rx = max(st.Peak.Rx, r.Rx)
+		st.Peak.Tx = max(st.Peak.Tx, r.Tx)
+		st.Average.Rx += r.Rx
+		st.Average.Tx += r.Tx
+	}
+	n := float64(len(rates))
+	st.Average.Rx /= n
+	st.Average.Tx /= n
+	st.Current = rates[len(rates)-1]
+	return st
+}
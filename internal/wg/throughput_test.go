@@ -0,0 +1,61 @@
+package wg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThroughput(t *testing.T) {
+	t0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	read := func(rx, tx uint64) *InterfaceStatus {
+		return &InterfaceStatus{Name: "wg0", Peers: []PeerStatus{{PublicKey: testPubKey1, TransferRx: rx, TransferTx: tx}}}
+	}
+
+	tp := NewThroughput(10 * time.Second)
+	tp.Add(t0, read(1000, 500))
+	if got := tp.Rates(testPubKey1, t0, time.Minute); len(got) != 0 {
+		t.Fatalf("first read gave rates %v", got)
+	}
+
+	tp.Add(t0.Add(2*time.Second), read(3000, 900))  // +2000/+400 over 2s
+	tp.Add(t0.Add(4*time.Second), read(400, 100))   // reset: counted from zero
+	tp.Add(t0.Add(6*time.Second), read(400, 100))   // idle
+	tp.Add(t0.Add(14*time.Second), read(8400, 100)) // +8000 over 8s
+
+	now := t0.Add(14 * time.Second)
+	got := tp.Rates(testPubKey1, now, time.Minute)
+	want := []Rate{
+		{At: t0.Add(4 * time.Second), Rx: 200, Tx: 50},
+		{At: t0.Add(6 * time.Second), Rx: 0, Tx: 0},
+		{At: t0.Add(14 * time.Second), Rx: 1000, Tx: 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Rates() = %v, want %v (older than keep dropped)", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rate %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := tp.Rates(testPubKey1, now, 5*time.Second); len(got) != 1 {
+		t.Errorf("Rates(5s window) = %v, want only the last", got)
+	}
+
+	st := Stats(got)
+	if st.Current.Rx != 1000 || st.Peak.Rx != 1000 || st.Peak.Tx != 50 || st.Average.Rx != 400 {
+		t.Errorf("Stats() = %+v", st)
+	}
+
+	if total, ok := tp.Total(); !ok || total != want[2] {
+		t.Errorf("Total() = %+v, %v, want %+v", total, ok, want[2])
+	}
+
+	tp.Add(now.Add(2*time.Second), &InterfaceStatus{Name: "wg0"})
+	if got := tp.Rates(testPubKey1, now, time.Minute); len(got) != 0 {
+		t.Errorf("removed peer still has rates %v", got)
+	}
+	if _, ok := tp.Total(); ok {
+		t.Error("Total() ok with no peers")
+	}
+}
@@ -0,0 +1,243 @@
+package wg
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Severity classifies a Diagnostic.
+type Severity int
+
+const (
+	// SeverityWarning marks a config that works but is probably not what
+	// the user intended.
+	SeverityWarning Severity = iota
+	// SeverityError marks a config that wg-quick will reject or that will
+	// misroute traffic.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic is a single validation finding, tied to a config key.
+type Diagnostic struct {
+	Severity Severity
+	Peer     int    // index into Interface.Peers, or -1 for the [Interface] section
+	Field    string // config key, e.g. "ListenPort" or "AllowedIPs"
+	Message  string
+}
+
+// String renders the diagnostic with its location, e.g.
+// "Peer 2 AllowedIPs: 10.0.0.2/32 is also routed to peer 1".
+func (d Diagnostic) String() string {
+	if d.Peer < 0 {
+		return fmt.Sprintf("%s: %s", d.Field, d.Message)
+	}
+	return fmt.Sprintf("Peer %d %s: %s", d.Peer+1, d.Field, d.Message)
+}
+
+// HasErrors reports whether any diagnostic has error severity.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidInterfaceName checks that a name is safe for use as a Linux
+// network interface name: non-empty, at most 15 characters, and only
+// containing alphanumeric characters, hyphens, and underscores.
+func ValidInterfaceName(name string) bool {
+	if len(name) == 0 || len(name) > 15 {
+		return false
+	}
+	for _, c := range name {
+		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_') { //nolint:staticcheck // QF1001 De Morgan's form is less readable here
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks an interface configuration for problems that would make
+// wg-quick fail or silently misbehave. Diagnostics are returned in config
+// order: interface fields first, then each peer.
+func Validate(iface *Interface) []Diagnostic {
+	var diags []Diagnostic
+	add := func(sev Severity, peer int, field, format string, args ...any) {
+		diags = append(diags, Diagnostic{Severity: sev, Peer: peer, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if iface.Name != "" && !ValidInterfaceName(iface.Name) {
+		add(SeverityError, -1, "Name", "use only a-z, A-Z, 0-9, hyphen, underscore (max 15 chars)")
+	}
+
+	switch {
+	case iface.PrivateKey == "":
+		add(SeverityError, -1, "PrivateKey", "is required")
+	case !validKey(iface.PrivateKey):
+		add(SeverityError, -1, "PrivateKey", "must be a base64-encoded 32-byte key")
+	}
+
+	if len(iface.Address) == 0 {
+		add(SeverityWarning, -1, "Address", "no address set; the tunnel will have no IP")
+	}
+	for _, p := range iface.Address {
+		if !p.IsValid() {
+			add(SeverityError, -1, "Address", "invalid prefix")
+		}
+	}
+
+	if iface.ListenPort < 0 || iface.ListenPort > 65535 {
+		add(SeverityError, -1, "ListenPort", "%d is outside 0-65535", iface.ListenPort)
+	}
+
+	switch {
+	case iface.MTU == 0:
+	case iface.MTU < 68 || iface.MTU > 65535:
+		add(SeverityError, -1, "MTU", "%d is outside 68-65535", iface.MTU)
+	case iface.MTU < 1280:
+		add(SeverityWarning, -1, "MTU", "%d is below 1280, the minimum for IPv6", iface.MTU)
+	}
+
+	if _, err := ParseTable(iface.Table); err != nil {
+		add(SeverityError, -1, "Table", "%v", err)
+	}
+
+	seenKeys := make(map[string]int)
+	seenNames := make(map[string]int)
+	for i := range iface.Peers {
+		diags = append(diags, validatePeer(i, &iface.Peers[i])...)
+
+		if name := strings.ToLower(iface.Peers[i].Name); name != "" {
+			if first, ok := seenNames[name]; ok {
+				add(SeverityWarning, i, "Name", "also the name of peer %d", first+1)
+			} else {
+				seenNames[name] = i
+			}
+		}
+
+		pub := iface.Peers[i].PublicKey
+		if pub == "" {
+			continue
+		}
+		if first, ok := seenKeys[pub]; ok {
+			add(SeverityError, i, "PublicKey", "duplicate of peer %d", first+1)
+		} else {
+			seenKeys[pub] = i
+		}
+	}
+
+	diags = append(diags, allowedIPOverlaps(iface.Peers)...)
+
+	return diags
+}
+
+// validatePeer checks the fields of a single peer.
+func validatePeer(i int, peer *Peer) []Diagnostic {
+	var diags []Diagnostic
+	add := func(sev Severity, field, format string, args ...any) {
+		diags = append(diags, Diagnostic{Severity: sev, Peer: i, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case peer.PublicKey == "":
+		add(SeverityError, "PublicKey", "is required")
+	case !validKey(peer.PublicKey):
+		add(SeverityError, "PublicKey", "must be a base64-encoded 32-byte key")
+	}
+
+	if peer.PresharedKey != "" && !validKey(peer.PresharedKey) {
+		add(SeverityError, "PresharedKey", "must be a base64-encoded 32-byte key")
+	}
+
+	for _, p := range peer.AllowedIPs {
+		if !p.IsValid() {
+			add(SeverityError, "AllowedIPs", "invalid prefix")
+			continue
+		}
+		if p != p.Masked() {
+			add(SeverityWarning, "AllowedIPs", "%s has host bits set; wg will use %s", p, p.Masked())
+		}
+	}
+
+	if peer.Endpoint != "" {
+		if err := validateEndpoint(peer.Endpoint); err != nil {
+			add(SeverityError, "Endpoint", "%v", err)
+		}
+	}
+
+	if peer.PersistentKeepalive < 0 || peer.PersistentKeepalive > 65535 {
+		add(SeverityError, "PersistentKeepalive", "%d is outside 0-65535 seconds", peer.PersistentKeepalive)
+	}
+
+	return diags
+}
+
+// allowedIPOverlaps reports AllowedIPs shared between peers. wg routes an
+// identical prefix only to the last peer that claims it, so exact duplicates
+// are errors; nested prefixes are legal (longest match wins) but worth a
+// warning.
+func allowedIPOverlaps(peers []Peer) []Diagnostic {
+	var diags []Diagnostic
+	for j := range peers {
+		for _, pj := range peers[j].AllowedIPs {
+			if !pj.IsValid() {
+				continue
+			}
+			pj = pj.Masked()
+			for i := 0; i < j; i++ {
+				for _, pi := range peers[i].AllowedIPs {
+					if !pi.IsValid() {
+						continue
+					}
+					pi = pi.Masked()
+					switch {
+					case pi == pj:
+						diags = append(diags, Diagnostic{
+							Severity: SeverityError, Peer: j, Field: "AllowedIPs",
+							Message: fmt.Sprintf("%s is also routed to peer %d; only one peer can own it", pj, i+1),
+						})
+					case pi.Overlaps(pj):
+						diags = append(diags, Diagnostic{
+							Severity: SeverityWarning, Peer: j, Field: "AllowedIPs",
+							Message: fmt.Sprintf("%s overlaps %s of peer %d", pj, pi, i+1),
+						})
+					}
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// validateEndpoint checks host:port syntax. IPv6 hosts must be bracketed.
+func validateEndpoint(endpoint string) error {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return fmt.Errorf("must be host:port ([addr]:port for IPv6)")
+	}
+	if host == "" {
+		return fmt.Errorf("host is missing")
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("port %q is outside 1-65535", port)
+	}
+	return nil
+}
+
+// validKey reports whether s is a base64-encoded 32-byte WireGuard key.
+func validKey(s string) bool {
+	_, err := ParseKey(s)
+	return err == nil
+}
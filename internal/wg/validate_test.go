@@ -0,0 +1,100 @@
+package wg
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testPrivKey = "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk="
+	testPubKey1 = "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg="
+	testPubKey2 = "TrMvSoP4jYQlY6RIzBgbssQqY3vxI2piVFBs2LR9PQc="
+)
+
+// validInterface returns a config that produces no diagnostics.
+func validInterface() *Interface {
+	return &Interface{
+		Name:       "wg0",
+		PrivateKey: testPrivKey,
+		Address:    mustPrefixes("10.0.0.1/24"),
+		ListenPort: 51820,
+		Peers: []Peer{
+			{PublicKey: testPubKey1, AllowedIPs: mustPrefixes("10.0.0.2/32"), Endpoint: "203.0.113.1:51820", PersistentKeepalive: 25},
+			{PublicKey: testPubKey2, AllowedIPs: mustPrefixes("10.0.0.3/32")},
+		},
+	}
+}
+
+func TestValidateValidConfig(t *testing.T) {
+	if diags := Validate(validInterface()); len(diags) != 0 {
+		t.Errorf("Validate() = %v, want no diagnostics", diags)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(*Interface)
+		peer     int
+		field    string
+		severity Severity
+	}{
+		{"missing private key", func(i *Interface) { i.PrivateKey = "" }, -1, "PrivateKey", SeverityError},
+		{"short private key", func(i *Interface) { i.PrivateKey = "abc123=" }, -1, "PrivateKey", SeverityError},
+		{"bad interface name", func(i *Interface) { i.Name = "wg/0" }, -1, "Name", SeverityError},
+		{"no address", func(i *Interface) { i.Address = nil }, -1, "Address", SeverityWarning},
+		{"port too high", func(i *Interface) { i.ListenPort = 70000 }, -1, "ListenPort", SeverityError},
+		{"mtu too low", func(i *Interface) { i.MTU = 40 }, -1, "MTU", SeverityError},
+		{"mtu below ipv6 minimum", func(i *Interface) { i.MTU = 1200 }, -1, "MTU", SeverityWarning},
+		{"bad table", func(i *Interface) { i.Table = "two words" }, -1, "Table", SeverityError},
+		{"missing peer key", func(i *Interface) { i.Peers[1].PublicKey = "" }, 1, "PublicKey", SeverityError},
+		{"bad psk", func(i *Interface) { i.Peers[0].PresharedKey = "nope" }, 0, "PresharedKey", SeverityError},
+		{"duplicate peer key", func(i *Interface) { i.Peers[1].PublicKey = testPubKey1 }, 1, "PublicKey", SeverityError},
+		{"duplicate allowed ips", func(i *Interface) { i.Peers[1].AllowedIPs = mustPrefixes("10.0.0.2/32") }, 1, "AllowedIPs", SeverityError},
+		{"nested allowed ips", func(i *Interface) { i.Peers[1].AllowedIPs = mustPrefixes("10.0.0.0/24") }, 1, "AllowedIPs", SeverityWarning},
+		{"host bits in allowed ips", func(i *Interface) { i.Peers[1].AllowedIPs = mustPrefixes("10.9.0.1/24") }, 1, "AllowedIPs", SeverityWarning},
+		{"endpoint without port", func(i *Interface) { i.Peers[0].Endpoint = "vpn.example.com" }, 0, "Endpoint", SeverityError},
+		{"endpoint port zero", func(i *Interface) { i.Peers[0].Endpoint = "vpn.example.com:0" }, 0, "Endpoint", SeverityError},
+		{"unbracketed ipv6 endpoint", func(i *Interface) { i.Peers[0].Endpoint = "2001:db8::1:51820" }, 0, "Endpoint", SeverityError},
+		{"keepalive too high", func(i *Interface) { i.Peers[0].PersistentKeepalive = 70000 }, 0, "PersistentKeepalive", SeverityError},
+		{"duplicate peer name", func(i *Interface) { i.Peers[0].Name = "Laptop"; i.Peers[1].Name = "laptop" }, 1, "Name", SeverityWarning},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			iface := validInterface()
+			tc.mutate(iface)
+
+			diags := Validate(iface)
+			if len(diags) != 1 {
+				t.Fatalf("Validate() = %v, want exactly one diagnostic", diags)
+			}
+			d := diags[0]
+			if d.Peer != tc.peer || d.Field != tc.field || d.Severity != tc.severity {
+				t.Errorf("Validate() = {%d %s %s}, want {%d %s %s}", d.Peer, d.Field, d.Severity, tc.peer, tc.field, tc.severity)
+			}
+			if HasErrors(diags) != (tc.severity == SeverityError) {
+				t.Errorf("HasErrors() = %v for %s", HasErrors(diags), tc.severity)
+			}
+		})
+	}
+}
+
+func TestValidateBracketedIPv6Endpoint(t *testing.T) {
+	iface := validInterface()
+	iface.Peers[0].Endpoint = "[2001:db8::1]:51820"
+	if diags := Validate(iface); len(diags) != 0 {
+		t.Errorf("Validate() = %v, want no diagnostics", diags)
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{Severity: SeverityError, Peer: 1, Field: "AllowedIPs", Message: "overlaps"}
+	if got := d.String(); got != "Peer 2 AllowedIPs: overlaps" {
+		t.Errorf("String() = %q", got)
+	}
+	d.Peer = -1
+	if got := d.String(); !strings.HasPrefix(got, "AllowedIPs:") {
+		t.Errorf("String() = %q, want interface diagnostic without peer prefix", got)
+	}
+}
@@ -1,15 +1,61 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mlu/wireguard-tui/internal/cli"
+	"github.com/mlu/wireguard-tui/internal/notify"
+	"github.com/mlu/wireguard-tui/internal/settings"
 	"github.com/mlu/wireguard-tui/internal/tui"
+	wg "github.com/mlu/wireguard-tui/internal/wg"
 )
 
+// dirList collects a repeatable string flag.
+type dirList []string
+
+func (d *dirList) String() string { return strings.Join(*d, ",") }
+
+func (d *dirList) Set(v string) error {
+	*d = append(*d, v)
+	return nil
+}
+
 func main() {
+	var configDirs dirList
+	flag.Var(&configDirs, "config-dir", "profile directory; repeat to merge several (default /etc/wireguard, or $"+settings.EnvConfigDir+")")
+	settingsPath := flag.String("settings", settings.DefaultPath(), "settings file")
+	privilege := flag.String("privilege", "", "privilege method: auto, sudo, doas, pkexec, root or cap (default $"+settings.EnvPrivilege+")")
+	flag.Usage = func() {
+		cli.Usage(flag.CommandLine.Output())
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	s, err := settings.Load(*settingsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	priv, err := wg.NewPrivileged(settings.ResolvePrivilege(*privilege, os.Getenv(settings.EnvPrivilege), s))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	runner := wg.ExecRunner{Privileged: priv}
+	mgr := wg.NewManager(runner)
+	mgr.SetConfigDirs(settings.ResolveConfigDirs(configDirs, os.Getenv(settings.EnvConfigDir), s))
+
+	if flag.NArg() > 0 {
+		os.Exit(cli.Run(mgr, flag.Args(), os.Stdout, os.Stderr))
+	}
+
 	for _, bin := range []string{"wg", "wg-quick"} {
 		if _, err := exec.LookPath(bin); err != nil {
 			fmt.Fprintf(os.Stderr, "Required binary not found: %s\n", bin)
@@ -17,7 +63,8 @@ func main() {
 		}
 	}
 
-	p := tea.NewProgram(tui.NewApp(), tea.WithAltScreen())
+	app := tui.NewApp(mgr).WithNotifications(notify.New(runner), s.NotifyFor)
+	p := tea.NewProgram(app, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)